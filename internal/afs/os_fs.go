@@ -4,3 +4,10 @@ package afs
 type OsFS interface {
 	OsFs()
 }
+
+// AvailableSpaceCapable should be implemented by Filesystem implementations that are backed by a storage with a limited
+// capacity (e.g. a disk) and that are able to cheaply report the space available to the current process.
+type AvailableSpaceCapable interface {
+	// AvailableSpace returns the number of bytes available in the storage containing the given path.
+	AvailableSpace(path string) (int64, error)
+}
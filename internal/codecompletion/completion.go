@@ -3,6 +3,7 @@ package codecompletion
 import (
 	"slices"
 	"strings"
+	"unicode"
 
 	"github.com/inoxlang/inox/internal/core"
 	"github.com/inoxlang/inox/internal/core/permkind"
@@ -66,6 +67,12 @@ func FindCompletions(args SearchArgs) []Completion {
 	cursorIndex := args.CursorIndex
 	mode := args.Mode
 
+	//a cursor located after the end of the chunk (e.g. after a partial identifier at the end of the input)
+	//is moved to the end of the chunk.
+	if length := len(chunk.Runes()); cursorIndex > length {
+		cursorIndex = length
+	}
+
 	var completions []Completion
 	var nodeAtCursor parse.Node
 	var _parent parse.Node
@@ -81,6 +88,11 @@ func FindCompletions(args SearchArgs) []Completion {
 			return parse.Prune, nil
 		}
 
+		//the missing configuration of a module import overlaps the end of the source (e.g. import lib ./)
+		if _, ok := node.(*parse.MissingExpression); ok && utils.Implements[*parse.ImportStatement](parent) {
+			return parse.Prune, nil
+		}
+
 		if nodeAtCursor == nil || node.Base().IncludedIn(nodeAtCursor) {
 			nodeAtCursor = node
 
@@ -144,7 +156,21 @@ func FindCompletions(args SearchArgs) []Completion {
 	case *parse.GlobalVariable:
 		completions = handleGlobalVariableCompletions(n, search)
 	case *parse.IdentifierLiteral:
-		completions = handleIdentifierAndKeywordCompletions(n, deepestCall, search)
+		if isPathOrURLExpressionWithInterpolations(_parent) {
+			completions = findInterpolationVariableCompletions(n, n.Name, search)
+		} else {
+			completions = handleIdentifierAndKeywordCompletions(n, deepestCall, search)
+		}
+	case *parse.UnknownNode:
+		//empty interpolation
+		if isPathOrURLExpressionWithInterpolations(_parent) {
+			completions = findInterpolationVariableCompletions(n, "", search)
+		}
+
+		//lone '%' in the subject slot of a lifetime job (lifetimejob #job for %)
+		if job, ok := _parent.(*parse.LifetimejobExpression); ok && job.Subject == n {
+			completions = findLifetimejobSubjectCompletions(job, search)
+		}
 	case *parse.IdentifierMemberExpression:
 		completions = handleIdentifierMemberCompletions(n, search)
 	case *parse.MemberExpression:
@@ -153,12 +179,33 @@ func FindCompletions(args SearchArgs) []Completion {
 		completions = handleDoubleColonExpressionCompletions(n, search)
 	case *parse.CallExpression: //if a call is the deepest node at cursor it means we are not in an argument
 		completions = handleNewCallArgumentCompletions(n, search)
+	case *parse.FunctionExpression:
+		completions = findFunctionReturnTypeCompletions(n, search)
+	case *parse.Block:
+		//the cursor may be located right before the body of a function.
+		if fnExpr, ok := _parent.(*parse.FunctionExpression); ok && cursorIndex == int(n.Span.Start) {
+			search.ancestorChain = _ancestorChain[:len(_ancestorChain)-1]
+			if len(search.ancestorChain) > 0 {
+				search.parent = search.ancestorChain[len(search.ancestorChain)-1]
+			}
+			completions = findFunctionReturnTypeCompletions(fnExpr, search)
+		}
 	case *parse.QuotedStringLiteral:
 		completions = findStringCompletions(n, search)
+	case *parse.RuneLiteral:
+		completions = findRuneEscapeCompletions(n, search)
 	case *parse.RelativePathLiteral:
-		completions = findPathCompletions(state.Global.Ctx, n.Raw)
+		if isImportSource(n, _parent) {
+			completions = findImportPathCompletions(state.Global.Ctx, n.Raw, search)
+		} else {
+			completions = findPathCompletions(state.Global.Ctx, n.Raw)
+		}
 	case *parse.AbsolutePathLiteral:
-		completions = findPathCompletions(state.Global.Ctx, n.Raw)
+		if isImportSource(n, _parent) {
+			completions = findImportPathCompletions(state.Global.Ctx, n.Raw, search)
+		} else {
+			completions = findPathCompletions(state.Global.Ctx, n.Raw)
+		}
 	case *parse.URLLiteral:
 		completions = findURLCompletions(state.Global.Ctx, n, search)
 	case *parse.URLPatternLiteral:
@@ -456,6 +503,118 @@ func handleGlobalVariableCompletions(n *parse.GlobalVariable, search completionS
 	return completions
 }
 
+func isPathOrURLExpressionWithInterpolations(n parse.Node) bool {
+	switch n.(type) {
+	case *parse.AbsolutePathExpression, *parse.RelativePathExpression, *parse.URLExpression, *parse.URLQueryParameter:
+		return true
+	}
+	return false
+}
+
+// findManifestLiteralValueCompletions suggests literal values (true, false, nil) for the value of a property
+// in the invocation section or in a database description. The returned boolean is false if the property
+// is not located in one of these places or if its value is neither a boolean nor nilable.
+func findManifestLiteralValueCompletions(ident *parse.IdentifierLiteral, prop *parse.ObjectProperty, ancestors []parse.Node) ([]Completion, bool) {
+	ancestorCount := len(ancestors)
+
+	var literalValues map[string][]string
+
+	switch {
+	//property of database description
+	case ancestorCount >= 7 && utils.Implements[*parse.Manifest](ancestors[ancestorCount-7]) &&
+		utils.Implements[*parse.ObjectLiteral](ancestors[ancestorCount-6]) &&
+		utils.Implements[*parse.ObjectProperty](ancestors[ancestorCount-5]) &&
+		ancestors[ancestorCount-5].(*parse.ObjectProperty).HasNameEqualTo(core.MANIFEST_DATABASES_SECTION_NAME) &&
+		utils.Implements[*parse.ObjectLiteral](ancestors[ancestorCount-4]) &&
+		utils.Implements[*parse.ObjectProperty](ancestors[ancestorCount-3]) &&
+		utils.Implements[*parse.ObjectLiteral](ancestors[ancestorCount-2]):
+
+		literalValues = MANIFEST_DB_DESC_LITERAL_VALUE_COMPLETIONS
+	//property of the invocation section
+	case ancestorCount >= 5 && utils.Implements[*parse.Manifest](ancestors[ancestorCount-5]) &&
+		utils.Implements[*parse.ObjectLiteral](ancestors[ancestorCount-4]) &&
+		utils.Implements[*parse.ObjectProperty](ancestors[ancestorCount-3]) &&
+		ancestors[ancestorCount-3].(*parse.ObjectProperty).HasNameEqualTo(core.MANIFEST_INVOCATION_SECTION_NAME) &&
+		utils.Implements[*parse.ObjectLiteral](ancestors[ancestorCount-2]):
+
+		literalValues = MANIFEST_INVOCATION_SECTION_LITERAL_VALUE_COMPLETIONS
+	default:
+		return nil, false
+	}
+
+	values, ok := literalValues[prop.Name()]
+	if !ok {
+		return nil, false
+	}
+
+	var completions []Completion
+
+	for _, value := range values {
+		if hasPrefixCaseInsensitive(value, ident.Name) {
+			completions = append(completions, Completion{
+				ShownString: value,
+				Value:       value,
+				Kind:        defines.CompletionItemKindConstant,
+			})
+		}
+	}
+
+	return completions, true
+}
+
+// findInterpolationVariableCompletions suggests the names of the local and global variables that are accessible
+// from an interpolation in a path or URL expression.
+func findInterpolationVariableCompletions(n parse.Node, prefix string, search completionSearch) []Completion {
+	state := search.state
+	ctx := state.Global.Ctx
+	mode := search.mode
+	ancestorChain := search.ancestorChain
+
+	var completions []Completion
+	suggestedNames := map[string]struct{}{}
+
+	addCompletion := func(name string, detail string) {
+		if _, ok := suggestedNames[name]; ok || !hasPrefixCaseInsensitive(name, prefix) {
+			return
+		}
+		suggestedNames[name] = struct{}{}
+
+		completions = append(completions, Completion{
+			ShownString: name,
+			Value:       name,
+			Kind:        defines.CompletionItemKindVariable,
+			LabelDetail: detail,
+		})
+	}
+
+	//local variables are suggested first because they shadow globals.
+
+	if mode == ShellCompletions {
+		for name, varVal := range state.CurrentLocalScope() {
+			detail, _ := core.GetStringifiedSymbolicValue(ctx, varVal, false)
+			addCompletion(name, detail)
+		}
+
+		state.Global.Globals.Foreach(func(name string, varVal core.Value, _ bool) error {
+			detail, _ := core.GetStringifiedSymbolicValue(ctx, varVal, false)
+			addCompletion(name, detail)
+			return nil
+		})
+	} else {
+		localScopeData, _ := state.Global.SymbolicData.GetLocalScopeData(n, ancestorChain)
+		for _, varData := range localScopeData.Variables {
+			addCompletion(varData.Name, symbolic.Stringify(varData.Value))
+		}
+
+		globalScopeData, _ := state.Global.SymbolicData.GetGlobalScopeData(n, ancestorChain)
+		for _, varData := range globalScopeData.Variables {
+			addCompletion(varData.Name, symbolic.Stringify(varData.Value))
+		}
+	}
+
+	return completions
+}
+
 func handleIdentifierAndKeywordCompletions(ident *parse.IdentifierLiteral, deepestCall *parse.CallExpression, search completionSearch) []Completion {
 	ancestors := search.ancestorChain
 	state := search.state
@@ -530,6 +689,14 @@ after_subcommand_completions:
 		prop := parent.(*parse.ObjectProperty)
 		objectLiteral := ancestors[ancestorCount-2].(*parse.ObjectLiteral)
 
+		//suggest true, false and nil if the identifier is the value of a boolean or nilable property of a manifest section
+		if !prop.HasImplicitKey() && prop.Value == ident {
+			literalValueCompletions, ok := findManifestLiteralValueCompletions(ident, prop, ancestors)
+			if ok {
+				return literalValueCompletions
+			}
+		}
+
 		//suggest sections of manifest
 		if utils.Implements[*parse.Manifest](ancestors[ancestorCount-3]) {
 			manifestObject := objectLiteral
@@ -730,6 +897,23 @@ after_subcommand_completions:
 				return completions
 			}
 
+			//case: the identifier may be the beginning of a metaproperty key.
+			if prop.HasImplicitKey() && strings.HasPrefix(ident.Name, "_") {
+				for _, name := range OBJECT_METAPROPERTY_NAMES {
+					if !strings.HasPrefix(name, ident.Name) || hasMetaproperty(objectLiteral, name) {
+						continue
+					}
+
+					value := name + " " + OBJECT_METAPROPERTY_INITIALIZATION_BLOCK_COMPLETIONS[name]
+					completions = append(completions, Completion{
+						ShownString:           value,
+						Value:                 value,
+						Kind:                  defines.CompletionItemKindProperty,
+						MarkdownDocumentation: OBJECT_METAPROPERTY_DOC[name],
+					})
+				}
+			}
+
 			properties, ok := state.Global.SymbolicData.GetAllowedNonPresentProperties(objectLiteral)
 			if !ok {
 				break
@@ -775,8 +959,11 @@ after_subcommand_completions:
 		switch {
 		case ident == attribute.Name:
 			completions = findXmlAttributeNameCompletions(ident, attribute, ancestors)
+			return completions
+		case ident != attribute.Value:
+			return completions
 		}
-		return completions
+		//the value is an expression: the variables in scope are suggested below.
 	case *parse.XMLOpeningElement:
 		//if tag name
 		switch {
@@ -784,6 +971,14 @@ after_subcommand_completions:
 			completions = findXmlTagAndTagNameCompletions(ident, search)
 		}
 		return completions
+	case *parse.MultiAssignment:
+		//the variables of a multi-assignment are being declared: suggesting the existing variables would lead to shadowing.
+		isDeclaredVariable := utils.Some(p.Variables, func(e parse.Node) bool {
+			return ident == e
+		})
+		if isDeclaredVariable {
+			return nil
+		}
 	}
 
 	callExpr, ok := parent.(*parse.CallExpression)
@@ -844,12 +1039,22 @@ after_subcommand_completions:
 					}
 				}
 
-				completions = append(completions, Completion{
+				completion := Completion{
 					ShownString: name,
 					Value:       name,
 					Kind:        defines.CompletionItemKindVariable,
 					LabelDetail: detail,
-				})
+				}
+
+				//the detail of functions is their signature, the documentation is added if help is found.
+				if goFunc, ok := varVal.(*core.GoFunction); ok {
+					completion.Kind = defines.CompletionItemKindFunction
+					completion.MarkdownDocumentation, _ = help.HelpForGoFunc(goFunc.GoFunc(), helpMessageConfig)
+				} else if _, ok := varVal.(*core.InoxFunction); ok {
+					completion.Kind = defines.CompletionItemKindFunction
+				}
+
+				completions = append(completions, completion)
 			}
 			return nil
 		})
@@ -874,12 +1079,16 @@ after_subcommand_completions:
 					LabelDetail: symbolic.Stringify(varData.Value),
 				}
 
-				symbolicFunc, ok := varData.Value.(*symbolic.GoFunction)
-				if ok {
-					help, ok := help.HelpForSymbolicGoFunc(symbolicFunc, helpMessageConfig)
+				//the detail of functions is their signature, the documentation is added if help is found.
+				switch fn := varData.Value.(type) {
+				case *symbolic.GoFunction:
+					completion.Kind = defines.CompletionItemKindFunction
+					help, ok := help.HelpForSymbolicGoFunc(fn, helpMessageConfig)
 					if ok {
 						completion.MarkdownDocumentation = help
 					}
+				case *symbolic.InoxFunction:
+					completion.Kind = defines.CompletionItemKindFunction
 				}
 
 				completions = append(completions, completion)
@@ -1451,6 +1660,129 @@ top_loop:
 	return completions
 }
 
+// findLifetimejobSubjectCompletions suggests the patterns in scope for the subject of a lifetime job.
+// Lifetime jobs without a subject (object property values) are not handled.
+func findLifetimejobSubjectCompletions(job *parse.LifetimejobExpression, search completionSearch) (completions []Completion) {
+	if job.Subject == nil {
+		return nil
+	}
+
+	if search.mode == ShellCompletions {
+		ctx := search.state.Global.Ctx
+		for name, patt := range ctx.GetNamedPatterns() {
+			detail, _ := core.GetStringifiedSymbolicValue(ctx, patt, false)
+			completions = append(completions, Completion{
+				ShownString: "%" + name,
+				Value:       "%" + name,
+				Kind:        defines.CompletionItemKindInterface,
+				LabelDetail: detail,
+			})
+		}
+		return
+	}
+
+	ancestorChain := search.ancestorChain
+	if len(ancestorChain) > 0 && ancestorChain[len(ancestorChain)-1] == job {
+		ancestorChain = ancestorChain[:len(ancestorChain)-1]
+	}
+
+	contextData, _ := search.state.Global.SymbolicData.GetContextData(job, ancestorChain)
+	for _, patternData := range contextData.Patterns {
+		completions = append(completions, Completion{
+			ShownString: "%" + patternData.Name,
+			Value:       "%" + patternData.Name,
+			Kind:        defines.CompletionItemKindInterface,
+			LabelDetail: symbolic.Stringify(patternData.Value),
+		})
+	}
+	return
+}
+
+// findFunctionReturnTypeCompletions suggests the return type of a function if the cursor is located between the
+// parameters and the body. Patterns and pointer types are suggested, struct types are not because they are not
+// allowed as return types.
+func findFunctionReturnTypeCompletions(n *parse.FunctionExpression, search completionSearch) (completions []Completion) {
+	chunk := search.chunk
+	cursorIndex := int32(search.cursorIndex)
+	runes := chunk.Runes()
+
+	if n.ReturnType != nil || n.Body == nil || cursorIndex > n.Body.Base().Span.Start || int(cursorIndex) > len(runes) {
+		return nil
+	}
+
+	//the cursor should be located after the closing parenthesis of the parameters.
+	prevIndex := cursorIndex - 1
+	for prevIndex >= n.Span.Start && unicode.IsSpace(runes[prevIndex]) {
+		prevIndex--
+	}
+	if prevIndex < n.Span.Start || runes[prevIndex] != ')' {
+		return nil
+	}
+
+	pos := chunk.GetSourcePosition(parse.NodeSpan{Start: cursorIndex, End: cursorIndex})
+
+	//patterns
+	if search.mode == ShellCompletions {
+		ctx := search.state.Global.Ctx
+		for name, patt := range ctx.GetNamedPatterns() {
+			detail, _ := core.GetStringifiedSymbolicValue(ctx, patt, false)
+			completions = append(completions, Completion{
+				ShownString:   "%" + name,
+				Value:         "%" + name,
+				Kind:          defines.CompletionItemKindInterface,
+				LabelDetail:   detail,
+				ReplacedRange: pos,
+			})
+		}
+	} else {
+		contextData, _ := search.state.Global.SymbolicData.GetContextData(n, search.ancestorChain)
+		for _, patternData := range contextData.Patterns {
+			completions = append(completions, Completion{
+				ShownString:   "%" + patternData.Name,
+				Value:         "%" + patternData.Name,
+				Kind:          defines.CompletionItemKindInterface,
+				LabelDetail:   symbolic.Stringify(patternData.Value),
+				ReplacedRange: pos,
+			})
+		}
+	}
+
+	//pointer types
+	var moduleStatements []parse.Node
+	for i := len(search.ancestorChain) - 1; i >= 0; i-- {
+		switch module := search.ancestorChain[i].(type) {
+		case *parse.Chunk:
+			moduleStatements = module.Statements
+		case *parse.EmbeddedModule:
+			moduleStatements = module.Statements
+		default:
+			continue
+		}
+		break
+	}
+
+	for _, stmt := range moduleStatements {
+		structDef, ok := stmt.(*parse.StructDefinition)
+		if !ok {
+			continue
+		}
+		name, ok := structDef.GetName()
+		if !ok {
+			continue
+		}
+
+		completions = append(completions, Completion{
+			ShownString:           "*" + name,
+			Value:                 "*" + name,
+			Kind:                  defines.CompletionItemKindStruct,
+			MarkdownDocumentation: core.STRUCT_TYPES_NOT_ALLOWED_AS_RETURN_TYPES,
+			ReplacedRange:         pos,
+		})
+	}
+
+	return completions
+}
+
 func findObjectInteriorCompletions(n *parse.ObjectLiteral, search completionSearch) (completions []Completion) {
 	chunk := search.chunk
 	cursorIndex := int32(search.cursorIndex)
@@ -1521,6 +1853,7 @@ func findObjectInteriorCompletions(n *parse.ObjectLiteral, search completionSear
 			completions = append(completions, Completion{
 				ShownString:           sectionName + suffix,
 				Value:                 sectionName + suffix,
+				LabelDetail:           MODULE_IMPORT_SECTION_LABEL_DETAILS[sectionName],
 				MarkdownDocumentation: MODULE_IMPORT_SECTION_DOC[sectionName],
 				Kind:                  defines.CompletionItemKindVariable,
 				ReplacedRange:         pos,
@@ -1554,6 +1887,25 @@ func findObjectInteriorCompletions(n *parse.ObjectLiteral, search completionSear
 				ReplacedRange:         pos,
 			})
 		}
+	case *parse.InitializationBlock:
+		if !isVisibilityDescription(n, ancestors) {
+			break
+		}
+		//suggest the keys of the visibility description that are not present
+		for _, key := range VISIBILITY_DESC_KEYS {
+			if n.HasNamedProp(key) {
+				continue
+			}
+
+			value := key + ": " + VISIBILITY_DESC_DEFAULT_VALUE_COMPLETIONS[key]
+			completions = append(completions, Completion{
+				ShownString:   value,
+				Value:         value,
+				LabelDetail:   VISIBILITY_DESC_LABEL_DETAILS[key],
+				Kind:          defines.CompletionItemKindProperty,
+				ReplacedRange: pos,
+			})
+		}
 	case *parse.ObjectProperty:
 		if parent.HasImplicitKey() || len(ancestors) < 3 {
 			return
@@ -1639,6 +1991,27 @@ func findObjectInteriorCompletions(n *parse.ObjectLiteral, search completionSear
 		//the cursor is located in the span of an object inside a manifest section.
 
 		switch manifestSectionName {
+		case core.MANIFEST_PERMS_SECTION_NAME:
+			//suggest the forms of the values accepted for the permission kind.
+
+			if parent.Value != n {
+				break
+			}
+
+			kind, ok := permkind.PermissionKindFromString(parent.Name())
+			if !ok {
+				break
+			}
+
+			for _, template := range PERM_KIND_VALUE_TEMPLATES[kind.Major()] {
+				completions = append(completions, Completion{
+					ShownString:   template.Value,
+					Value:         template.Value,
+					Kind:          defines.CompletionItemKindValue,
+					LabelDetail:   template.LabelDetail,
+					ReplacedRange: pos,
+				})
+			}
 		case core.MANIFEST_DATABASES_SECTION_NAME:
 			//suggest database description's properties
 
@@ -1730,10 +2103,57 @@ func findDictionaryInteriorCompletions(n *parse.DictionaryLiteral, search comple
 		}
 	}
 
+	//suggest the #self entry in the 'visible_by' dictionary of a visibility description.
+	ancestors := search.ancestorChain
+	if prop, ok := search.parent.(*parse.ObjectProperty); ok && len(ancestors) >= 3 &&
+		prop.HasNameEqualTo(core.VISIBILITY_DESC_VISIBLE_BY_KEY) && prop.Value == n {
+
+		desc, ok := ancestors[len(ancestors)-2].(*parse.ObjectLiteral)
+		if ok && isVisibilityDescription(desc, ancestors[:len(ancestors)-2]) && !hasSelfDictEntry(n) {
+			completions = append(completions, Completion{
+				ShownString:   VISIBILITY_DESC_SELF_ENTRY_COMPLETION,
+				Value:         VISIBILITY_DESC_SELF_ENTRY_COMPLETION,
+				LabelDetail:   VISIBILITY_DESC_SELF_ENTRY_LABEL_DETAIL,
+				Kind:          defines.CompletionItemKindProperty,
+				ReplacedRange: pos,
+			})
+		}
+	}
+
 	return
 }
 
+// isVisibilityDescription returns true if obj is the object in the initialization block of a _visibility_ metaproperty,
+// ancestors should be the ancestors of obj.
+func isVisibilityDescription(obj *parse.ObjectLiteral, ancestors []parse.Node) bool {
+	if len(ancestors) < 2 {
+		return false
+	}
+
+	block, ok := ancestors[len(ancestors)-1].(*parse.InitializationBlock)
+	if !ok || len(block.Statements) == 0 || block.Statements[0] != obj {
+		return false
+	}
+
+	metaprop, ok := ancestors[len(ancestors)-2].(*parse.ObjectMetaProperty)
+	return ok && metaprop.Name() == core.VISIBILITY_KEY
+}
+
+func hasSelfDictEntry(dict *parse.DictionaryLiteral) bool {
+	for _, entry := range dict.Entries {
+		key, ok := entry.Key.(*parse.UnambiguousIdentifierLiteral)
+		if ok && key.Name == core.VISIBILITY_DESC_SELF_KEY {
+			return true
+		}
+	}
+	return false
+}
+
 func findStringCompletions(strLit *parse.QuotedStringLiteral, search completionSearch) (completions []Completion) {
+	if completions, ok := findStringEscapeCompletions(strLit, search); ok {
+		return completions
+	}
+
 	// in attribute
 	if attribute, ok := search.parent.(*parse.XMLAttribute); ok {
 		switch {
@@ -1747,6 +2167,77 @@ func findStringCompletions(strLit *parse.QuotedStringLiteral, search completionS
 	return
 }
 
+// findStringEscapeCompletions suggests escape sequences if the cursor directly follows an unescaped backslash
+// inside strLit. Multiline string literals are not handled because they have different escaping rules.
+func findStringEscapeCompletions(strLit *parse.QuotedStringLiteral, search completionSearch) (completions []Completion, ok bool) {
+	runes := search.chunk.Runes()
+	cursorIndex := int32(search.cursorIndex)
+	span := strLit.Span
+
+	//the cursor should be after the opening quote and before the closing quote (if present).
+	isTerminated := strLit.Err == nil && span.End-span.Start >= 2 && runes[span.End-1] == '"'
+	if cursorIndex <= span.Start+1 || (isTerminated && cursorIndex >= span.End) || int(cursorIndex) > len(runes) {
+		return nil, false
+	}
+
+	if runes[cursorIndex-1] != '\\' || utils.CountPrevBackslashes(runes, cursorIndex)%2 == 0 {
+		return nil, false
+	}
+
+	//replace the backslash.
+	pos := search.chunk.GetSourcePosition(parse.NodeSpan{Start: cursorIndex - 1, End: cursorIndex})
+
+	for _, escape := range STRING_ESCAPE_SEQUENCES {
+		completions = append(completions, Completion{
+			ShownString:   escape.Sequence,
+			Value:         escape.Sequence,
+			Kind:          defines.CompletionItemKindConstant,
+			LabelDetail:   escape.Description,
+			ReplacedRange: pos,
+		})
+	}
+	return completions, true
+}
+
+// findRuneEscapeCompletions suggests rune literals with an escape sequence (e.g. '\n') if runeLit is incomplete and
+// the cursor directly follows the opening quote or the backslash after the opening quote. The whole literal is replaced.
+func findRuneEscapeCompletions(runeLit *parse.RuneLiteral, search completionSearch) (completions []Completion) {
+	if runeLit.Err == nil {
+		return nil
+	}
+
+	runes := search.chunk.Runes()
+	cursorIndex := int32(search.cursorIndex)
+	span := runeLit.Span
+
+	switch {
+	case cursorIndex == span.Start+1:
+	case cursorIndex == span.Start+2 && int(cursorIndex) <= len(runes) && runes[cursorIndex-1] == '\\':
+	default:
+		return nil
+	}
+
+	for _, escape := range RUNE_ESCAPE_SEQUENCES {
+		literal := "'" + escape.Sequence + "'"
+		completions = append(completions, Completion{
+			ShownString: literal,
+			Value:       literal,
+			Kind:        defines.CompletionItemKindConstant,
+			LabelDetail: escape.Description,
+		})
+	}
+	return completions
+}
+
 func hasPrefixCaseInsensitive(s, prefix string) bool {
 	return strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix))
 }
+
+func hasMetaproperty(objectLiteral *parse.ObjectLiteral, name string) bool {
+	for _, metaprop := range objectLiteral.MetaProperties {
+		if metaprop.Name() == name {
+			return true
+		}
+	}
+	return false
+}
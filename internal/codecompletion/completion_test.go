@@ -14,6 +14,7 @@ import (
 	"github.com/inoxlang/inox/internal/globals/globalnames"
 	"github.com/inoxlang/inox/internal/globals/net_ns"
 	"github.com/inoxlang/inox/internal/help"
+	"github.com/inoxlang/inox/internal/projectserver/lsp/defines"
 	"github.com/stretchr/testify/assert"
 
 	parse "github.com/inoxlang/inox/internal/parse"
@@ -193,6 +194,34 @@ func runSingleModeTests(t *testing.T, mode Mode, wd, dir string) {
 			}, completions)
 		})
 
+		t.Run("local variable in the right side of a multi-assignment", func(t *testing.T) {
+			state := core.NewTreeWalkState(core.NewContext(core.ContextConfig{Permissions: perms}))
+			defer state.Global.Ctx.CancelGracefully()
+
+			chunk, _ := parseChunkSource("list = [1, 2]; assign a b = li", "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 30)
+			assert.EqualValues(t, []Completion{
+				{
+					ShownString:   "list",
+					Value:         "list",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 28, End: 30}}},
+			}, completions)
+		})
+
+		t.Run("variable declared by a multi-assignment", func(t *testing.T) {
+			state := core.NewTreeWalkState(core.NewContext(core.ContextConfig{Permissions: perms}))
+			defer state.Global.Ctx.CancelGracefully()
+
+			//the existing variable should not be suggested because the name is being declared.
+			chunk, _ := parseChunkSource("list = [1, 2]; assign li b = list", "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 24)
+			assert.Empty(t, completions)
+		})
+
 		t.Run("local variable in a command-liked function call", func(t *testing.T) {
 			state := core.NewTreeWalkState(core.NewContext(core.ContextConfig{Permissions: perms}))
 			defer state.Global.Ctx.CancelGracefully()
@@ -307,8 +336,7 @@ func runSingleModeTests(t *testing.T, mode Mode, wd, dir string) {
 				t.Skip()
 			}
 
-			//TODO: fix (it's working in VSCode)
-			t.Skip()
+			registerSleepFunction()
 
 			state := newState()
 			state.SetGlobal("sleep", core.WrapGoFunction(core.Sleep), core.GlobalConst)
@@ -326,6 +354,79 @@ func runSingleModeTests(t *testing.T, mode Mode, wd, dir string) {
 				},
 			}, completions)
 		})
+
+		t.Run("suggest global function: signature and documentation", func(t *testing.T) {
+			registerSleepFunction()
+
+			state := newState()
+			defer state.Global.Ctx.CancelGracefully()
+
+			state.SetGlobal("sleep", core.WrapGoFunction(core.Sleep), core.GlobalConst)
+			chunk, _ := parseChunkSource("sle", "")
+
+			if mode == LspCompletions {
+				doSymbolicCheck(chunk, state.Global)
+			}
+
+			completions := FindCompletions(SearchArgs{
+				State:       state,
+				Chunk:       chunk,
+				CursorIndex: 3,
+				Mode:        mode,
+			})
+
+			if !assert.Len(t, completions, 1) {
+				return
+			}
+
+			completion := completions[0]
+			assert.Equal(t, "sleep", completion.Value)
+			assert.Equal(t, defines.CompletionItemKindFunction, completion.Kind)
+			assert.Equal(t, "fn(_ duration) ", completion.LabelDetail)
+			assert.Equal(t, utils.MustGet(help.HelpFor("sleep", helpMessageConfig)), completion.MarkdownDocumentation)
+		})
+
+		t.Run("suggest global function: partial name at the end of the input", func(t *testing.T) {
+			if mode != LspCompletions {
+				t.Skip()
+			}
+
+			registerSleepFunction()
+
+			testCases := []struct {
+				code        string
+				cursorIndex int
+				replaced    parse.NodeSpan
+			}{
+				{"sle", 3, parse.NodeSpan{Start: 0, End: 3}},
+				{"a = 1\nsle", 9, parse.NodeSpan{Start: 6, End: 9}},
+				{"fn f(){\n  sle", 13, parse.NodeSpan{Start: 10, End: 13}},
+				{"if true {\n  sle", 15, parse.NodeSpan{Start: 12, End: 15}},
+				//cursor after the end of the input
+				{"sle", 4, parse.NodeSpan{Start: 0, End: 3}},
+			}
+
+			for _, testCase := range testCases {
+				t.Run(testCase.code, func(t *testing.T) {
+					state := newState()
+					defer state.Global.Ctx.CancelGracefully()
+
+					state.SetGlobal("sleep", core.WrapGoFunction(core.Sleep), core.GlobalConst)
+					chunk, _ := parseChunkSource(testCase.code, "")
+
+					doSymbolicCheck(chunk, state.Global)
+					completions := findCompletions(state, chunk, testCase.cursorIndex)
+
+					assert.EqualValues(t, []Completion{
+						{
+							ShownString:   "sleep",
+							Value:         "sleep",
+							ReplacedRange: parse.SourcePositionRange{Span: testCase.replaced},
+						},
+					}, completions)
+				})
+			}
+		})
 	})
 
 	t.Run("identifier member expression", func(t *testing.T) {
@@ -586,6 +687,124 @@ func runSingleModeTests(t *testing.T, mode Mode, wd, dir string) {
 		})
 	})
 
+	t.Run("metaproperty key", func(t *testing.T) {
+		if mode == ShellCompletions {
+			t.Skip()
+		}
+
+		t.Run("underscore in object literal", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("{_}", "")
+			doSymbolicCheck(chunk, state.Global, nil)
+
+			visibility := "_visibility_ " + OBJECT_METAPROPERTY_INITIALIZATION_BLOCK_COMPLETIONS["_visibility_"]
+			constraints := "_constraints_ " + OBJECT_METAPROPERTY_INITIALIZATION_BLOCK_COMPLETIONS["_constraints_"]
+
+			completions := findCompletions(state, chunk, 2)
+			assert.EqualValues(t, []Completion{
+				{ShownString: visibility, Value: visibility, ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 1, End: 2}}},
+				{ShownString: constraints, Value: constraints, ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 1, End: 2}}},
+			}, completions)
+		})
+
+		t.Run("start of metaproperty key in object literal", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("{_v}", "")
+			doSymbolicCheck(chunk, state.Global, nil)
+
+			visibility := "_visibility_ " + OBJECT_METAPROPERTY_INITIALIZATION_BLOCK_COMPLETIONS["_visibility_"]
+
+			completions := _findCompletions(state, chunk, 3, true, nil)
+			assert.EqualValues(t, []Completion{
+				{
+					ShownString:           visibility,
+					Value:                 visibility,
+					ReplacedRange:         parse.SourcePositionRange{Span: parse.NodeSpan{Start: 1, End: 3}},
+					MarkdownDocumentation: OBJECT_METAPROPERTY_DOC["_visibility_"],
+				},
+			}, completions)
+		})
+
+		t.Run("keys of visibility description", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("{_visibility_ { {} }}", "")
+			doSymbolicCheck(chunk, state.Global, nil)
+
+			public := core.VISIBILITY_DESC_PUBLIC_KEY + ": .{}"
+			visibleBy := core.VISIBILITY_DESC_VISIBLE_BY_KEY + ": :{#self: .{}}"
+
+			completions := findCompletions(state, chunk, 17)
+			assert.EqualValues(t, []Completion{
+				{ShownString: public, Value: public, ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 17, End: 17}}},
+				{ShownString: visibleBy, Value: visibleBy, ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 17, End: 17}}},
+			}, completions)
+		})
+
+		t.Run("keys of visibility description: key already present", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("{_visibility_ { {public: .{}, } }}", "")
+			doSymbolicCheck(chunk, state.Global, nil)
+
+			visibleBy := core.VISIBILITY_DESC_VISIBLE_BY_KEY + ": :{#self: .{}}"
+
+			completions := findCompletions(state, chunk, 30)
+			assert.EqualValues(t, []Completion{
+				{ShownString: visibleBy, Value: visibleBy, ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 30, End: 30}}},
+			}, completions)
+		})
+
+		t.Run("#self entry in visible_by", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("{_visibility_ { {visible_by: :{}} }}", "")
+			doSymbolicCheck(chunk, state.Global, nil)
+
+			completions := findCompletions(state, chunk, 31)
+			assert.EqualValues(t, []Completion{
+				{
+					ShownString:   "#self: .{}",
+					Value:         "#self: .{}",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 31, End: 31}},
+				},
+			}, completions)
+		})
+
+		t.Run("#self entry already present in visible_by", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("{_visibility_ { {visible_by: :{#self: .{}, }} }}", "")
+			doSymbolicCheck(chunk, state.Global, nil)
+
+			completions := findCompletions(state, chunk, 42)
+			assert.Empty(t, completions)
+		})
+
+		t.Run("object in initialization block of another metaproperty", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("{_constraints_ { {} }}", "")
+			doSymbolicCheck(chunk, state.Global, nil)
+
+			completions := findCompletions(state, chunk, 18)
+			assert.Empty(t, completions)
+		})
+
+		t.Run("metaproperty already present", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("{_constraints_ { }, _c}", "")
+			doSymbolicCheck(chunk, state.Global, nil)
+
+			completions := findCompletions(state, chunk, 22)
+			assert.Empty(t, completions)
+		})
+
+		t.Run("start of metaproperty key in object pattern", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("%{_v}", "")
+			doSymbolicCheck(chunk, state.Global, nil)
+
+			completions := findCompletions(state, chunk, 4)
+			assert.Empty(t, completions)
+		})
+	})
+
 	t.Run("named patterns", func(t *testing.T) {
 		if mode == ShellCompletions {
 			t.Run("suggest pre-declared pattern from first letter", func(t *testing.T) {
@@ -666,6 +885,146 @@ func runSingleModeTests(t *testing.T, mode Mode, wd, dir string) {
 		})
 	})
 
+	t.Run("lifetime job subject", func(t *testing.T) {
+		if mode == ShellCompletions {
+			t.Run("lone percent sign", func(t *testing.T) {
+				state := newState()
+				state.Global.Ctx.AddNamedPattern("int", core.INT_PATTERN)
+				chunk, _ := parseChunkSource("lifetimejob #job for %", "")
+
+				completions := findCompletions(state, chunk, 22)
+				assert.EqualValues(t, []Completion{
+					{
+						ShownString:   "%int",
+						Value:         "%int",
+						ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 21, End: 22}},
+					},
+				}, completions)
+			})
+			return
+		}
+
+		t.Run("lone percent sign", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("pattern p = %{}\nlifetimejob #job for %", "")
+			doSymbolicCheck(chunk, state.Global)
+
+			completions := findCompletions(state, chunk, 38)
+			assert.EqualValues(t, []Completion{
+				{
+					ShownString:   "%p",
+					Value:         "%p",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 37, End: 38}},
+				},
+			}, completions)
+		})
+
+		t.Run("lone percent sign followed by the embedded module", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("pattern p = %{}\nlifetimejob #job for % {}", "")
+			doSymbolicCheck(chunk, state.Global)
+
+			completions := findCompletions(state, chunk, 38)
+			assert.EqualValues(t, []Completion{
+				{
+					ShownString:   "%p",
+					Value:         "%p",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 37, End: 38}},
+				},
+			}, completions)
+		})
+
+		t.Run("first letter", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("pattern p = %{}\nlifetimejob #job for %p", "")
+			doSymbolicCheck(chunk, state.Global)
+
+			completions := findCompletions(state, chunk, 39)
+			assert.EqualValues(t, []Completion{
+				{
+					ShownString:   "%p",
+					Value:         "%p",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 37, End: 39}},
+				},
+			}, completions)
+		})
+
+		t.Run("object property value without subject", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("pattern p = %{}\n{lifetimejob #job %}", "")
+			doSymbolicCheck(chunk, state.Global)
+
+			completions := findCompletions(state, chunk, 35)
+			assert.Empty(t, completions)
+		})
+	})
+
+	t.Run("function return type", func(t *testing.T) {
+		t.Run("patterns and pointer types should be suggested but not struct types", func(t *testing.T) {
+			state := newState()
+			var chunk *parse.ParsedChunkSource
+			var cursorIndex int
+			var patternCompletion Completion
+
+			if mode == ShellCompletions {
+				state.Global.Ctx.AddNamedPattern("int", core.INT_PATTERN)
+				chunk, _ = parseChunkSource("struct S {}\nfn f() { }", "")
+				cursorIndex = 19
+				patternCompletion = Completion{ShownString: "%int", Value: "%int"}
+			} else {
+				chunk, _ = parseChunkSource("struct S {}\npattern p = 1\nfn f() { }", "")
+				doSymbolicCheck(chunk, state.Global)
+				cursorIndex = 33
+				patternCompletion = Completion{ShownString: "%p", Value: "%p"}
+			}
+			patternCompletion.ReplacedRange = parse.SourcePositionRange{Span: parse.NodeSpan{Start: int32(cursorIndex), End: int32(cursorIndex)}}
+
+			completions := _findCompletions(state, chunk, cursorIndex, true /*keep documentation*/, nil)
+			assert.EqualValues(t, []Completion{
+				patternCompletion,
+				{
+					ShownString:           "*S",
+					Value:                 "*S",
+					ReplacedRange:         parse.SourcePositionRange{Span: parse.NodeSpan{Start: int32(cursorIndex), End: int32(cursorIndex)}},
+					MarkdownDocumentation: core.STRUCT_TYPES_NOT_ALLOWED_AS_RETURN_TYPES,
+				},
+			}, completions)
+		})
+
+		t.Run("cursor separated from the body by a space", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("struct S {}\nfn f()  { }", "")
+			doSymbolicCheck(chunk, state.Global)
+
+			completions := findCompletions(state, chunk, 19)
+			assert.EqualValues(t, []Completion{
+				{
+					ShownString:   "*S",
+					Value:         "*S",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 19, End: 19}},
+				},
+			}, completions)
+		})
+
+		t.Run("no suggestions inside the parameters", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("struct S {}\nfn f( ) { }", "")
+			doSymbolicCheck(chunk, state.Global)
+
+			completions := findCompletions(state, chunk, 17)
+			assert.Empty(t, completions)
+		})
+
+		t.Run("no suggestions if the return type is present", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("struct S {}\nfn f() %int { }", "")
+			doSymbolicCheck(chunk, state.Global)
+
+			completions := findCompletions(state, chunk, 23)
+			assert.Empty(t, completions)
+		})
+	})
+
 	t.Run("manifest section", func(t *testing.T) {
 
 		if mode == ShellCompletions {
@@ -773,6 +1132,35 @@ func runSingleModeTests(t *testing.T, mode Mode, wd, dir string) {
 			}
 		})
 
+		t.Run("value of expected-schema-update", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("manifest{databases:{main:{expected-schema-update: f}}}", "")
+			doSymbolicCheck(chunk, state.Global)
+
+			completions := findCompletions(state, chunk, 51)
+			assert.EqualValues(t, []Completion{
+				{
+					ShownString:   "false",
+					Value:         "false",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 50, End: 51}},
+				},
+			}, completions)
+		})
+
+		t.Run("value of resolution-data", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("manifest{databases:{main:{resolution-data: n}}}", "")
+			doSymbolicCheck(chunk, state.Global)
+
+			completions := findCompletions(state, chunk, 44)
+			assert.EqualValues(t, []Completion{
+				{
+					ShownString:   "nil",
+					Value:         "nil",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 43, End: 44}},
+				},
+			}, completions)
+		})
 	})
 
 	t.Run("module import config section", func(t *testing.T) {
@@ -811,6 +1199,51 @@ func runSingleModeTests(t *testing.T, mode Mode, wd, dir string) {
 				Value:         core.IMPORT_CONFIG__ALLOW_PROPNAME + ": {}",
 				ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 18, End: 18}},
 			})
+			assert.Contains(t, completions, Completion{
+				ShownString:   core.IMPORT_CONFIG__ARGUMENTS_PROPNAME + ": {}",
+				Value:         core.IMPORT_CONFIG__ARGUMENTS_PROPNAME + ": {}",
+				ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 18, End: 18}},
+			})
+			assert.Contains(t, completions, Completion{
+				ShownString:   core.IMPORT_CONFIG__VALIDATION_PROPNAME + `: ""`,
+				Value:         core.IMPORT_CONFIG__VALIDATION_PROPNAME + `: ""`,
+				ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 18, End: 18}},
+			})
+		})
+
+		t.Run("in module import config with a section", func(t *testing.T) {
+			state := newState()
+			chunk := utils.Must(parseChunkSource("import lib /a.ix {allow: {}, }", ""))
+			doSymbolicCheck(chunk, state.Global)
+
+			completions := findCompletions(state, chunk, 29)
+			assert.Equal(t, []Completion{
+				{
+					ShownString:   core.IMPORT_CONFIG__ARGUMENTS_PROPNAME + ": {}",
+					Value:         core.IMPORT_CONFIG__ARGUMENTS_PROPNAME + ": {}",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 29, End: 29}},
+				},
+				{
+					ShownString:   core.IMPORT_CONFIG__VALIDATION_PROPNAME + `: ""`,
+					Value:         core.IMPORT_CONFIG__VALIDATION_PROPNAME + `: ""`,
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 29, End: 29}},
+				},
+			}, completions)
+		})
+
+		t.Run("label details", func(t *testing.T) {
+			state := newState()
+			chunk := utils.Must(parseChunkSource("import lib /a.ix {}", ""))
+			doSymbolicCheck(chunk, state.Global)
+
+			completions := FindCompletions(SearchArgs{State: state, Chunk: chunk, CursorIndex: 18, Mode: mode})
+			for _, completion := range completions {
+				if completion.Value == core.IMPORT_CONFIG__VALIDATION_PROPNAME+`: ""` {
+					assert.Equal(t, MODULE_IMPORT_SECTION_LABEL_DETAILS[core.IMPORT_CONFIG__VALIDATION_PROPNAME], completion.LabelDetail)
+					return
+				}
+			}
+			assert.Fail(t, "the validation section should be suggested")
 		})
 	})
 
@@ -892,6 +1325,24 @@ func runSingleModeTests(t *testing.T, mode Mode, wd, dir string) {
 		}, completions)
 	})
 
+	t.Run("value templates in the description of a permission kind in manifest", func(t *testing.T) {
+		state := newState()
+		chunk, _ := parseChunkSource("manifest{permissions:{read:{}}}", "")
+		doSymbolicCheck(chunk, state.Global)
+
+		completions := findCompletions(state, chunk, 28)
+		assert.Contains(t, completions, Completion{
+			ShownString:   "%/...",
+			Value:         "%/...",
+			ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 28, End: 28}},
+		})
+		assert.Contains(t, completions, Completion{
+			ShownString:   "%https://example.com/...",
+			Value:         "%https://example.com/...",
+			ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 28, End: 28}},
+		})
+	})
+
 	t.Run("permission kind in module import", func(t *testing.T) {
 		state := newState()
 		chunk, _ := parseChunkSource("manifest{};import lib /lib.ix {allow:{}}", "")
@@ -1034,6 +1485,109 @@ func runSingleModeTests(t *testing.T, mode Mode, wd, dir string) {
 		}, completions)
 	})
 
+	t.Run("import source", func(t *testing.T) {
+		importsDir := filepath.Join(dir, "imports")
+		mainModulePath := filepath.Join(importsDir, "main.ix")
+
+		os.MkdirAll(filepath.Join(importsDir, "sub"), 0700)
+		os.WriteFile(mainModulePath, nil, 0600)
+		os.WriteFile(filepath.Join(importsDir, "lib.ix"), []byte("manifest {}"), 0600)
+		os.WriteFile(filepath.Join(importsDir, "chunk.ix"), []byte("includable-chunk\n"), 0600)
+		os.WriteFile(filepath.Join(importsDir, "commented-chunk.ix"), []byte("#!/usr/bin/env inox\n\n# comment\nincludable-chunk\n"), 0600)
+		os.WriteFile(filepath.Join(importsDir, "commented-lib.ix"), []byte("# includable-chunk\nmanifest {}"), 0600)
+		os.WriteFile(filepath.Join(importsDir, "data.txt"), nil, 0600)
+
+		parseModuleSource := func(code string) *parse.ParsedChunkSource {
+			chunk, _ := parse.ParseChunkSource(parse.SourceFile{
+				NameString:  mainModulePath,
+				Resource:    mainModulePath,
+				ResourceDir: importsDir,
+				CodeString:  code,
+			})
+			return chunk
+		}
+
+		t.Run("module import", func(t *testing.T) {
+			state := newState()
+			code := "import lib ./"
+			chunk := parseModuleSource(code)
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, len(code))
+			assert.EqualValues(t, []Completion{
+				{
+					ShownString:   "commented-lib.ix",
+					Value:         "./commented-lib.ix",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 11, End: 13}},
+				},
+				{
+					ShownString:   "lib.ix",
+					Value:         "./lib.ix",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 11, End: 13}},
+				},
+				{
+					ShownString:   "sub",
+					Value:         "./sub/",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 11, End: 13}},
+				},
+			}, completions)
+		})
+
+		t.Run("inclusion import", func(t *testing.T) {
+			state := newState()
+			code := "import ./"
+			chunk := parseModuleSource(code)
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, len(code))
+			assert.EqualValues(t, []Completion{
+				{
+					ShownString:   "chunk.ix",
+					Value:         "./chunk.ix",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 7, End: 9}},
+				},
+				{
+					ShownString:   "commented-chunk.ix",
+					Value:         "./commented-chunk.ix",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 7, End: 9}},
+				},
+				{
+					ShownString:   "sub",
+					Value:         "./sub/",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 7, End: 9}},
+				},
+			}, completions)
+		})
+
+		t.Run("inclusion import: prefix", func(t *testing.T) {
+			state := newState()
+			code := "import ./s"
+			chunk := parseModuleSource(code)
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, len(code))
+			assert.EqualValues(t, []Completion{
+				{
+					ShownString:   "sub",
+					Value:         "./sub/",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 7, End: 10}},
+				},
+			}, completions)
+		})
+
+		t.Run("no read permission", func(t *testing.T) {
+			state := core.NewTreeWalkState(core.NewContext(core.ContextConfig{
+				Filesystem: fs_ns.GetOsFilesystem(),
+			}))
+			code := "import lib ./"
+			chunk := parseModuleSource(code)
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, len(code))
+			assert.Empty(t, completions)
+		})
+	})
+
 	t.Run("break", func(t *testing.T) {
 
 		t.Run("in for statement's block", func(t *testing.T) {
@@ -1212,6 +1766,182 @@ func runSingleModeTests(t *testing.T, mode Mode, wd, dir string) {
 
 	})
 
+	t.Run("variables in path and URL interpolations", func(t *testing.T) {
+		if mode != LspCompletions {
+			t.Skip()
+			return
+		}
+
+		t.Run("absolute path expression", func(t *testing.T) {
+			state := newState()
+			defer state.Global.Ctx.CancelGracefully()
+
+			chunk, _ := parseChunkSource("val = 1; /a/{v}", "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 14)
+			assert.EqualValues(t, []Completion{
+				{ShownString: "val", Value: "val", ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 13, End: 14}}},
+			}, completions)
+		})
+
+		t.Run("absolute path expression: empty interpolation", func(t *testing.T) {
+			state := newState()
+			defer state.Global.Ctx.CancelGracefully()
+
+			chunk, _ := parseChunkSource("val = 1; /a/{}", "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 13)
+			assert.EqualValues(t, []Completion{
+				{ShownString: "val", Value: "val", ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 13, End: 13}}},
+			}, completions)
+		})
+
+		t.Run("URL expression", func(t *testing.T) {
+			state := newState()
+			defer state.Global.Ctx.CancelGracefully()
+
+			chunk, _ := parseChunkSource("val = 1; https://example.com/{v}", "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 31)
+			assert.EqualValues(t, []Completion{
+				{ShownString: "val", Value: "val", ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 30, End: 31}}},
+			}, completions)
+		})
+
+		t.Run("variable not in scope", func(t *testing.T) {
+			state := newState()
+			defer state.Global.Ctx.CancelGracefully()
+
+			chunk, _ := parseChunkSource("fn f(){ val = 1 }; /a/{v}", "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 24)
+			assert.Empty(t, completions)
+		})
+	})
+
+	t.Run("string escape sequences", func(t *testing.T) {
+		makeEscapeCompletions := func(backslashIndex int32) (completions []Completion) {
+			for _, escape := range STRING_ESCAPE_SEQUENCES {
+				completions = append(completions, Completion{
+					ShownString:   escape.Sequence,
+					Value:         escape.Sequence,
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: backslashIndex, End: backslashIndex + 1}},
+				})
+			}
+			return
+		}
+
+		t.Run("after backslash in terminated string", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource(`"a\"`, "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 3)
+			assert.EqualValues(t, makeEscapeCompletions(2), completions)
+		})
+
+		t.Run("after backslash in unterminated string", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource(`"a\`, "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 3)
+			assert.EqualValues(t, makeEscapeCompletions(2), completions)
+		})
+
+		t.Run("after escaped backslash", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource(`"a\\"`, "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 4)
+			assert.Empty(t, completions)
+		})
+
+		t.Run("after backslash in multiline string", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource("`a\\n`", "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 3)
+			assert.Empty(t, completions)
+		})
+	})
+
+	t.Run("rune escape sequences", func(t *testing.T) {
+		makeRuneCompletions := func(start, end int32) (completions []Completion) {
+			for _, escape := range RUNE_ESCAPE_SEQUENCES {
+				completions = append(completions, Completion{
+					ShownString:   "'" + escape.Sequence + "'",
+					Value:         "'" + escape.Sequence + "'",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: start, End: end}},
+				})
+			}
+			return
+		}
+
+		t.Run("after opening quote", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource(`'`, "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 1)
+			assert.EqualValues(t, makeRuneCompletions(0, 1), completions)
+		})
+
+		t.Run("after backslash", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource(`'\`, "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 2)
+			assert.EqualValues(t, makeRuneCompletions(0, 2), completions)
+		})
+
+		t.Run("argument", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource(`f('`, "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 3)
+			assert.EqualValues(t, makeRuneCompletions(2, 3), completions)
+		})
+
+		t.Run("complete rune literal", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource(`'a'`, "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 1)
+			assert.Empty(t, completions)
+		})
+
+		t.Run("after character", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource(`'a`, "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 2)
+			assert.Empty(t, completions)
+		})
+
+		t.Run("label details", func(t *testing.T) {
+			state := newState()
+			chunk, _ := parseChunkSource(`'\`, "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := FindCompletions(SearchArgs{State: state, Chunk: chunk, CursorIndex: 2, Mode: mode})
+			if assert.Len(t, completions, len(RUNE_ESCAPE_SEQUENCES)) {
+				assert.Equal(t, `'\n'`, completions[0].Value)
+				assert.Equal(t, RUNE_ESCAPE_SEQUENCES[0].Description, completions[0].LabelDetail)
+			}
+		})
+	})
+
 	t.Run("html attribute names", func(t *testing.T) {
 		t.Run("local variable in top level module", func(t *testing.T) {
 			state := core.NewTreeWalkState(core.NewContext(core.ContextConfig{Permissions: perms}))
@@ -1242,6 +1972,23 @@ func runSingleModeTests(t *testing.T, mode Mode, wd, dir string) {
 			return
 		}
 
+		t.Run("variable", func(t *testing.T) {
+			state := core.NewTreeWalkState(core.NewContext(core.ContextConfig{Permissions: perms}))
+			defer state.Global.Ctx.CancelGracefully()
+
+			chunk, _ := parseChunkSource("name = \"a\"; html<div class=na></div>", "")
+
+			doSymbolicCheck(chunk, state.Global)
+			completions := findCompletions(state, chunk, 29)
+			assert.EqualValues(t, []Completion{
+				{
+					ShownString:   "name",
+					Value:         "name",
+					ReplacedRange: parse.SourcePositionRange{Span: parse.NodeSpan{Start: 27, End: 29}},
+				},
+			}, completions)
+		})
+
 		t.Run("src in <script>: empty", func(t *testing.T) {
 			state := core.NewTreeWalkState(core.NewContext(core.ContextConfig{Permissions: perms}))
 			defer state.Global.Ctx.CancelGracefully()
@@ -1389,3 +2136,14 @@ func makeFilesystem() *fs_ns.MemFilesystem {
 
 	return fls
 }
+
+// registerSleepFunction registers the symbolic equivalent and the help of core.Sleep, they are normally
+// registered by the globals package.
+func registerSleepFunction() {
+	if !core.IsSymbolicEquivalentOfGoFunctionRegistered(core.Sleep) {
+		core.RegisterSymbolicGoFunction(core.Sleep, func(ctx *symbolic.Context, _ *symbolic.Duration) {
+
+		})
+	}
+	help.RegisterHelpValue(core.Sleep, "sleep")
+}
@@ -2,6 +2,7 @@ package codecompletion
 
 import (
 	"github.com/inoxlang/inox/internal/core"
+	"github.com/inoxlang/inox/internal/core/permkind"
 	"github.com/inoxlang/inox/internal/core/symbolic"
 	"github.com/inoxlang/inox/internal/help"
 	"github.com/inoxlang/inox/internal/utils"
@@ -30,6 +31,24 @@ var (
 		core.MANIFEST_DATABASES_SECTION_NAME: utils.MustGet(help.HelpFor("manifest/databases-section", helpMessageConfig)),
 	}
 
+	//templates of the values suggested inside the description of a permission kind (e.g. read: {}),
+	//the key is a major permission kind.
+	PERM_KIND_VALUE_TEMPLATES = map[permkind.PermissionKind][]valueTemplate{
+		permkind.Read:    RESOURCE_PERM_VALUE_TEMPLATES,
+		permkind.Write:   RESOURCE_PERM_VALUE_TEMPLATES,
+		permkind.Delete:  RESOURCE_PERM_VALUE_TEMPLATES,
+		permkind.Provide: {{"https://localhost:8080", "host"}},
+	}
+
+	RESOURCE_PERM_VALUE_TEMPLATES = []valueTemplate{
+		{"%/...", "path pattern"},
+		{"/", "absolute path"},
+		{"%https://example.com/...", "URL pattern"},
+		{"https://example.com/", "URL"},
+		{"https://example.com", "host"},
+		{"%https://*.example.com", "host pattern"},
+	}
+
 	MANIFEST_DB_DESC_DEFAULT_VALUE_COMPLETIONS = map[string]string{
 		core.MANIFEST_DATABASE__RESOURCE_PROP_NAME:               "ldb://main  # (example) local database named 'main'",
 		core.MANIFEST_DATABASE__RESOLUTION_DATA_PROP_NAME:        "nil",
@@ -44,14 +63,27 @@ var (
 		core.MANIFEST_DATABASE__ASSERT_SCHEMA_UPDATE_PROP_NAME:   utils.MustGet(help.HelpFor("manifest/databases-section/assert-schema", helpMessageConfig)),
 	}
 
+	//literal values suggested for the properties of database descriptions whose value is a boolean or is nilable.
+	MANIFEST_DB_DESC_LITERAL_VALUE_COMPLETIONS = map[string][]string{
+		core.MANIFEST_DATABASE__RESOLUTION_DATA_PROP_NAME:        {"nil"},
+		core.MANIFEST_DATABASE__EXPECTED_SCHEMA_UPDATE_PROP_NAME: {"true", "false"},
+	}
+
+	//literal values suggested for the properties of the invocation section whose value is a boolean or is nilable.
+	MANIFEST_INVOCATION_SECTION_LITERAL_VALUE_COMPLETIONS = map[string][]string{
+		core.MANIFEST_INVOCATION__ASYNC_PROP_NAME: {"true", "false"},
+	}
+
 	MODULE_IMPORT_SECTION_DEFAULT_VALUE_COMPLETIONS = map[string]string{
-		core.IMPORT_CONFIG__ALLOW_PROPNAME:     "{}",
-		core.IMPORT_CONFIG__ARGUMENTS_PROPNAME: "{}",
+		core.IMPORT_CONFIG__ALLOW_PROPNAME:      "{}",
+		core.IMPORT_CONFIG__ARGUMENTS_PROPNAME:  "{}",
+		core.IMPORT_CONFIG__VALIDATION_PROPNAME: `""`,
 	}
 
 	MODULE_IMPORT_SECTION_DOC = map[string]string{
-		core.IMPORT_CONFIG__ALLOW_PROPNAME:     utils.MustGet(help.HelpFor("module-import-config/allow-section", helpMessageConfig)),
-		core.IMPORT_CONFIG__ARGUMENTS_PROPNAME: utils.MustGet(help.HelpFor("module-import-config/arguments-section", helpMessageConfig)),
+		core.IMPORT_CONFIG__ALLOW_PROPNAME:      utils.MustGet(help.HelpFor("module-import-config/allow-section", helpMessageConfig)),
+		core.IMPORT_CONFIG__ARGUMENTS_PROPNAME:  utils.MustGet(help.HelpFor("module-import-config/arguments-section", helpMessageConfig)),
+		core.IMPORT_CONFIG__VALIDATION_PROPNAME: utils.MustGet(help.HelpFor("module-import-config/validation-section", helpMessageConfig)),
 	}
 
 	MODULE_IMPORT_SECTION_LABEL_DETAILS = map[string]string{
@@ -76,7 +108,78 @@ var (
 		symbolic.LTHREAD_META_GLOBALS_SECTION: "{}",
 	}
 
+	//metaproperties that can be initialized in object literals, see core.initializeMetaproperties.
+	OBJECT_METAPROPERTY_NAMES = []string{core.VISIBILITY_KEY, core.CONSTRAINTS_KEY}
+
+	OBJECT_METAPROPERTY_INITIALIZATION_BLOCK_COMPLETIONS = map[string]string{
+		core.VISIBILITY_KEY:  "{\n  {\n    public: .{}\n  }\n}",
+		core.CONSTRAINTS_KEY: "{ }",
+	}
+
+	OBJECT_METAPROPERTY_DOC = map[string]string{
+		core.VISIBILITY_KEY: "The `_visibility_` metaproperty defines the visibility of the object's properties. " +
+			"The initialization block should contain an object with a **public** key listing the public properties:\n" +
+			"```\n{\n  _visibility_ {\n    {\n      public: .{a}\n    }\n  }\n  a: 1\n}\n```",
+		core.CONSTRAINTS_KEY: "The `_constraints_` metaproperty defines constraints the object should always satisfy. " +
+			"The initialization block should only contain binary expressions involving `self`:\n" +
+			"```\n{\n  _constraints_ { (self.a >= 0) }\n  a: 1\n}\n```",
+	}
+
+	//keys of the object in the initialization block of the visibility metaproperty, see core.checkVisibilityInitializationBlock.
+	VISIBILITY_DESC_KEYS = []string{core.VISIBILITY_DESC_PUBLIC_KEY, core.VISIBILITY_DESC_VISIBLE_BY_KEY}
+
+	VISIBILITY_DESC_DEFAULT_VALUE_COMPLETIONS = map[string]string{
+		core.VISIBILITY_DESC_PUBLIC_KEY:     ".{}",
+		core.VISIBILITY_DESC_VISIBLE_BY_KEY: ":{" + VISIBILITY_DESC_SELF_ENTRY_COMPLETION + "}",
+	}
+
+	VISIBILITY_DESC_LABEL_DETAILS = map[string]string{
+		core.VISIBILITY_DESC_PUBLIC_KEY:     "publicly visible properties",
+		core.VISIBILITY_DESC_VISIBLE_BY_KEY: "properties visible by specific entities",
+	}
+
+	//entry of the 'visible_by' dictionary listing the properties visible by the object itself.
+	VISIBILITY_DESC_SELF_ENTRY_COMPLETION   = "#" + core.VISIBILITY_DESC_SELF_KEY + ": .{}"
+	VISIBILITY_DESC_SELF_ENTRY_LABEL_DETAIL = "properties visible by the object itself"
+
+	//escape sequences supported in quoted string literals (same as JSON).
+	STRING_ESCAPE_SEQUENCES = []struct {
+		Sequence    string
+		Description string
+	}{
+		{`\n`, "line feed"},
+		{`\t`, "tab"},
+		{`\r`, "carriage return"},
+		{`\"`, "double quote"},
+		{`\\`, "backslash"},
+		{`\/`, "slash"},
+		{`\b`, "backspace"},
+		{`\f`, "form feed"},
+		{`\u0000`, "unicode character (4 hexadecimal digits)"},
+	}
+
+	//escape sequences supported in rune literals (same single character escapes as Golang).
+	RUNE_ESCAPE_SEQUENCES = []struct {
+		Sequence    string
+		Description string
+	}{
+		{`\n`, "line feed"},
+		{`\t`, "tab"},
+		{`\r`, "carriage return"},
+		{`\'`, "single quote"},
+		{`\\`, "backslash"},
+		{`\a`, "alert or bell"},
+		{`\b`, "backspace"},
+		{`\f`, "form feed"},
+		{`\v`, "vertical tab"},
+	}
+
 	helpMessageConfig = help.HelpMessageConfig{
 		Format: help.MarkdownFormat,
 	}
 )
+
+type valueTemplate struct {
+	Value       string
+	LabelDetail string
+}
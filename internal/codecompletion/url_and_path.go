@@ -1,12 +1,17 @@
 package codecompletion
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"path"
 	"path/filepath"
 	"slices"
 	"strings"
 
+	"github.com/inoxlang/inox/internal/afs"
 	"github.com/inoxlang/inox/internal/core"
+	"github.com/inoxlang/inox/internal/core/permkind"
 	"github.com/inoxlang/inox/internal/core/symbolic"
 	"github.com/inoxlang/inox/internal/inoxconsts"
 	"github.com/inoxlang/inox/internal/projectserver/lsp/defines"
@@ -20,6 +25,11 @@ import (
 	parse "github.com/inoxlang/inox/internal/parse"
 )
 
+const (
+	//maximum number of bytes read from the start of an Inox file in order to find the includable-chunk header.
+	MAX_INCLUDABLE_CHUNK_HEADER_SEARCH_SIZE = 1000
+)
+
 func findPathCompletions(ctx *core.Context, pth string) []Completion {
 	var completions []Completion
 
@@ -62,6 +72,134 @@ func findPathCompletions(ctx *core.Context, pth string) []Completion {
 	return completions
 }
 
+func isImportSource(n parse.Node, parent parse.Node) bool {
+	switch p := parent.(type) {
+	case *parse.ImportStatement:
+		return p.Source == n
+	case *parse.InclusionImportStatement:
+		return p.Source == n
+	}
+	return false
+}
+
+// findImportPathCompletions suggests directories and importable Inox files for the source of an import statement:
+// modules for module imports and includable chunks for inclusion imports. Relative paths are resolved against the
+// directory of the chunk if it is known. Files and directories that are not readable are not suggested.
+func findImportPathCompletions(ctx *core.Context, pth string, search completionSearch) []Completion {
+	var completions []Completion
+
+	_, isInclusionImport := search.parent.(*parse.InclusionImportStatement)
+
+	dir := path.Dir(pth)
+	base := path.Base(pth)
+
+	if core.Path(pth).IsDirPath() {
+		base = ""
+	}
+
+	listedDir := dir
+	chunkPath := ""
+
+	if src, ok := search.chunk.Source.(parse.SourceFile); ok && !src.IsResourceURL {
+		chunkPath = src.Resource
+		if !core.Path(dir).IsAbsolute() && src.ResourceDir != "" {
+			listedDir = path.Join(src.ResourceDir, dir)
+		}
+	}
+
+	entries, err := fs_ns.ListFiles(ctx, core.ToValueOptionalParam(core.Path(core.AppendTrailingSlashIfNotPresent(listedDir))))
+	if err != nil {
+		return nil
+	}
+
+	for _, e := range entries {
+		name := string(e.BaseName_)
+		if !strings.HasPrefix(name, base) || string(e.AbsPath_) == chunkPath {
+			continue
+		}
+
+		pth := path.Join(dir, name)
+
+		if !parse.HasPathLikeStart(pth) {
+			pth = "./" + pth
+		}
+
+		kind := defines.CompletionItemKindFile
+
+		if e.Mode_.FileMode().IsDir() {
+			pth += "/"
+			kind = defines.CompletionItemKindFolder
+		} else {
+			if !strings.HasSuffix(name, inoxconsts.INOXLANG_FILE_EXTENSION) {
+				continue
+			}
+
+			perm := core.FilesystemPermission{Kind_: permkind.Read, Entity: e.AbsPath_}
+			if err := ctx.CheckHasPermission(perm); err != nil {
+				continue
+			}
+
+			isIncludableChunk, err := isIncludableChunkFile(ctx.GetFileSystem(), string(e.AbsPath_))
+			if err != nil {
+				continue
+			}
+
+			if isIncludableChunk != isInclusionImport {
+				continue
+			}
+		}
+
+		completions = append(completions, Completion{
+			ShownString: name,
+			Value:       pth,
+			Kind:        kind,
+			LabelDetail: "%" + core.PATH_PATTERN.Name,
+		})
+	}
+
+	return completions
+}
+
+// isIncludableChunkFile reports whether an Inox file starts with the includable-chunk header, the file is not parsed and
+// only its first MAX_INCLUDABLE_CHUNK_HEADER_SEARCH_SIZE bytes are read.
+func isIncludableChunkFile(fls afs.Filesystem, pth string) (bool, error) {
+	f, err := fls.Open(pth)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	start := make([]byte, MAX_INCLUDABLE_CHUNK_HEADER_SEARCH_SIZE)
+	n, err := io.ReadFull(f, start)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, err
+	}
+
+	return hasIncludableChunkHeader(start[:n]), nil
+}
+
+// hasIncludableChunkHeader reports whether the first line of start that is not blank, a comment or a shebang
+// begins with the includable-chunk keyword.
+func hasIncludableChunkHeader(start []byte) bool {
+	for i, line := range bytes.Split(start, []byte{'\n'}) {
+		if i == 0 && bytes.HasPrefix(line, []byte("#!")) {
+			continue
+		}
+
+		line = bytes.TrimLeft(line, " \t\r;")
+		if len(line) == 0 {
+			continue
+		}
+
+		if line[0] == '#' && (len(line) == 1 || parse.IsCommentFirstSpace(rune(line[1]))) {
+			continue
+		}
+
+		return bytes.HasPrefix(line, []byte(parse.INCLUDABLE_CHUNK_KEYWORD_STRING))
+	}
+	return false
+}
+
 func findURLCompletions(ctx *core.Context, node *parse.URLLiteral, search completionSearch) (completions []Completion) {
 
 	res, err := core.EvalSimpleValueLiteral(node, nil)
@@ -11,6 +11,7 @@ var (
 	ErrSnapshotEntryPathMustBeAbsolute = errors.New("snapshot file path must be absolute")
 	ErrSnapshotEntryNotAFile           = errors.New("filesystem entry is not a file")
 	ErrAlreadyBeingSnapshoted          = errors.New("the filesystem is already being snapshoted")
+	ErrSnapshotMaxTotalSizeExceeded    = errors.New("the total size of the files included in the snapshot exceeds the maximum")
 
 	_ = Value((*FilesystemSnapshotIL)(nil))
 	_ = Serializable((*FilesystemSnapshotIL)(nil))
@@ -30,6 +31,14 @@ type FilesystemSnapshotConfig struct {
 	GetContent       func(ChecksumSHA256 [32]byte) AddressableContent
 	InclusionFilters []PathPattern
 	ExclusionFilters []PathPattern
+
+	//If greater than zero the snapshot is aborted with ErrSnapshotMaxTotalSizeExceeded
+	//if the total size of the included files exceeds this value.
+	MaxTotalSize ByteCount
+
+	//If not nil the snapshot is aborted when the context is done. If nil, filesystems having their own
+	//context (e.g. meta filesystems) abort the snapshot when their context is done.
+	Context *Context
 }
 
 func (c FilesystemSnapshotConfig) IsFileIncluded(path Path) bool {
@@ -705,7 +705,7 @@ func EvaluatePermissionListingObjectNode(n *parse.ObjectLiteral, config PreinitA
 		var checkErr []error
 		checkPermissionListingObject(n, func(n parse.Node, msg string) {
 			checkErr = append(checkErr, errors.New(msg))
-		})
+		}, func(n parse.Node, msg string) {})
 		if len(checkErr) != 0 {
 			return nil, utils.CombineErrors(checkErr...)
 		}
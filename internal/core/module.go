@@ -45,6 +45,11 @@ var (
 		ApplicationModule:     "application",
 	}
 
+	//aliases that users may write instead of the actual module kind names.
+	MODULE_KIND_ALIASES = map[string]ModuleKind{
+		"lthread": UserLThreadModule,
+	}
+
 	ErrFileToIncludeDoesNotExist       = errors.New("file to include does not exist")
 	ErrFileToIncludeIsAFolder          = errors.New("file to include is a folder")
 	ErrMissingManifest                 = errors.New("missing manifest")
@@ -112,6 +117,10 @@ func ParseModuleKind(s string) (ModuleKind, error) {
 		}
 	}
 
+	if kind, ok := MODULE_KIND_ALIASES[s]; ok {
+		return kind, nil
+	}
+
 	return -1, ErrInvalidModuleKind
 }
 
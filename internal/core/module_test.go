@@ -362,6 +362,32 @@ func TestParseModuleFromSource(t *testing.T) {
 	})
 }
 
+func TestParseModuleKind(t *testing.T) {
+	testconfig.AllowParallelization(t)
+
+	t.Run("kind name", func(t *testing.T) {
+		kind, err := ParseModuleKind("spec")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, SpecModule, kind)
+	})
+
+	t.Run("lthread alias", func(t *testing.T) {
+		kind, err := ParseModuleKind("lthread")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, UserLThreadModule, kind)
+		assert.True(t, kind.IsEmbedded())
+	})
+
+	t.Run("invalid kind name", func(t *testing.T) {
+		_, err := ParseModuleKind("?")
+		assert.ErrorIs(t, err, ErrInvalidModuleKind)
+	})
+}
+
 func TestParseLocalModule(t *testing.T) {
 	testconfig.AllowParallelization(t)
 
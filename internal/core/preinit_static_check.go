@@ -86,6 +86,7 @@ type manifestStaticCheckArguments struct {
 	ignoreUnknownSections bool
 	moduleKind            ModuleKind
 	onError               func(n parse.Node, msg string)
+	onWarning             func(n parse.Node, msg string) //optional
 	project               Project
 }
 
@@ -93,6 +94,10 @@ func checkManifestObject(args manifestStaticCheckArguments) {
 	objLit := args.objLit
 	ignoreUnknownSections := args.ignoreUnknownSections
 	onError := args.onError
+	onWarning := args.onWarning
+	if onWarning == nil {
+		onWarning = func(n parse.Node, msg string) {}
+	}
 
 	parse.Walk(objLit, func(node, parent, scopeNode parse.Node, ancestorChain []parse.Node, after bool) (parse.TraversalAction, error) {
 		switch n := node.(type) {
@@ -151,7 +156,7 @@ func checkManifestObject(args manifestStaticCheckArguments) {
 			}
 		case MANIFEST_PERMS_SECTION_NAME:
 			if obj, ok := p.Value.(*parse.ObjectLiteral); ok {
-				checkPermissionListingObject(obj, onError)
+				checkPermissionListingObject(obj, onError, onWarning)
 			} else {
 				onError(p, PERMS_SECTION_SHOULD_BE_AN_OBJECT)
 			}
@@ -169,6 +174,11 @@ func checkManifestObject(args manifestStaticCheckArguments) {
 					return parse.ContinueTraversal, nil
 				}
 
+				//keys are checked separately.
+				if entry, ok := parent.(*parse.DictionaryEntry); ok && node == entry.Key {
+					return parse.Prune, nil
+				}
+
 				switch n := node.(type) {
 				case *parse.ObjectLiteral, *parse.ObjectProperty:
 				case *parse.DictionaryEntry, parse.SimpleValueLiteral, *parse.GlobalVariable,
@@ -181,29 +191,30 @@ func checkManifestObject(args manifestStaticCheckArguments) {
 				return parse.ContinueTraversal, nil
 			}, nil)
 
-			if !hasErrors {
-				staticallyCheckHostDefinitionFnRegistryLock.Lock()
-				defer staticallyCheckHostDefinitionFnRegistryLock.Unlock()
-
-				for _, entry := range dict.Entries {
-					key := entry.Key
-
-					switch k := key.(type) {
-					case *parse.InvalidURL:
-					case *parse.HostLiteral:
-						host := utils.Must(EvalSimpleValueLiteral(k, nil)).(Host)
-						fn, ok := staticallyCheckHostDefinitionDataFnRegistry[host.Scheme()]
-						if ok {
-							errMsg := fn(args.project, entry.Value)
-							if errMsg != "" {
-								onError(entry.Value, errMsg)
-							}
-						} else {
-							onError(k, HOST_SCHEME_NOT_SUPPORTED)
+			staticallyCheckHostDefinitionFnRegistryLock.Lock()
+			defer staticallyCheckHostDefinitionFnRegistryLock.Unlock()
+
+			for _, entry := range dict.Entries {
+				switch k := entry.Key.(type) {
+				case *parse.InvalidURL:
+					onError(k, fmtInvalidURLInHostDefinitionsSection(k.Value))
+				case *parse.HostLiteral:
+					host := utils.Must(EvalSimpleValueLiteral(k, nil)).(Host)
+					fn, ok := staticallyCheckHostDefinitionDataFnRegistry[host.Scheme()]
+					if !ok {
+						onError(k, fmtHostSchemeNotSupported(host.Scheme()))
+						continue
+					}
+
+					//the data is only checked if the values contain no forbidden nodes.
+					if !hasErrors {
+						errMsg := fn(args.project, entry.Value)
+						if errMsg != "" {
+							onError(entry.Value, errMsg)
 						}
-					default:
-						onError(k, HOST_DEFS_SECTION_SHOULD_BE_A_DICT)
 					}
+				default:
+					onError(k, fmtNotHostLiteralInHostDefinitionsSection(k))
 				}
 			}
 		case MANIFEST_LIMITS_SECTION_NAME:
@@ -227,6 +238,19 @@ func checkManifestObject(args manifestStaticCheckArguments) {
 
 				return parse.ContinueTraversal, nil
 			}, nil)
+
+			//check the value of each limit
+			for _, limitProp := range obj.Properties {
+				if limitProp.Value == nil {
+					continue
+				}
+
+				switch limitProp.Value.(type) {
+				case *parse.QuantityLiteral, *parse.RateLiteral, *parse.IntLiteral, *parse.GlobalVariable:
+				default:
+					onError(limitProp.Value, fmtLimitValueShouldBeQuantityOrRate(limitProp.Name()))
+				}
+			}
 		case MANIFEST_ENV_SECTION_NAME:
 
 			if args.moduleKind.IsEmbedded() {
@@ -290,7 +314,7 @@ func checkManifestObject(args manifestStaticCheckArguments) {
 
 			switch propVal := p.Value.(type) {
 			case *parse.ObjectLiteral:
-				checkInvocationObject(propVal, objLit, onError, args.project)
+				checkInvocationObject(propVal, objLit, onError, onWarning, args.project)
 			default:
 				onError(p, INVOCATION_SECTION_SHOULD_BE_AN_OBJECT)
 			}
@@ -317,7 +341,7 @@ func checkManifestObject(args manifestStaticCheckArguments) {
 
 }
 
-func checkPermissionListingObject(objLit *parse.ObjectLiteral, onError func(n parse.Node, msg string)) {
+func checkPermissionListingObject(objLit *parse.ObjectLiteral, onError, onWarning func(n parse.Node, msg string)) {
 	parse.Walk(objLit, func(node, parent, scopeNode parse.Node, ancestorChain []parse.Node, after bool) (parse.TraversalAction, error) {
 		switch n := node.(type) {
 		case *parse.ObjectLiteral, *parse.ListLiteral, *parse.DictionaryLiteral, *parse.DictionaryEntry, *parse.ObjectProperty,
@@ -341,11 +365,11 @@ func checkPermissionListingObject(objLit *parse.ObjectLiteral, onError func(n pa
 			onError(p.Key, fmtNotValidPermissionKindName(p.Name()))
 			continue
 		}
-		checkSingleKindPermissions(permKind, p.Value, onError)
+		checkSingleKindPermissions(permKind, p.Value, onError, onWarning)
 	}
 }
 
-func checkSingleKindPermissions(permKind PermissionKind, desc parse.Node, onError func(n parse.Node, msg string)) {
+func checkSingleKindPermissions(permKind PermissionKind, desc parse.Node, onError, onWarning func(n parse.Node, msg string)) {
 	checkSingleItem := func(node parse.Node) {
 		switch n := node.(type) {
 		case *parse.AbsolutePathExpression:
@@ -357,9 +381,13 @@ func checkSingleKindPermissions(permKind PermissionKind, desc parse.Node, onErro
 			onError(n, fmtOnlyAbsPathPatternsAreAcceptedInPerms(n.Raw))
 		case *parse.URLExpression:
 		case *parse.URLLiteral:
+			checkMemHostPermission(permKind, n, n.Value, onError)
 		case *parse.URLPatternLiteral:
+			checkMemHostPermission(permKind, n, n.Value, onError)
 		case *parse.HostLiteral:
+			checkMemHostPermission(permKind, n, n.Value, onError)
 		case *parse.HostPatternLiteral:
+			checkMemHostPermission(permKind, n, n.Value, onError)
 		case *parse.PatternIdentifierLiteral, *parse.PatternNamespaceIdentifierLiteral:
 		case *parse.GlobalVariable, *parse.Variable, *parse.IdentifierLiteral:
 
@@ -408,10 +436,16 @@ func checkSingleKindPermissions(permKind PermissionKind, desc parse.Node, onErro
 
 	switch v := desc.(type) {
 	case *parse.ListLiteral:
+		if len(v.Elements) == 0 {
+			onWarning(v, EMPTY_PERMISSION_KIND_VALUE)
+		}
 		for _, elem := range v.Elements {
 			checkSingleItem(elem)
 		}
 	case *parse.ObjectLiteral:
+		if len(v.Properties) == 0 && len(v.SpreadElements) == 0 && len(v.MetaProperties) == 0 {
+			onWarning(v, EMPTY_PERMISSION_KIND_VALUE)
+		}
 		for _, prop := range v.Properties {
 			if prop.HasImplicitKey() {
 				checkSingleItem(prop.Value)
@@ -440,6 +474,19 @@ func checkSingleKindPermissions(permKind PermissionKind, desc parse.Node, onErro
 
 }
 
+// checkMemHostPermission reports an error if a write (including minor kinds such as update) or delete permission
+// targets a mem:// resource.
+func checkMemHostPermission(permKind PermissionKind, node parse.Node, value string, onError func(n parse.Node, msg string)) {
+	if !strings.HasPrefix(value, "mem://") {
+		return
+	}
+
+	switch permKind.Major() {
+	case permkind.Write, permkind.Delete:
+		onError(node, PERM_NOT_APPLICABLE_TO_MEM_HOST)
+	}
+}
+
 func checkPreinitFilesObject(obj *parse.ObjectLiteral, onError func(n parse.Node, msg string)) {
 
 	hasForbiddenNodes := false
@@ -624,7 +671,16 @@ func checkDatabasesObject(
 	}
 }
 
-func checkInvocationObject(obj *parse.ObjectLiteral, manifestObj *parse.ObjectLiteral, onError func(n parse.Node, msg string), project Project) {
+func checkInvocationObject(
+	obj *parse.ObjectLiteral,
+	manifestObj *parse.ObjectLiteral,
+	onError func(n parse.Node, msg string),
+	onWarning func(n parse.Node, msg string),
+	project Project,
+) {
+
+	hasValidOnAddedElement := false
+	var asyncTrue *parse.BooleanLiteral
 
 	for _, p := range obj.Properties {
 		if p.Value == nil {
@@ -644,6 +700,7 @@ func checkInvocationObject(obj *parse.ObjectLiteral, manifestObj *parse.ObjectLi
 					if !IsStaticallyCheckDBFunctionRegistered(Scheme(scheme)) {
 						onError(manifestObj, SCHEME_NOT_DB_SCHEME_OR_IS_NOT_SUPPORTED)
 					} else {
+						hasValidOnAddedElement = true
 						//if the scheme corresponds to a database and the manifest does not
 						//contain the databases section, we add an error
 						if !manifestObj.HasNamedProp(MANIFEST_DATABASES_SECTION_NAME) {
@@ -656,13 +713,21 @@ func checkInvocationObject(obj *parse.ObjectLiteral, manifestObj *parse.ObjectLi
 				onError(p.Value, ONLY_URL_LITS_ARE_SUPPORTED_FOR_NOW)
 			}
 		case MANIFEST_INVOCATION__ASYNC_PROP_NAME:
-			if _, ok := p.Value.(*parse.BooleanLiteral); !ok {
+			boolLit, ok := p.Value.(*parse.BooleanLiteral)
+			if !ok {
 				onError(p.Value, A_BOOL_LIT_IS_EXPECTED)
+			} else if boolLit.Value {
+				asyncTrue = boolLit
 			}
 		default:
 			onError(p, fmtUnexpectedPropOfInvocationDescription(p.Name()))
 		}
 	}
+
+	//async invocation only makes sense if the module is automatically invoked.
+	if asyncTrue != nil && !hasValidOnAddedElement {
+		onWarning(asyncTrue, ASYNC_INVOCATION_HAS_NO_EFFECT_WITHOUT_VALID_TRIGGER)
+	}
 }
 
 func checkParametersObject(objLit *parse.ObjectLiteral, onError func(n parse.Node, msg string)) {
@@ -704,6 +769,11 @@ func checkParametersObject(objLit *parse.ObjectLiteral, onError func(n parse.Nod
 			optionPattern, isOptionPattern := prop.Value.(*parse.OptionPatternLiteral)
 			if isOptionPattern {
 				propValue = optionPattern.Value
+
+				if !parse.NodeIsPattern(propValue) {
+					onError(prop, "the value of the option pattern describing a non positional parameter should be a named pattern or a pattern literal (ex: %--verbose=%bool)")
+					continue
+				}
 			}
 
 			switch propVal := propValue.(type) {
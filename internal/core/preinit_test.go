@@ -13,12 +13,20 @@ import (
 	"github.com/go-git/go-billy/v5/util"
 	"github.com/inoxlang/inox/internal/afs"
 	permkind "github.com/inoxlang/inox/internal/core/permkind"
+	"github.com/inoxlang/inox/internal/inoxconsts"
 	"github.com/inoxlang/inox/internal/parse"
 	"github.com/inoxlang/inox/internal/testconfig"
 	"github.com/inoxlang/inox/internal/utils"
 	"github.com/stretchr/testify/assert"
 )
 
+// preinitTestArguments is an argument object passed to the parameters of a manifest and the expected argument values.
+type preinitTestArguments struct {
+	argObject ValMap
+	expected  map[string]Value
+	error     bool
+}
+
 func TestPreInit(t *testing.T) {
 	testconfig.AllowParallelization(t)
 
@@ -82,6 +90,7 @@ func TestPreInit(t *testing.T) {
 		expectedPermissions          []Permission
 		expectedLimits               []Limit
 		expectedParameters           []ModuleParameter
+		expectedArguments            []preinitTestArguments
 		expectedResolutions          map[Host]Value
 		expectedPreinitFileConfigs   PreinitFiles
 		expectedDatabaseConfigs      DatabaseConfigs
@@ -141,6 +150,16 @@ func TestPreInit(t *testing.T) {
 			error:                     true,
 			expectedStaticCheckErrors: []string{INVALID_KIND_SECTION_EMBEDDED_MOD_KINDS_NOT_ALLOWED},
 		},
+		{
+			name: "kind: lthread alias of an embedded module kind is not allowed",
+			module: `
+				manifest {
+					kind: "lthread"
+				}`,
+			expectedLimits:            []Limit{minLimitA, minLimitB, threadLimit},
+			error:                     true,
+			expectedStaticCheckErrors: []string{INVALID_KIND_SECTION_EMBEDDED_MOD_KINDS_NOT_ALLOWED},
+		},
 		{
 			name: "kind: invalid module kind",
 			module: `
@@ -235,6 +254,111 @@ func TestPreInit(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "parameters: positional with pattern union",
+			module: `
+				manifest {
+					parameters: {
+						{
+							name: #value
+							pattern: %| int | str
+						}
+					}
+				}`,
+			//the union pattern is not compared because it holds its node.
+			expectedLimits: []Limit{minLimitA, minLimitB, threadLimit},
+			expectedArguments: []preinitTestArguments{
+				{
+					argObject: ValMap{inoxconsts.IMPLICIT_PROP_NAME: NewWrappedValueList(Int(1))},
+					expected:  map[string]Value{"value": Int(1)},
+				},
+				{
+					argObject: ValMap{inoxconsts.IMPLICIT_PROP_NAME: NewWrappedValueList(String("a"))},
+					expected:  map[string]Value{"value": String("a")},
+				},
+				{
+					argObject: ValMap{inoxconsts.IMPLICIT_PROP_NAME: NewWrappedValueList(True)},
+					error:     true,
+				},
+			},
+		},
+		{
+			name: "parameters: positional with optional pattern",
+			module: `
+				manifest {
+					parameters: {
+						{
+							name: #value
+							pattern: %int?
+						}
+					}
+				}`,
+			expectedLimits: []Limit{minLimitA, minLimitB, threadLimit},
+			expectedParameters: []ModuleParameter{
+				{
+					positional: true,
+					pattern:    utils.Must(NewOptionalPattern(nil, INT_PATTERN)),
+					name:       "value",
+				},
+			},
+		},
+		{
+			name: "parameters: non positional with pattern union",
+			module: `
+				manifest {
+					parameters: {
+						value: {
+							pattern: %| int | str
+						}
+					}
+				}`,
+			//the union pattern is not compared because it holds its node.
+			expectedLimits: []Limit{minLimitA, minLimitB, threadLimit},
+			expectedArguments: []preinitTestArguments{
+				{
+					argObject: ValMap{"value": Int(1)},
+					expected:  map[string]Value{"value": Int(1)},
+				},
+				{
+					argObject: ValMap{"value": String("a")},
+					expected:  map[string]Value{"value": String("a")},
+				},
+				{
+					argObject: ValMap{"value": True},
+					error:     true,
+				},
+			},
+		},
+		{
+			name: "parameters: non positional with option pattern",
+			module: `
+				manifest {
+					parameters: {
+						verbose: %--verbose=%bool
+					}
+				}`,
+			expectedLimits: []Limit{minLimitA, minLimitB, threadLimit},
+			expectedParameters: []ModuleParameter{
+				{
+					positional: false,
+					pattern:    BOOL_PATTERN,
+					name:       "verbose",
+					cliArgName: "verbose",
+				},
+			},
+		},
+		{
+			name: "parameters: non positional with option pattern whose value is not a pattern",
+			module: `
+				manifest {
+					parameters: {
+						verbose: %--verbose=1
+					}
+				}`,
+			expectedLimits:            []Limit{minLimitA, minLimitB, threadLimit},
+			error:                     true,
+			expectedStaticCheckErrors: []string{"the value of the option pattern describing a non positional parameter should be a named pattern or a pattern literal"},
+		},
 		{
 			name: "host definition",
 			module: `
@@ -273,6 +397,42 @@ func TestPreInit(t *testing.T) {
 			})},
 			error: false,
 		},
+		{
+			name: "host definition with a URL key",
+			module: `
+				manifest {
+					host-definitions: :{
+						ldb://main/ : /mydb
+					}
+				}`,
+			expectedLimits:            []Limit{minLimitA, minLimitB, threadLimit},
+			error:                     true,
+			expectedStaticCheckErrors: []string{fmtNotHostLiteralInHostDefinitionsSection(&parse.URLLiteral{})},
+		},
+		{
+			name: "host definition with a key that is not a host literal",
+			module: `
+				manifest {
+					host-definitions: :{
+						"ldb://main" : /mydb
+					}
+				}`,
+			expectedLimits:            []Limit{minLimitA, minLimitB, threadLimit},
+			error:                     true,
+			expectedStaticCheckErrors: []string{HOST_DEFS_KEYS_SHOULD_BE_HOST_LITS},
+		},
+		{
+			name: "host definition with a host whose scheme is not supported",
+			module: `
+				manifest {
+					host-definitions: :{
+						https://example.com : /mydb
+					}
+				}`,
+			expectedLimits:            []Limit{minLimitA, minLimitB, threadLimit},
+			error:                     true,
+			expectedStaticCheckErrors: []string{fmtHostSchemeNotSupported("https")},
+		},
 		{
 			name:                "empty manifest",
 			module:              `manifest {}`,
@@ -386,6 +546,32 @@ func TestPreInit(t *testing.T) {
 				}`,
 			error: true,
 		},
+		{
+			name: "byte_rate_limit",
+			module: `manifest {
+					limits: {
+						"b": 1kB/s
+					}
+				}`,
+			expectedPermissions: []Permission{},
+			expectedLimits: []Limit{
+				minLimitA,
+				{Name: "b", Kind: ByteRateLimit, Value: 1000},
+				threadLimit,
+			},
+			expectedResolutions: nil,
+			error:               false,
+		},
+		{
+			name: "limit_with_string_value",
+			module: `manifest {
+					limits: {
+						"a": "1"
+					}
+				}`,
+			error:                     true,
+			expectedStaticCheckErrors: []string{fmtLimitValueShouldBeQuantityOrRate("a")},
+		},
 		{
 			name: "host_with_unsupported_scheme",
 			module: `manifest {
@@ -1848,6 +2034,22 @@ func TestPreInit(t *testing.T) {
 					assert.EqualValues(t, testCase.expectedParameters, params)
 				}
 
+				if testCase.expectedArguments != nil {
+					ctx := NewContexWithEmptyState(ContextConfig{}, nil)
+					defer ctx.CancelGracefully()
+
+					for _, arguments := range testCase.expectedArguments {
+						args, err := manifest.Parameters.GetArgumentsFromObject(ctx, objFrom(arguments.argObject))
+						if arguments.error {
+							assert.Error(t, err)
+							continue
+						}
+						if assert.NoError(t, err) {
+							assert.Equal(t, arguments.expected, args.ValueMap())
+						}
+					}
+				}
+
 				if testCase.expectedModuleKind != nil {
 					assert.EqualValues(t, *testCase.expectedModuleKind, manifest.explicitModuleKind)
 				}
@@ -1890,3 +2092,64 @@ func TestPreInit(t *testing.T) {
 	}
 
 }
+
+func TestCheckManifestObject(t *testing.T) {
+
+	check := func(code string) (errors []string) {
+		chunk, _ := parse.ParseChunk(code, "<chunk>")
+
+		checkManifestObject(manifestStaticCheckArguments{
+			objLit:     chunk.Manifest.Object.(*parse.ObjectLiteral),
+			moduleKind: ApplicationModule,
+			onError: func(n parse.Node, msg string) {
+				errors = append(errors, msg)
+			},
+		})
+		return
+	}
+
+	//PreInit does not check manifests containing parsing errors.
+	t.Run("host definition with an invalid URL key", func(t *testing.T) {
+		errors := check(`
+			manifest {
+				host-definitions: :{
+					https://aa:1:1 : /mydb
+				}
+			}`)
+
+		assert.Equal(t, []string{fmtInvalidURLInHostDefinitionsSection("https://aa:1:1")}, errors)
+	})
+
+	t.Run("read permission on the memory host", func(t *testing.T) {
+		errors := check(`
+			manifest {
+				permissions: {
+					read: mem://localproc
+				}
+			}`)
+
+		assert.Empty(t, errors)
+	})
+
+	t.Run("delete permission on the memory host", func(t *testing.T) {
+		errors := check(`
+			manifest {
+				permissions: {
+					delete: mem://localproc
+				}
+			}`)
+
+		assert.Equal(t, []string{PERM_NOT_APPLICABLE_TO_MEM_HOST}, errors)
+	})
+
+	t.Run("update permission on a memory URL", func(t *testing.T) {
+		errors := check(`
+			manifest {
+				permissions: {
+					update: mem://localproc/a
+				}
+			}`)
+
+		assert.Equal(t, []string{PERM_NOT_APPLICABLE_TO_MEM_HOST}, errors)
+	})
+}
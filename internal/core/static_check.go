@@ -19,6 +19,14 @@ import (
 const (
 	CHECK_ERR_PREFIX  = "check: "
 	MAX_NAME_BYTE_LEN = 64
+
+	//names of the experimental features, see StaticCheckInput.ExperimentalFeatures.
+
+	//report the if conditions and assertions that are boolean literals.
+	CONSTANT_CONDITION_EXPERIMENTAL_FEATURE = "constant-condition"
+
+	//report the top-level functions whose only captured global is initialized with an object literal.
+	CONSIDER_MAKING_METHOD_EXPERIMENTAL_FEATURE = "consider-making-method"
 )
 
 var (
@@ -39,15 +47,79 @@ type StaticCheckInput struct {
 	ShellLocalVars         map[string]Value
 	Patterns               map[string]Pattern
 	PatternNamespaces      map[string]*PatternNamespace
+
+	//If true a module containing top-level test statements but no kind section in its manifest
+	//causes an error instead of a warning.
+	ErrorOnTestStatementsWithoutKind bool
+
+	//If not nil this function is called for nodes that are not allowed in assertions by default,
+	//returning true allows the node. It is mainly used by embedded DSLs requiring richer assertions.
+	IsNodeAllowedInAssertions func(node parse.Node) bool
+
+	//If true a warning is added for each global variable that is read but never reassigned,
+	//such variables could be declared as constants.
+	WarnGlobalCouldBeConst bool
+
+	//If true a warning is added for each pattern definition (pattern p = ...) whose pattern is never referenced.
+	//References inside the definition of the pattern itself (recursive lazy patterns) are ignored.
+	WarnUnusedPatterns bool
+
+	//Opinionated checks that are enabled by name (e.g. CONSTANT_CONDITION_EXPERIMENTAL_FEATURE), all experimental
+	//features are disabled by default.
+	ExperimentalFeatures map[string]bool
+
+	//If not empty this name replaces the source name of Chunk in the locations of errors and warnings,
+	//the locations in included chunks and imported modules are not affected.
+	SourceNameOverride string
+
+	//If not nil this function is called for each error as soon as it is found, the errors are still accumulated
+	//and returned by StaticCheck. This enables reporting diagnostics before the end of the check.
+	OnError func(err *StaticCheckError)
+
+	//Maximum number of accumulated errors, 0 means no limit. When the limit is reached the next errors are dropped
+	//and a single TOO_MANY_ERRORS error is added, the whole AST is still checked.
+	MaxErrors int
+
+	//If true the source positions of the nodes of Chunk can be retrieved with (*StaticCheckData).SourcePositions,
+	//the index is only built the first time it is requested. SourceNameOverride is taken into account.
+	IndexSourcePositions bool
+
+	//If true a panic occurring during the check (e.g. failure of the check of an included chunk) is recovered
+	//and StaticCheck returns a single error describing the internal failure instead of panicking, the returned
+	//data only contains this error.
+	Recover bool
 }
 
 // StaticCheck performs various checks on an AST, like checking duplicate declarations and keys or checking that statements like return,
 // break and continue are not misplaced. No type checks are performed.
-func StaticCheck(input StaticCheckInput) (*StaticCheckData, error) {
+func StaticCheck(input StaticCheckInput) (finalData *StaticCheckData, finalErr error) {
 	if input.State == nil {
 		return nil, errors.New("missing state")
 	}
 
+	if input.Recover {
+		defer func() {
+			e := recover()
+			if e == nil {
+				return
+			}
+
+			var location parse.SourcePositionStack
+			if input.Chunk != nil && input.Node != nil {
+				location = parse.SourcePositionStack{input.Chunk.GetSourcePosition(input.Node.Base().Span)}
+			}
+
+			err := utils.ConvertPanicValueToError(e)
+			internalErr := NewStaticCheckError(fmtInternalStaticCheckFailure(err), location)
+			if input.OnError != nil {
+				input.OnError(internalErr)
+			}
+
+			finalData = &StaticCheckData{errors: []*StaticCheckError{internalErr}}
+			finalErr = combineStaticCheckErrors(internalErr)
+		}()
+	}
+
 	globals := make(map[parse.Node]map[string]globalVarInfo)
 
 	var module parse.Node //ok if nil
@@ -91,7 +163,7 @@ func StaticCheck(input StaticCheckInput) (*StaticCheckData, error) {
 	checker := &checker{
 		checkInput:        input,
 		fnDecls:           make(map[parse.Node]map[string]int),
-		structDefs:        make(map[parse.Node]map[string]int),
+		structDefs:        make(map[parse.Node]map[string]structDefInfo),
 		globalVars:        globals,
 		localVars:         localVars,
 		shellLocalVars:    shellLocalVars,
@@ -108,6 +180,19 @@ func StaticCheck(input StaticCheckInput) (*StaticCheckData, error) {
 		},
 	}
 
+	if input.IndexSourcePositions && input.Chunk != nil {
+		checker.data.positionIndexChunk = input.Chunk
+		checker.data.positionIndexSourceName = input.SourceNameOverride
+	}
+
+	if input.WarnGlobalCouldBeConst {
+		checker.globalVarUsages = make(map[parse.Node]map[string]*globalVarUsage)
+	}
+
+	if input.WarnUnusedPatterns {
+		checker.referencedPatterns = make(map[string]bool)
+	}
+
 	if module != nil {
 		var statements []parse.Node
 		if chunk, ok := module.(*parse.Chunk); ok {
@@ -124,9 +209,45 @@ func StaticCheck(input StaticCheckInput) (*StaticCheckData, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	if input.WarnGlobalCouldBeConst {
+		checker.warnAboutGlobalsThatCouldBeConstants()
+	}
+
+	if input.WarnUnusedPatterns {
+		checker.warnAboutUnusedPatterns()
+	}
+
+	if module != nil && checker.isExperimentalFeatureEnabled(CONSIDER_MAKING_METHOD_EXPERIMENTAL_FEATURE) {
+		checker.suggestMakingMethods(module)
+	}
+
 	return checker.data, combineStaticCheckErrors(checker.data.errors...)
 }
 
+// CheckChunkSyntaxOnly performs the subset of the checks of StaticCheck that do not require a state or a module:
+// literal checks (quantities, rates, ranges, URLs and hosts) and duplicate key checks. Variables, imports, inclusions
+// and placement rules depending on declarations are not checked. The returned errors do not include warnings.
+func CheckChunkSyntaxOnly(chunk *parse.ParsedChunkSource) []*StaticCheckError {
+	if chunk == nil || chunk.Node == nil {
+		return nil
+	}
+
+	checker := &checker{
+		properties: make(map[*parse.ObjectLiteral]*propertyInfo),
+		chunk:      chunk,
+		store:      make(map[parse.Node]interface{}),
+		data:       &StaticCheckData{},
+	}
+
+	checkNode := func(node, parent, scopeNode parse.Node, ancestorChain []parse.Node, after bool) (parse.TraversalAction, error) {
+		return checker.checkSingleNodeSyntaxOnly(node, parent), nil
+	}
+
+	parse.Walk(chunk.Node, checkNode, nil)
+	return checker.data.errors
+}
+
 // see Check function.
 type checker struct {
 	currentModule            *Module //can be nil
@@ -140,7 +261,7 @@ type checker struct {
 	fnDecls map[parse.Node]map[string]int
 
 	//key: *parse.Chunk|*parse.EmbeddedModule
-	structDefs map[parse.Node]map[string]int
+	structDefs map[parse.Node]map[string]structDefInfo
 
 	//key: *parse.Chunk|*parse.EmbeddedModule
 	globalVars map[parse.Node]map[string]globalVarInfo
@@ -161,9 +282,26 @@ type checker struct {
 
 	shellLocalVars map[string]bool
 
+	//key: *parse.Chunk|*parse.EmbeddedModule, nil if global variable usages are not tracked. The map is shared with
+	//the checkers of included chunks, see globalVarUsageScope.
+	globalVarUsages map[parse.Node]map[string]*globalVarUsage
+
+	//nil if pattern usages are not tracked. Patterns are inherited by embedded modules, so the usages are
+	//tracked by name for the whole checked code.
+	referencedPatterns map[string]bool
+	patternDefinitions []*parse.PatternDefinition
+
+	//call to 'manifest' resulting from a misplaced manifest, it is reported once and not checked.
+	misplacedManifest *parse.CallExpression
+
 	store map[parse.Node]any
 
-	data *StaticCheckData
+	data                *StaticCheckData
+	tooManyErrorsMarker *StaticCheckError //set when StaticCheckInput.MaxErrors is reached
+
+	//number of errors accumulated by the ancestor checkers when the checker was created, the errors of a child
+	//checker are merged into its parent so they count towards the same StaticCheckInput.MaxErrors limit.
+	errorCountOffset int
 }
 
 // globalVarInfo represents the information stored about a global variable during checking.
@@ -173,6 +311,21 @@ type globalVarInfo struct {
 	fnExpr          *parse.FunctionExpression
 }
 
+// globalVarUsage records how a global variable declared in the checked code is used.
+type globalVarUsage struct {
+	declaration  parse.Node
+	location     parse.SourcePositionStack //location of the declaration, it may be located in an included chunk.
+	isRead       bool
+	isReassigned bool
+}
+
+// structDefInfo represents the information stored about a struct definition during checking.
+type structDefInfo struct {
+	//source position stack of the struct's name, the definition may be located in an included chunk.
+	location parse.SourcePositionStack
+	fields   []string
+}
+
 // locallVarInfo represents the information stored about a local variable during checking.
 type localVarInfo struct {
 	isGroupMatchingVar bool
@@ -208,19 +361,88 @@ func (checker *checker) getSourcePositionStack(node parse.Node) parse.SourcePosi
 		sourcePositionStack = checker.parentChecker.getSourcePositionStack(importStmt)
 	}
 
-	sourcePositionStack = append(sourcePositionStack, checker.chunk.GetSourcePosition(node.Base().Span))
+	position := checker.chunk.GetSourcePosition(node.Base().Span)
+	if checker.parentChecker == nil && checker.checkInput.SourceNameOverride != "" {
+		position.SourceName = checker.checkInput.SourceNameOverride
+	}
+
+	sourcePositionStack = append(sourcePositionStack, position)
 	return sourcePositionStack
 }
 
 func (checker *checker) addError(node parse.Node, s string) {
-	checker.data.errors = append(checker.data.errors, checker.makeCheckingError(node, s))
+	checker.recordError(checker.makeCheckingError(node, s))
+}
+
+func (checker *checker) recordError(err *StaticCheckError) {
+	appended := checker.appendError(err)
+	if appended != nil && checker.checkInput.OnError != nil {
+		checker.checkInput.OnError(appended)
+	}
+}
+
+// appendError appends err to the errors and returns it. If StaticCheckInput.MaxErrors is reached err is dropped and
+// a TOO_MANY_ERRORS marker is appended and returned instead, nil is returned if the marker is already present in the
+// checker or in an ancestor checker.
+func (checker *checker) appendError(err *StaticCheckError) *StaticCheckError {
+	maxErrors := checker.checkInput.MaxErrors
+
+	if maxErrors <= 0 || checker.errorCountOffset+len(checker.data.errors) < maxErrors {
+		checker.data.errors = append(checker.data.errors, err)
+		return err
+	}
+
+	for c := checker; c != nil; c = c.parentChecker {
+		if c.tooManyErrorsMarker != nil {
+			return nil
+		}
+	}
+
+	checker.tooManyErrorsMarker = NewStaticCheckError(TOO_MANY_ERRORS, err.Location)
+	checker.data.errors = append(checker.data.errors, checker.tooManyErrorsMarker)
+	return checker.tooManyErrorsMarker
+}
+
+// mergeErrorsOfChildChecker appends the errors of a child checker, OnError has already been called by the child checker
+// for its errors and for its TOO_MANY_ERRORS marker.
+func (checker *checker) mergeErrorsOfChildChecker(child *checker) {
+	for _, err := range child.data.errors {
+		if err == child.tooManyErrorsMarker {
+			continue
+		}
+
+		appended := checker.appendError(err)
+		if appended != nil && appended != err && checker.checkInput.OnError != nil {
+			//TOO_MANY_ERRORS marker of the checker.
+			checker.checkInput.OnError(appended)
+		}
+	}
+
+	if child.tooManyErrorsMarker != nil && checker.tooManyErrorsMarker == nil {
+		checker.tooManyErrorsMarker = child.tooManyErrorsMarker
+		checker.data.errors = append(checker.data.errors, child.tooManyErrorsMarker)
+	}
 }
 
 func (checker *checker) addWarning(node parse.Node, s string) {
 	checker.data.warnings = append(checker.data.warnings, checker.makeCheckingWarning(node, s))
 }
 
+func (checker *checker) isExperimentalFeatureEnabled(name string) bool {
+	return checker.checkInput.ExperimentalFeatures[name]
+}
+
 func (c *checker) defineStructs(closestModule parse.Node, statements []parse.Node) {
+	c.defineStructsOfChunk(closestModule, statements, c.getSourcePositionStack)
+}
+
+// defineStructsOfChunk defines the structs of a chunk (main chunk or included chunk), getLocation
+// should return the source position stack of a node in the chunk.
+func (c *checker) defineStructsOfChunk(
+	closestModule parse.Node,
+	statements []parse.Node,
+	getLocation func(node parse.Node) parse.SourcePositionStack,
+) {
 
 	//Define structs from included chunks.
 	for _, stmt := range statements {
@@ -232,7 +454,13 @@ func (c *checker) defineStructs(closestModule parse.Node, statements []parse.Nod
 		if includedChunk == nil { //File not found
 			return
 		}
-		c.defineStructs(closestModule, includedChunk.Node.Statements)
+
+		getLocationInIncludedChunk := func(node parse.Node) parse.SourcePositionStack {
+			location := slices.Clone(getLocation(inclusionStmt))
+			return append(location, includedChunk.GetSourcePosition(node.Base().Span))
+		}
+
+		c.defineStructsOfChunk(closestModule, includedChunk.Node.Statements, getLocationInIncludedChunk)
 	}
 
 	//Define other structs.
@@ -245,11 +473,15 @@ func (c *checker) defineStructs(closestModule parse.Node, statements []parse.Nod
 		name, ok := structDef.GetName()
 		if ok {
 			defs := c.getModStructDefs(closestModule)
-			_, alreadyDefined := defs[name]
+			firstDef, alreadyDefined := defs[name]
+			location := getLocation(structDef.Name)
+
 			if alreadyDefined {
-				c.addError(structDef.Name, fmtInvalidStructDefAlreadyDeclared(name))
+				err := NewStaticCheckError(fmtInvalidStructDefAlreadyDeclared(name), location)
+				err.RelatedLocations = []parse.SourcePositionStack{firstDef.location}
+				c.recordError(err)
 			} else {
-				defs[name] = 0
+				defs[name] = structDefInfo{location: location, fields: getStructFieldNames(structDef)}
 			}
 		}
 
@@ -284,6 +516,20 @@ func (c *checker) defineStructs(closestModule parse.Node, statements []parse.Nod
 	}
 }
 
+// getStructFieldNames returns the names of the fields defined in the body of a struct definition, methods are not included.
+func getStructFieldNames(structDef *parse.StructDefinition) (names []string) {
+	if structDef.Body == nil {
+		return nil
+	}
+
+	for _, memberDefinition := range structDef.Body.Definitions {
+		if fieldDef, ok := memberDefinition.(*parse.StructFieldDefinition); ok && fieldDef.Name != nil {
+			names = append(names, fieldDef.Name.Name)
+		}
+	}
+	return
+}
+
 func (checker *checker) check(node parse.Node) error {
 	checkNode := func(node, parent, scopeNode parse.Node, ancestorChain []parse.Node, after bool) (parse.TraversalAction, error) {
 		return checker.checkSingleNode(node, parent, scopeNode, ancestorChain, after), nil
@@ -387,10 +633,10 @@ func (checker *checker) getModFunctionDecls(mod parse.Node) map[string]int {
 	return fns
 }
 
-func (checker *checker) getModStructDefs(mod parse.Node) map[string]int {
+func (checker *checker) getModStructDefs(mod parse.Node) map[string]structDefInfo {
 	defs, ok := checker.structDefs[mod]
 	if !ok {
-		defs = make(map[string]int)
+		defs = make(map[string]structDefInfo)
 		checker.structDefs[mod] = defs
 	}
 	return defs
@@ -479,6 +725,8 @@ func (c *checker) checkSingleNode(n, parent, scopeNode parse.Node, ancestorChain
 	//Check that the node is allowed in assertions.
 
 	if closestAssertion != nil {
+		allowed := false
+
 		switch n := n.(type) {
 		case
 			//variables
@@ -504,9 +752,8 @@ func (c *checker) checkSingleNode(n, parent, scopeNode parse.Node, ancestorChain
 			*parse.ComplexStringPatternPiece, *parse.PatternPieceElement, *parse.PatternGroupName,
 			*parse.PatternUnion,
 			*parse.PatternCallExpression:
+			allowed = true
 		case *parse.CallExpression:
-			allowed := false
-
 			ident, ok := n.Callee.(*parse.IdentifierLiteral)
 			if ok {
 				switch ident.Name {
@@ -514,50 +761,43 @@ func (c *checker) checkSingleNode(n, parent, scopeNode parse.Node, ancestorChain
 					allowed = true
 				}
 			}
-
-			if !allowed {
-				c.addError(n, fmtFollowingNodeTypeNotAllowedInAssertions(n))
-			}
 		default:
-			if !parse.NodeIsSimpleValueLiteral(n) {
-				c.addError(n, fmtFollowingNodeTypeNotAllowedInAssertions(n))
-			}
+			allowed = parse.NodeIsSimpleValueLiteral(n)
+		}
+
+		if !allowed && c.checkInput.IsNodeAllowedInAssertions != nil {
+			allowed = c.checkInput.IsNodeAllowedInAssertions(n)
+		}
+
+		if !allowed {
+			c.addError(n, fmtFollowingNodeTypeNotAllowedInAssertions(n))
 		}
 	}
 
 	//Actually check the node.
 
 	switch node := n.(type) {
-	case *parse.IntegerRangeLiteral:
-		if upperBound, ok := node.UpperBound.(*parse.IntLiteral); ok && node.LowerBound.Value > upperBound.Value {
-			c.addError(n, LOWER_BOUND_OF_INT_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND)
-		}
-	case *parse.FloatRangeLiteral:
-		if upperBound, ok := node.UpperBound.(*parse.FloatLiteral); ok && node.LowerBound.Value > upperBound.Value {
-			c.addError(n, LOWER_BOUND_OF_FLOAT_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND)
-		}
-	case *parse.QuantityLiteral:
-		return c.checkQuantityLiteral(node)
-	case *parse.RateLiteral:
-		return c.checkRateLiteral(node)
-	case *parse.URLLiteral:
-		if strings.HasPrefix(node.Value, "mem://") && utils.Must(url.Parse(node.Value)).Host != MEM_HOSTNAME {
-			c.addError(node, INVALID_MEM_HOST_ONLY_VALID_VALUE)
-		}
-	case *parse.HostLiteral:
-		if strings.HasPrefix(node.Value, "mem://") && utils.Must(url.Parse(node.Value)).Host != MEM_HOSTNAME {
-			c.addError(node, INVALID_MEM_HOST_ONLY_VALID_VALUE)
+	case *parse.IntegerRangeLiteral, *parse.FloatRangeLiteral, *parse.QuantityRangeLiteral:
+		c.checkSingleNodeSyntaxOnly(n, parent)
+		c.checkRangeLiteralIsNotStandalone(node, parent)
+	case *parse.QuantityLiteral, *parse.RateLiteral, *parse.URLLiteral, *parse.HostLiteral,
+		*parse.ObjectLiteral, *parse.RecordLiteral, *parse.ObjectPatternLiteral, *parse.RecordPatternLiteral,
+		*parse.DictionaryLiteral:
+		return c.checkSingleNodeSyntaxOnly(n, parent)
+	case *parse.IfStatement:
+		c.checkConditionIsNotConstant(node.Test)
+	case *parse.IfExpression:
+		c.checkConditionIsNotConstant(node.Test)
+	case *parse.AssertionStatement:
+		if boolLit, ok := node.Expr.(*parse.BooleanLiteral); ok && !boolLit.Value {
+			if c.isExperimentalFeatureEnabled(CONSTANT_CONDITION_EXPERIMENTAL_FEATURE) {
+				c.addWarning(boolLit, ASSERTION_ALWAYS_FAILS)
+			}
+		} else {
+			c.checkConditionIsNotConstant(node.Expr)
 		}
-	case *parse.ObjectLiteral:
-		return c.checkObjectLiteral(node)
-	case *parse.RecordLiteral:
-		return c.checkRecordLiteral(node)
-	case *parse.ObjectPatternLiteral, *parse.RecordPatternLiteral:
-		return c.checkObjectRecordPatternLiteral(node)
-	case *parse.DictionaryLiteral:
-		return c.checkDictionaryLiteral(node)
 	case *parse.SpawnExpression:
-		return c.checkSpawnExpr(node, closestModule)
+		return c.checkSpawnExpr(node, closestModule, ancestorChain)
 	case *parse.LifetimejobExpression:
 		return c.checkLifetimejobExpr(node, parent, closestModule)
 	case *parse.ReceptionHandlerExpression:
@@ -565,6 +805,23 @@ func (c *checker) checkSingleNode(n, parent, scopeNode parse.Node, ancestorChain
 			c.addError(node, MISPLACED_RECEPTION_HANDLER_EXPRESSION)
 		}
 
+		switch node.Pattern.(type) {
+		case *parse.ObjectPatternLiteral, *parse.RecordPatternLiteral:
+		default:
+			if node.Pattern != nil {
+				c.addError(node.Pattern, RECEPTION_HANDLER_PATTERN_SHOULD_BE_OBJECT_OR_RECORD_PATTERN)
+			}
+		}
+
+		//the handler is called with the received message.
+		if fnExpr, ok := node.Handler.(*parse.FunctionExpression); ok && len(fnExpr.Parameters) != 1 {
+			c.addError(fnExpr, RECEPTION_HANDLER_FN_SHOULD_TAKE_ONE_PARAM)
+		}
+
+	case *parse.CallExpression:
+		if node == c.misplacedManifest {
+			return parse.Prune
+		}
 	case *parse.MappingExpression:
 		//
 	case *parse.StaticMappingEntry:
@@ -572,7 +829,7 @@ func (c *checker) checkSingleNode(n, parent, scopeNode parse.Node, ancestorChain
 	case *parse.DynamicMappingEntry:
 		return c.checkDynamicMappingEntry(node)
 	case *parse.ComputeExpression:
-		return c.checkComputeExpr(node, scopeNode, ancestorChain)
+		return c.checkComputeExpr(node, scopeNode)
 	case *parse.InclusionImportStatement:
 		return c.checkInclusionImportStmt(node, parent, closestModule, inPreinitBlock)
 	case *parse.ImportStatement:
@@ -582,7 +839,7 @@ func (c *checker) checkSingleNode(n, parent, scopeNode parse.Node, ancestorChain
 	case *parse.LocalVariableDeclarations:
 		return c.checkLocalVarDecls(node, scopeNode, closestModule)
 	case *parse.GlobalVariableDeclarations:
-		return c.checkGlobalVarDecls(node, scopeNode, closestModule)
+		return c.checkGlobalVarDecls(node, parent, scopeNode, closestModule)
 	case *parse.Assignment, *parse.MultiAssignment:
 		return c.checkAssignment(node, scopeNode, closestModule)
 	case *parse.ForStatement:
@@ -597,6 +854,8 @@ func (c *checker) checkSingleNode(n, parent, scopeNode parse.Node, ancestorChain
 		return c.checkFuncExpr(node, closestModule, ancestorChain)
 	case *parse.FunctionPatternExpression:
 		return c.checkFuncPatternExpr(node, closestModule)
+	case *parse.ReturnStatement:
+		return c.checkReturnStmt(node, scopeNode, closestModule)
 	case *parse.YieldStatement:
 		return c.checkYieldStmt(node, ancestorChain)
 	case *parse.BreakStatement, *parse.ContinueStatement:
@@ -658,10 +917,13 @@ func (c *checker) checkSingleNode(n, parent, scopeNode parse.Node, ancestorChain
 			c.addError(node, OPTIONAL_DYN_MEMB_EXPR_NOT_SUPPORTED_YET)
 		}
 	case *parse.ExtendStatement:
-		if _, ok := parent.(*parse.Chunk); !ok {
+		chunk, ok := parent.(*parse.Chunk)
+		if !ok {
 			c.addError(node, MISPLACED_EXTEND_STATEMENT_TOP_LEVEL_STMT)
 			return parse.ContinueTraversal
 		}
+		c.checkExtendedPattern(node, chunk)
+		c.warnAboutParamsShadowingExtendedFields(node, chunk)
 	case *parse.StructDefinition:
 		if parent != closestModule {
 			c.addError(node, MISPLACED_STRUCT_DEF_TOP_LEVEL_STMT)
@@ -672,7 +934,7 @@ func (c *checker) checkSingleNode(n, parent, scopeNode parse.Node, ancestorChain
 	case *parse.NewExpression:
 		return c.checkNewExpr(node)
 	case *parse.StructInitializationLiteral:
-		return c.checkStructInitLiteral(node)
+		return c.checkStructInitLiteral(node, parent, closestModule)
 	case *parse.PointerType:
 		return c.checkPointerType(node, parent)
 	case *parse.DereferenceExpression:
@@ -708,6 +970,135 @@ func (c *checker) precheckTopLevelStatements(chunk *parse.Chunk) {
 			}
 		}
 	}
+
+	if !isIncludedChunk {
+		c.checkKindOfModuleWithTestStatements(chunk)
+		c.checkManifestIsNearTop(chunk)
+	}
+}
+
+// checkManifestIsNearTop reports a manifest preceded by statements other than constant declarations and the
+// preinit statement. Such a manifest is not recognized by the parser, it is parsed as a call to 'manifest'.
+func (c *checker) checkManifestIsNearTop(chunk *parse.Chunk) {
+	if chunk.Manifest != nil {
+		return
+	}
+
+	for _, stmt := range chunk.Statements {
+		call, ok := stmt.(*parse.CallExpression)
+		if !ok || !call.CommandLikeSyntax || len(call.Arguments) != 1 {
+			continue
+		}
+
+		ident, ok := call.Callee.(*parse.IdentifierLiteral)
+		if !ok || ident.Name != parse.MANIFEST_KEYWORD_STR {
+			continue
+		}
+
+		if _, ok := call.Arguments[0].(*parse.ObjectLiteral); ok {
+			c.misplacedManifest = call
+			c.addError(stmt, MANIFEST_SHOULD_BE_NEAR_TOP)
+			return
+		}
+	}
+}
+
+// checkKindOfModuleWithTestStatements reports a warning (or an error) if a regular module contains
+// top-level testsuite/testcase statements but its manifest has no kind section.
+func (c *checker) checkKindOfModuleWithTestStatements(chunk *parse.Chunk) {
+	if c.currentModule != nil && c.currentModule.ModuleKind != UnspecifiedModuleKind {
+		return
+	}
+
+	if chunk.Manifest == nil {
+		return
+	}
+
+	manifestObj, ok := chunk.Manifest.Object.(*parse.ObjectLiteral)
+	if !ok || manifestObj.HasNamedProp(MANIFEST_KIND_SECTION_NAME) {
+		return
+	}
+
+	for _, stmt := range chunk.Statements {
+		isTestStmt := false
+
+		switch stmt := stmt.(type) {
+		case *parse.TestSuiteExpression:
+			isTestStmt = stmt.IsStatement
+		case *parse.TestCaseExpression:
+			isTestStmt = stmt.IsStatement
+		}
+
+		if !isTestStmt {
+			continue
+		}
+
+		if c.checkInput.ErrorOnTestStatementsWithoutKind {
+			c.addError(stmt, TEST_STMTS_IN_MODULE_WITHOUT_KIND_SECTION)
+		} else {
+			c.addWarning(stmt, TEST_STMTS_IN_MODULE_WITHOUT_KIND_SECTION)
+		}
+		return
+	}
+}
+
+// checkSingleNodeSyntaxOnly performs the checks of checkSingleNode that do not depend on the state, the module
+// or the declarations, see CheckChunkSyntaxOnly.
+func (c *checker) checkSingleNodeSyntaxOnly(n, parent parse.Node) parse.TraversalAction {
+	switch node := n.(type) {
+	case *parse.IntegerRangeLiteral:
+		if upperBound, ok := node.UpperBound.(*parse.IntLiteral); ok && node.LowerBound.Value > upperBound.Value {
+			c.addError(n, LOWER_BOUND_OF_INT_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND)
+		}
+	case *parse.FloatRangeLiteral:
+		if upperBound, ok := node.UpperBound.(*parse.FloatLiteral); ok && node.LowerBound.Value > upperBound.Value {
+			c.addError(n, LOWER_BOUND_OF_FLOAT_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND)
+		}
+	case *parse.QuantityRangeLiteral:
+		c.checkQuantityRangeLiteral(node)
+	case *parse.QuantityLiteral:
+		return c.checkQuantityLiteral(node)
+	case *parse.RateLiteral:
+		return c.checkRateLiteral(node)
+	case *parse.URLLiteral:
+		if strings.HasPrefix(node.Value, "mem://") && utils.Must(url.Parse(node.Value)).Host != MEM_HOSTNAME {
+			c.addError(node, INVALID_MEM_HOST_ONLY_VALID_VALUE)
+		}
+	case *parse.HostLiteral:
+		if strings.HasPrefix(node.Value, "mem://") && utils.Must(url.Parse(node.Value)).Host != MEM_HOSTNAME {
+			c.addError(node, INVALID_MEM_HOST_ONLY_VALID_VALUE)
+		}
+	case *parse.ObjectLiteral:
+		return c.checkObjectLiteral(node)
+	case *parse.RecordLiteral:
+		return c.checkRecordLiteral(node)
+	case *parse.ObjectPatternLiteral, *parse.RecordPatternLiteral:
+		return c.checkObjectRecordPatternLiteral(node)
+	case *parse.DictionaryLiteral:
+		return c.checkDictionaryLiteral(node)
+	}
+	return parse.ContinueTraversal
+}
+
+// checkConditionIsNotConstant adds a warning if the condition is a boolean literal, only literals are
+// reported in order to keep the check simple. See CONSTANT_CONDITION_EXPERIMENTAL_FEATURE.
+func (c *checker) checkConditionIsNotConstant(condition parse.Node) {
+	if !c.isExperimentalFeatureEnabled(CONSTANT_CONDITION_EXPERIMENTAL_FEATURE) {
+		return
+	}
+
+	if _, ok := condition.(*parse.BooleanLiteral); ok {
+		c.addWarning(condition, CONSTANT_CONDITION)
+	}
+}
+
+// checkRangeLiteralIsNotStandalone adds a warning if the range literal is a statement: a range literal
+// computes nothing on its own.
+func (c *checker) checkRangeLiteralIsNotStandalone(node, parent parse.Node) {
+	switch parent.(type) {
+	case *parse.Chunk, *parse.Block, *parse.EmbeddedModule:
+		c.addWarning(node, RANGE_LITERAL_HAS_NO_EFFECT)
+	}
 }
 
 func (c *checker) checkQuantityLiteral(node *parse.QuantityLiteral) parse.TraversalAction {
@@ -798,6 +1189,58 @@ func (c *checker) checkQuantityLiteral(node *parse.QuantityLiteral) parse.Traver
 	return parse.ContinueTraversal
 }
 
+// checkQuantityRangeLiteral checks that the bounds have the same unit family and that the lower bound is not greater
+// than the upper bound. Invalid bounds are ignored because they are reported by checkQuantityLiteral.
+func (c *checker) checkQuantityRangeLiteral(node *parse.QuantityRangeLiteral) {
+	upperBoundLit, ok := node.UpperBound.(*parse.QuantityLiteral)
+	if !ok || node.LowerBound == nil {
+		return
+	}
+
+	lowerBound, err := evalQuantity(node.LowerBound.Values, node.LowerBound.Units)
+	if err != nil {
+		return
+	}
+
+	upperBound, err := evalQuantity(upperBoundLit.Values, upperBoundLit.Units)
+	if err != nil {
+		return
+	}
+
+	if getQuantityUnitFamily(node.LowerBound.Units) != getQuantityUnitFamily(upperBoundLit.Units) {
+		c.addError(node, QUANTITY_RANGE_BOUNDS_UNIT_MISMATCH)
+		return
+	}
+
+	comparable, ok := lowerBound.(Comparable)
+	if !ok {
+		return
+	}
+
+	if result, ok := comparable.Compare(upperBound); ok && result > 0 {
+		c.addError(node, LOWER_BOUND_OF_QUANTITY_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND)
+	}
+}
+
+// getQuantityUnitFamily returns the unit of the last part of a quantity without its multiplier,
+// all duration units belong to the same family.
+func getQuantityUnitFamily(units []string) string {
+	unit := units[len(units)-1]
+
+	if len(unit) > 1 {
+		switch unit[0] {
+		case 'k', 'M', 'G', 'T':
+			unit = unit[1:]
+		}
+	}
+
+	switch unit {
+	case "h", "mn", "s", "ms", "us", "ns":
+		return "duration"
+	}
+	return unit
+}
+
 func (c *checker) checkRateLiteral(node *parse.RateLiteral) parse.TraversalAction {
 	lastUnit1 := node.Units[len(node.Units)-1]
 	rateUnit := node.DivUnit
@@ -958,7 +1401,7 @@ func (c *checker) checkDictionaryLiteral(node *parse.DictionaryLiteral) parse.Tr
 	return parse.ContinueTraversal
 }
 
-func (c *checker) checkSpawnExpr(node *parse.SpawnExpression, closestModule parse.Node) parse.TraversalAction {
+func (c *checker) checkSpawnExpr(node *parse.SpawnExpression, closestModule parse.Node, ancestorChain []parse.Node) parse.TraversalAction {
 
 	var globals = make(map[string]globalVarInfo)
 	var globalDescNode parse.Node
@@ -1021,11 +1464,21 @@ func (c *checker) checkSpawnExpr(node *parse.SpawnExpression, closestModule pars
 	if node.Module != nil && node.Module.SingleCallExpr {
 		calleeNode := node.Module.Statements[0].(*parse.CallExpression).Callee
 
+		//the callee (or the left of the callee) is passed to the embedded module, so it should be declared in the parent scope.
+		var calleeIdent *parse.IdentifierLiteral
+
 		switch calleeNode := calleeNode.(type) {
 		case *parse.IdentifierLiteral:
-			globals[calleeNode.Name] = globalVarInfo{isConst: true}
+			calleeIdent = calleeNode
 		case *parse.IdentifierMemberExpression:
-			globals[calleeNode.Left.Name] = globalVarInfo{isConst: true}
+			calleeIdent = calleeNode.Left
+		}
+
+		if calleeIdent != nil {
+			if !c.varExists(calleeIdent.Name, ancestorChain) {
+				c.addError(calleeIdent, fmtVarIsNotDeclared(calleeIdent.Name))
+			}
+			globals[calleeIdent.Name] = globalVarInfo{isConst: true}
 		}
 	}
 
@@ -1103,28 +1556,18 @@ func (c *checker) checkDynamicMappingEntry(node *parse.DynamicMappingEntry) pars
 	return parse.ContinueTraversal
 }
 
-func (c *checker) checkComputeExpr(node *parse.ComputeExpression, scopeNode parse.Node, ancestorChain []parse.Node) parse.TraversalAction {
-	if _, ok := scopeNode.(*parse.DynamicMappingEntry); !ok {
-		c.addError(node, MISPLACED_COMPUTE_EXPR_SHOULD_BE_IN_DYNAMIC_MAPPING_EXPR_ENTRY)
-	} else {
-	ancestor_loop:
-		for i := len(ancestorChain) - 1; i >= 0; i-- {
-			ancestor := ancestorChain[i]
-			if ancestor == scopeNode {
-				break
-			}
+func (c *checker) checkComputeExpr(node *parse.ComputeExpression, scopeNode parse.Node) parse.TraversalAction {
+	//Mapping entries and mapping expressions are scope containers so the scope node is the closest entry,
+	//even if the expression is located in a mapping nested inside the right side of another entry.
+	if staticEntry, ok := scopeNode.(*parse.StaticMappingEntry); ok && node.IncludedIn(staticEntry.Key) {
+		c.addError(node, MISPLACED_COMPUTE_EXPR_IN_STATIC_MAPPING_ENTRY_KEY)
+		return parse.ContinueTraversal
+	}
 
-			switch a := ancestor.(type) {
-			case *parse.StaticMappingEntry:
-				c.addError(node, MISPLACED_COMPUTE_EXPR_SHOULD_BE_IN_DYNAMIC_MAPPING_EXPR_ENTRY)
-				break ancestor_loop
-			case *parse.DynamicMappingEntry:
-				if a.Key == node || i < len(ancestorChain)-1 && ancestorChain[i+1] == a.Key {
-					c.addError(node, MISPLACED_COMPUTE_EXPR_SHOULD_BE_IN_DYNAMIC_MAPPING_EXPR_ENTRY)
-				}
-				break ancestor_loop
-			}
-		}
+	entry, ok := scopeNode.(*parse.DynamicMappingEntry)
+
+	if !ok || node.IncludedIn(entry.Key) {
+		c.addError(node, MISPLACED_COMPUTE_EXPR_SHOULD_BE_IN_DYNAMIC_MAPPING_EXPR_ENTRY)
 	}
 	return parse.ContinueTraversal
 }
@@ -1171,7 +1614,7 @@ func (c *checker) checkInclusionImportStmt(node *parse.InclusionImportStatement,
 		parentChecker:            c,
 		checkInput:               c.checkInput,
 		fnDecls:                  make(map[parse.Node]map[string]int),
-		structDefs:               make(map[parse.Node]map[string]int),
+		structDefs:               make(map[parse.Node]map[string]structDefInfo),
 		globalVars:               globals,
 		localVars:                make(map[parse.Node]map[string]localVarInfo),
 		properties:               make(map[*parse.ObjectLiteral]*propertyInfo),
@@ -1180,6 +1623,8 @@ func (c *checker) checkInclusionImportStmt(node *parse.InclusionImportStatement,
 		currentModule:            c.currentModule,
 		chunk:                    includedChunk.ParsedChunkSource,
 		inclusionImportStatement: node,
+		globalVarUsages:          c.globalVarUsages,
+		errorCountOffset:         c.errorCountOffset + len(c.data.errors),
 		store:                    make(map[parse.Node]any),
 		data: &StaticCheckData{
 			fnData:      map[*parse.FunctionExpression]*FunctionStaticData{},
@@ -1194,21 +1639,13 @@ func (c *checker) checkInclusionImportStmt(node *parse.InclusionImportStatement,
 		panic(err)
 	}
 
-	if len(chunkChecker.data.errors) != 0 {
-		c.data.errors = append(c.data.errors, chunkChecker.data.errors...)
-	}
+	c.mergeErrorsOfChildChecker(chunkChecker)
 
 	if len(chunkChecker.data.warnings) != 0 {
 		c.data.warnings = append(c.data.warnings, chunkChecker.data.warnings...)
 	}
 
-	for k, v := range chunkChecker.data.fnData {
-		c.data.fnData[k] = v
-	}
-
-	for k, v := range chunkChecker.data.mappingData {
-		c.data.mappingData[k] = v
-	}
+	c.data.mergeFnAndMappingData(chunkChecker.data)
 
 	// include all global data & top level local variables
 	for k, v := range chunkChecker.fnDecls[includedChunk.Node] {
@@ -1355,7 +1792,7 @@ func (c *checker) checkImportStmt(node *parse.ImportStatement, parent, closestMo
 		parentChecker:         c,
 		checkInput:            c.checkInput,
 		fnDecls:               make(map[parse.Node]map[string]int),
-		structDefs:            make(map[parse.Node]map[string]int),
+		structDefs:            make(map[parse.Node]map[string]structDefInfo),
 		globalVars:            globals,
 		localVars:             make(map[parse.Node]map[string]localVarInfo),
 		properties:            make(map[*parse.ObjectLiteral]*propertyInfo),
@@ -1364,6 +1801,7 @@ func (c *checker) checkImportStmt(node *parse.ImportStatement, parent, closestMo
 		currentModule:         importedModule,
 		chunk:                 importedModule.MainChunk,
 		moduleImportStatement: node,
+		errorCountOffset:      c.errorCountOffset + len(c.data.errors),
 		store:                 make(map[parse.Node]any),
 		data: &StaticCheckData{
 			fnData:      map[*parse.FunctionExpression]*FunctionStaticData{},
@@ -1378,9 +1816,7 @@ func (c *checker) checkImportStmt(node *parse.ImportStatement, parent, closestMo
 		panic(err)
 	}
 
-	if len(chunkChecker.data.errors) != 0 {
-		c.data.errors = append(c.data.errors, chunkChecker.data.errors...)
-	}
+	c.mergeErrorsOfChildChecker(chunkChecker)
 
 	if len(chunkChecker.data.warnings) != 0 {
 		c.data.warnings = append(c.data.warnings, chunkChecker.data.warnings...)
@@ -1435,7 +1871,14 @@ func (c *checker) checkLocalVarDecls(node *parse.LocalVariableDeclarations, scop
 	return parse.ContinueTraversal
 }
 
-func (c *checker) checkGlobalVarDecls(node *parse.GlobalVariableDeclarations, scopeNode, closestModule parse.Node) parse.TraversalAction {
+func (c *checker) checkGlobalVarDecls(node *parse.GlobalVariableDeclarations, parent, scopeNode, closestModule parse.Node) parse.TraversalAction {
+	switch parent.(type) {
+	case *parse.Chunk, *parse.EmbeddedModule:
+	default:
+		c.addError(node, MISPLACED_GLOBAL_VAR_DECLS_TOP_LEVEL_STMT)
+		return parse.Prune
+	}
+
 	globalVars := c.getModGlobalVars(closestModule)
 
 	for _, decl := range node.Declarations {
@@ -1454,6 +1897,7 @@ func (c *checker) checkGlobalVarDecls(node *parse.GlobalVariableDeclarations, sc
 			return parse.ContinueTraversal
 		}
 		globalVars[name] = globalVarInfo{}
+		c.recordGlobalVarDeclaration(name, decl, closestModule)
 	}
 
 	return parse.ContinueTraversal
@@ -1491,11 +1935,13 @@ func (c *checker) checkAssignment(node parse.Node, scopeNode, closestModule pars
 					c.addError(node, fmtInvalidGlobalVarAssignmentNameIsConstant(left.Name))
 					return parse.ContinueTraversal
 				}
+				c.recordGlobalVarReassignment(left.Name, closestModule)
 			} else {
 				if assignment.Operator != parse.Assign {
 					c.addError(node, fmtInvalidGlobalVarAssignmentVarDoesNotExist(left.Name))
 				}
 				variables[left.Name] = globalVarInfo{isConst: false}
+				c.recordGlobalVarDeclaration(left.Name, node, closestModule)
 			}
 
 		case *parse.Variable:
@@ -1571,6 +2017,25 @@ func (c *checker) checkAssignment(node parse.Node, scopeNode, closestModule pars
 
 			names = append(names, name)
 		}
+
+		//check the arity if the right side is a list or tuple literal with a fixed length.
+		var elements []parse.Node
+		hasFixedLength := false
+
+		switch right := assignment.Right.(type) {
+		case *parse.ListLiteral:
+			elements, hasFixedLength = right.Elements, !right.HasSpreadElements()
+		case *parse.TupleLiteral:
+			elements, hasFixedLength = right.Elements, !right.HasSpreadElements()
+		}
+
+		if hasFixedLength {
+			varCount := len(assignment.Variables)
+			//missing elements are allowed in nillable multi-assignments: the remaining variables are set to nil.
+			if len(elements) > varCount || (len(elements) < varCount && !assignment.Nillable) {
+				c.addWarning(assignment.Right, MULTI_ASSIGN_ARITY_MISMATCH)
+			}
+		}
 	}
 
 	for _, name := range names {
@@ -1670,8 +2135,11 @@ func (c *checker) checkFuncDecl(node *parse.FunctionDeclaration, parent, closest
 
 		fns[node.Name.Name] = 0
 		globVars[node.Name.Name] = globalVarInfo{isConst: true, fnExpr: node.Function}
+		c.recordDeclaredFunction(node.Name.Name, node.Name, node.Function, false)
 	case *parse.StructBody:
-		//struct method
+		//struct method: the globals it references are recorded in the data of its function expression,
+		//like the globals referenced by any other function.
+		c.recordDeclaredFunction(node.Name.Name, node.Name, node.Function, true)
 	default:
 		c.addError(node, INVALID_FN_DECL_SHOULD_BE_TOP_LEVEL_STMT)
 		return parse.ContinueTraversal
@@ -1680,7 +2148,29 @@ func (c *checker) checkFuncDecl(node *parse.FunctionDeclaration, parent, closest
 	return parse.ContinueTraversal
 }
 
+// recordDeclaredFunction records a named function, see StaticCheckData.FunctionNames.
+func (c *checker) recordDeclaredFunction(name string, nameNode parse.Node, fnExpr *parse.FunctionExpression, isMethod bool) {
+	position := c.chunk.GetSourcePosition(nameNode.Base().Span)
+	if c.parentChecker == nil && c.checkInput.SourceNameOverride != "" {
+		position.SourceName = c.checkInput.SourceNameOverride
+	}
+
+	c.data.declaredFunctions = append(c.data.declaredFunctions, DeclaredFunction{
+		Name:     name,
+		Position: position,
+		IsMethod: isMethod,
+		Function: fnExpr,
+	})
+}
+
 func (c *checker) checkFuncExpr(node *parse.FunctionExpression, closestModule parse.Node, ancestorChain []parse.Node) parse.TraversalAction {
+	//object method
+	if len(ancestorChain) > 0 {
+		if prop, ok := ancestorChain[len(ancestorChain)-1].(*parse.ObjectProperty); ok && prop.Value == node && !prop.HasImplicitKey() {
+			c.recordDeclaredFunction(prop.Name(), prop.Key, node, true)
+		}
+	}
+
 	fnLocalVars := c.getLocalVarsInScope(node)
 
 	//we check that the captured variable exists & is a local
@@ -1734,6 +2224,19 @@ func (c *checker) checkFuncPatternExpr(node *parse.FunctionPatternExpression, cl
 	return parse.ContinueTraversal
 }
 
+// checkReturnStmt adds a warning if a value is returned at the top level of a test suite or test case module:
+// the value would be ignored.
+func (c *checker) checkReturnStmt(node *parse.ReturnStatement, scopeNode, closestModule parse.Node) parse.TraversalAction {
+	if node.Expr == nil || scopeNode != closestModule || c.currentModule == nil || !c.currentModule.ModuleKind.IsTestModule() {
+		return parse.ContinueTraversal
+	}
+
+	if _, ok := closestModule.(*parse.Chunk); ok {
+		c.addWarning(node, RETURN_VALUE_IGNORED_IN_TEST_MODULE)
+	}
+	return parse.ContinueTraversal
+}
+
 func (c *checker) checkYieldStmt(node *parse.YieldStatement, ancestorChain []parse.Node) parse.TraversalAction {
 	ok := c.checkInput.Module != nil && c.checkInput.Module.IsEmbedded()
 
@@ -1850,6 +2353,124 @@ func (c *checker) checkVariable(node *parse.Variable, scopeNode parse.Node) pars
 	return parse.ContinueTraversal
 }
 
+// suggestMakingMethods adds a warning for each top-level function declaration whose only captured global is a global
+// initialized with an object literal by a top-level statement of module, globals assigned by several top-level statements
+// are ignored. See CONSIDER_MAKING_METHOD_EXPERIMENTAL_FEATURE.
+func (c *checker) suggestMakingMethods(module parse.Node) {
+	statements := getModuleStatements(module)
+
+	assignmentCounts := map[string]int{}
+	objectGlobals := map[string]bool{}
+
+	recordAssignment := func(name string, right parse.Node) {
+		assignmentCounts[name]++
+		_, isObjectLiteral := right.(*parse.ObjectLiteral)
+		objectGlobals[name] = isObjectLiteral && assignmentCounts[name] == 1
+	}
+
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *parse.GlobalVariableDeclarations:
+			for _, decl := range stmt.Declarations {
+				if ident, ok := decl.Left.(*parse.IdentifierLiteral); ok {
+					recordAssignment(ident.Name, decl.Right)
+				}
+			}
+		case *parse.Assignment:
+			if globalVar, ok := stmt.Left.(*parse.GlobalVariable); ok {
+				recordAssignment(globalVar.Name, stmt.Right)
+			}
+		}
+	}
+
+	for _, stmt := range statements {
+		decl, ok := stmt.(*parse.FunctionDeclaration)
+		if !ok || decl.Name == nil {
+			continue
+		}
+
+		fnData := c.data.GetFnData(decl.Function)
+		if fnData != nil && len(fnData.capturedGlobals) == 1 && objectGlobals[fnData.capturedGlobals[0]] {
+			c.addWarning(decl.Name, CONSIDER_MAKING_METHOD)
+		}
+	}
+}
+
+// globalVarUsageScope returns the key of the global variable usages of closestModule: the globals of an included chunk
+// are the globals of the module including it.
+func (c *checker) globalVarUsageScope(closestModule parse.Node) parse.Node {
+	if c.inclusionImportStatement != nil && closestModule == c.chunk.Node {
+		//inclusion import statements are top-level statements.
+		return c.parentChecker.globalVarUsageScope(c.parentChecker.chunk.Node)
+	}
+	return closestModule
+}
+
+func (c *checker) recordGlobalVarDeclaration(name string, declaration, closestModule parse.Node) {
+	if c.globalVarUsages == nil {
+		return
+	}
+	scope := c.globalVarUsageScope(closestModule)
+
+	usages, ok := c.globalVarUsages[scope]
+	if !ok {
+		usages = make(map[string]*globalVarUsage)
+		c.globalVarUsages[scope] = usages
+	}
+
+	if usage, ok := usages[name]; ok {
+		//the variable is declared by another chunk (shadowing error).
+		usage.isReassigned = true
+		return
+	}
+	usages[name] = &globalVarUsage{declaration: declaration, location: c.getSourcePositionStack(declaration)}
+}
+
+func (c *checker) recordGlobalVarRead(name string, closestModule parse.Node) {
+	if usage, ok := c.globalVarUsages[c.globalVarUsageScope(closestModule)][name]; ok {
+		usage.isRead = true
+	}
+}
+
+func (c *checker) recordGlobalVarReassignment(name string, closestModule parse.Node) {
+	if usage, ok := c.globalVarUsages[c.globalVarUsageScope(closestModule)][name]; ok {
+		usage.isReassigned = true
+	}
+}
+
+// warnAboutGlobalsThatCouldBeConstants adds a warning for each global variable that is read but never reassigned.
+func (c *checker) warnAboutGlobalsThatCouldBeConstants() {
+	type candidate struct {
+		name     string
+		location parse.SourcePositionStack
+	}
+	var candidates []candidate
+
+	for _, usages := range c.globalVarUsages {
+		for name, usage := range usages {
+			if usage.isRead && !usage.isReassigned {
+				candidates = append(candidates, candidate{name, usage.location})
+			}
+		}
+	}
+
+	//the declarations located in included chunks are sorted by the position of their inclusion import statement,
+	//and then by their position in the included chunk.
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		for i := 0; i < min(len(a.location), len(b.location)); i++ {
+			if diff := a.location[i].Span.Start - b.location[i].Span.Start; diff != 0 {
+				return int(diff)
+			}
+		}
+		return len(a.location) - len(b.location)
+	})
+
+	for _, candidate := range candidates {
+		warning := NewStaticCheckWarning(fmtGlobalVarCouldBeConstant(candidate.name), candidate.location)
+		c.data.warnings = append(c.data.warnings, warning)
+	}
+}
+
 func (c *checker) checkGlobalVar(node *parse.GlobalVariable, parent, scopeNode, closestModule parse.Node, ancestorChain []parse.Node) parse.TraversalAction {
 
 	if len(node.Name) > MAX_NAME_BYTE_LEN {
@@ -1880,11 +2501,19 @@ func (c *checker) checkGlobalVar(node *parse.GlobalVariable, parent, scopeNode,
 		return parse.ContinueTraversal
 	}
 
+	if node.Name == globalnames.CURRENT_TEST && !c.isInTestCaseModule(closestModule, ancestorChain) {
+		c.addError(node, CURRENT_TEST_ONLY_AVAILABLE_IN_TEST_CASES)
+		return parse.ContinueTraversal
+	}
+
 	if !exist {
 		c.addError(node, fmtGlobalVarIsNotDeclared(node.Name))
 		return parse.ContinueTraversal
 	}
 
+	c.recordGlobalVarRead(node.Name, closestModule)
+	c.checkAssertionDoesNotDependOnMutableGlobal(node, globalVarInfo, ancestorChain)
+
 	switch scope := scopeNode.(type) {
 	case *parse.FunctionExpression:
 		c.data.addFnCapturedGlobal(scope, node.Name, &globalVarInfo)
@@ -2028,6 +2657,13 @@ func (c *checker) checkIdentifier(node *parse.IdentifierLiteral, parent, scopeNo
 		return parse.ContinueTraversal
 	}
 
+	if node.Name == globalnames.CURRENT_TEST && !c.isInTestCaseModule(closestModule, ancestorChain) {
+		if _, isLocal := c.getLocalVarsInScope(scopeNode)[node.Name]; !isLocal {
+			c.addError(node, CURRENT_TEST_ONLY_AVAILABLE_IN_TEST_CASES)
+			return parse.ContinueTraversal
+		}
+	}
+
 	if !c.varExists(node.Name, ancestorChain) {
 		if node.Name == "const" {
 			c.addError(node, VAR_CONST_NOT_DECLARED_IF_YOU_MEANT_TO_DECLARE_CONSTANTS_GLOBAL_CONST_DECLS_ONLY_SUPPORTED_AT_THE_START_OF_THE_MODULE)
@@ -2041,6 +2677,11 @@ func (c *checker) checkIdentifier(node *parse.IdentifierLiteral, parent, scopeNo
 	if c.doGlobalVarExist(node.Name, closestModule) {
 		globalVarInfo := c.getModGlobalVars(closestModule)[node.Name]
 
+		if decl, ok := parent.(*parse.GlobalVariableDeclaration); !ok || decl.Left != node {
+			c.recordGlobalVarRead(node.Name, closestModule)
+			c.checkAssertionDoesNotDependOnMutableGlobal(node, globalVarInfo, ancestorChain)
+		}
+
 		switch scope := scopeNode.(type) {
 		case *parse.FunctionExpression:
 			c.data.addFnCapturedGlobal(scope, node.Name, &globalVarInfo)
@@ -2077,6 +2718,96 @@ func (c *checker) checkIdentifier(node *parse.IdentifierLiteral, parent, scopeNo
 	return parse.ContinueTraversal
 }
 
+// checkAssertionDoesNotDependOnMutableGlobal adds a warning if the reference to a global variable is part of an assertion
+// and the variable is not constant: the result of such an assertion may depend on when it is evaluated.
+func (c *checker) checkAssertionDoesNotDependOnMutableGlobal(ref parse.Node, info globalVarInfo, ancestorChain []parse.Node) {
+	if info.isConst || findClosest[*parse.AssertionStatement](ancestorChain) == nil {
+		return
+	}
+	c.addWarning(ref, ASSERTION_DEPENDS_ON_MUTABLE_GLOBAL)
+}
+
+// checkExtendedPattern reports the extension of a pattern defined in the same chunk as a literal that clearly does
+// not describe objects (e.g. an integer literal), the full resolution of the extended pattern is done by the symbolic evaluation.
+func (c *checker) checkExtendedPattern(node *parse.ExtendStatement, chunk *parse.Chunk) {
+	ident, ok := node.ExtendedPattern.(*parse.PatternIdentifierLiteral)
+	if !ok {
+		return
+	}
+
+	def := findPatternDefinition(chunk, ident.Name)
+	if def == nil {
+		return
+	}
+
+	switch def.Right.(type) {
+	case *parse.IdentifierLiteral:
+		//not a pattern, an error is reported elsewhere.
+	case parse.SimpleValueLiteral, *parse.ListPatternLiteral, *parse.TuplePatternLiteral:
+		c.addError(node.ExtendedPattern, CANNOT_EXTEND_NON_OBJECT_PATTERN)
+	}
+}
+
+// findPatternDefinition returns the top-level definition of the pattern named name in chunk, or nil if not found.
+func findPatternDefinition(chunk *parse.Chunk, name string) *parse.PatternDefinition {
+	for _, stmt := range chunk.Statements {
+		def, ok := stmt.(*parse.PatternDefinition)
+		if !ok {
+			continue
+		}
+		if defName, ok := def.PatternName(); ok && defName == name {
+			return def
+		}
+	}
+	return nil
+}
+
+// warnAboutParamsShadowingExtendedFields adds a warning for each parameter of the extension methods whose name is
+// the name of a property of the extended pattern. The properties are only known if the pattern is an object pattern
+// literal or a pattern defined at the top level by an object pattern literal.
+func (c *checker) warnAboutParamsShadowingExtendedFields(node *parse.ExtendStatement, chunk *parse.Chunk) {
+	extension, ok := node.Extension.(*parse.ObjectLiteral)
+	if !ok {
+		return
+	}
+
+	var objectPattern *parse.ObjectPatternLiteral
+
+	switch p := node.ExtendedPattern.(type) {
+	case *parse.ObjectPatternLiteral:
+		objectPattern = p
+	case *parse.PatternIdentifierLiteral:
+		if def := findPatternDefinition(chunk, p.Name); def != nil {
+			objectPattern, _ = def.Right.(*parse.ObjectPatternLiteral)
+		}
+	}
+
+	if objectPattern == nil {
+		return
+	}
+
+	fields := map[string]bool{}
+	for _, prop := range objectPattern.Properties {
+		switch prop.Key.(type) {
+		case *parse.IdentifierLiteral, *parse.QuotedStringLiteral:
+			fields[prop.Name()] = true
+		}
+	}
+
+	for _, prop := range extension.Properties {
+		method, ok := prop.Value.(*parse.FunctionExpression)
+		if !ok {
+			continue
+		}
+
+		for _, param := range method.Parameters {
+			if param.Var != nil && fields[param.Var.Name] {
+				c.addWarning(param, fmtParameterShadowsExtendedField(param.Var.Name))
+			}
+		}
+	}
+}
+
 func (c *checker) checkSelfExprAndSendValExpr(node, parent parse.Node, ancestorChain []parse.Node) parse.TraversalAction {
 	isSelfExpr := true
 
@@ -2240,11 +2971,21 @@ func (c *checker) checkPatternDef(node *parse.PatternDefinition, parent, closest
 	if ok {
 		patterns := c.getModPatterns(closestModule)
 
-		if _, alreadyDefined := patterns[patternName]; alreadyDefined && !inPreinitBlock {
+		_, alreadyDefined := patterns[patternName]
+		if alreadyDefined && !inPreinitBlock {
 			c.addError(node, fmtPatternAlreadyDeclared(patternName))
 		} else {
 			patterns[patternName] = 0
 		}
+
+		//structs are defined before the checking of the module's statements, so the order of the definitions does not matter.
+		if _, isStructName := c.getModStructDefs(closestModule)[patternName]; isStructName {
+			c.addError(node, fmtNameCollidesWithStructType(patternName))
+		}
+
+		if !alreadyDefined && c.referencedPatterns != nil {
+			c.patternDefinitions = append(c.patternDefinitions, node)
+		}
 	}
 	return parse.ContinueTraversal
 }
@@ -2294,6 +3035,11 @@ func (c *checker) checkPatternIdentifier(node *parse.PatternIdentifierLiteral, p
 
 	}
 
+	if def, ok := parent.(*parse.PatternDefinition); ok && def.Left == node {
+		//collisions with struct types are reported by checkPatternDef.
+		return parse.ContinueTraversal
+	}
+
 	//Check if struct type.
 	stuctDefs := c.getModStructDefs(closestModule)
 	_, ok := stuctDefs[node.Name]
@@ -2323,19 +3069,20 @@ func (c *checker) checkPatternIdentifier(node *parse.PatternIdentifierLiteral, p
 
 	}
 
-	//Ignore the check if the pattern identifier refers to a pattern that is not yet defined.
-
-	for _, a := range ancestorChain {
-		if def, ok := a.(*parse.PatternDefinition); ok && def.IsLazy {
-			return parse.ContinueTraversal
-		}
-	}
+	c.recordPatternReference(node, ancestorChain)
 
 	//Check that the pattern is declared.
 
 	name := node.Name
 	patterns := c.getModPatterns(closestModule)
 	if _, ok := patterns[name]; !ok {
+		//Lazy pattern definitions are allowed to reference the pattern being defined and the patterns defined later.
+		if def := findClosest[*parse.PatternDefinition](ancestorChain); def != nil && def.IsLazy {
+			if defName, ok := def.PatternName(); (ok && defName == name) || isPatternDefinedByModule(name, closestModule) {
+				return parse.ContinueTraversal
+			}
+		}
+
 		errMsg := ""
 		switch parent.(type) {
 		case *parse.PointerType, *parse.NewExpression:
@@ -2348,6 +3095,63 @@ func (c *checker) checkPatternIdentifier(node *parse.PatternIdentifierLiteral, p
 	return parse.ContinueTraversal
 }
 
+// isPatternDefinedByModule returns true if a top-level statement of module (*parse.Chunk or *parse.EmbeddedModule)
+// defines a pattern named name.
+func isPatternDefinedByModule(name string, module parse.Node) bool {
+	for _, stmt := range getModuleStatements(module) {
+		if def, ok := stmt.(*parse.PatternDefinition); ok {
+			if defName, ok := def.PatternName(); ok && defName == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getModuleStatements returns the top-level statements of a *parse.Chunk or *parse.EmbeddedModule.
+func getModuleStatements(module parse.Node) []parse.Node {
+	switch m := module.(type) {
+	case *parse.Chunk:
+		return m.Statements
+	case *parse.EmbeddedModule:
+		return m.Statements
+	}
+	return nil
+}
+
+// recordPatternReference records a reference to a pattern if pattern usages are tracked. The name of a pattern definition
+// and the references inside the definition of the referenced pattern are not recorded.
+func (c *checker) recordPatternReference(node *parse.PatternIdentifierLiteral, ancestorChain []parse.Node) {
+	if c.referencedPatterns == nil {
+		return
+	}
+
+	def := findClosest[*parse.PatternDefinition](ancestorChain)
+	if def != nil {
+		if name, ok := def.PatternName(); ok && name == node.Name {
+			return
+		}
+	}
+
+	c.referencedPatterns[node.Name] = true
+}
+
+// warnAboutUnusedPatterns adds a warning for each pattern definition whose pattern is never referenced.
+func (c *checker) warnAboutUnusedPatterns() {
+	definitions := slices.Clone(c.patternDefinitions)
+
+	slices.SortFunc(definitions, func(a, b *parse.PatternDefinition) int {
+		return int(a.Span.Start - b.Span.Start)
+	})
+
+	for _, def := range definitions {
+		name, _ := def.PatternName()
+		if !c.referencedPatterns[name] {
+			c.addWarning(def, UNUSED_PATTERN_DEFINITION)
+		}
+	}
+}
+
 func (c *checker) checkRuntimeTypeCheckExpr(node *parse.RuntimeTypeCheckExpression, parent parse.Node) parse.TraversalAction {
 	switch p := parent.(type) {
 	case *parse.CallExpression:
@@ -2379,8 +3183,22 @@ func (c *checker) checkNewExpr(node *parse.NewExpression) parse.TraversalAction
 	return parse.ContinueTraversal
 }
 
-func (c *checker) checkStructInitLiteral(node *parse.StructInitializationLiteral) parse.TraversalAction {
-	// look for duplicate field names
+func (c *checker) checkStructInitLiteral(node *parse.StructInitializationLiteral, parent, closestModule parse.Node) parse.TraversalAction {
+	//get the definition of the struct type, undefined struct types are reported when checking the type name.
+	var (
+		structName string
+		structDef  structDefInfo
+		isDefined  bool
+	)
+
+	if newExpr, ok := parent.(*parse.NewExpression); ok {
+		if patternIdent, ok := newExpr.Type.(*parse.PatternIdentifierLiteral); ok {
+			structName = patternIdent.Name
+			structDef, isDefined = c.getModStructDefs(closestModule)[structName]
+		}
+	}
+
+	// look for duplicate and unknown field names
 	fieldNames := make([]string, 0, len(node.Fields))
 
 	for _, field := range node.Fields {
@@ -2392,6 +3210,10 @@ func (c *checker) checkStructInitLiteral(node *parse.StructInitializationLiteral
 			} else {
 				fieldNames = append(fieldNames, name)
 			}
+
+			if isDefined && !slices.Contains(structDef.fields, name) {
+				c.addError(fieldInit.Name, fmtStructHasNoFieldNamed(structName, name))
+			}
 		}
 	}
 	return parse.ContinueTraversal
@@ -2506,6 +3328,21 @@ search_test_suite:
 	return parse.ContinueTraversal
 }
 
+// isInTestCaseModule returns true if closestModule is the module of a test case,
+// ancestorChain should contain closestModule.
+func (c *checker) isInTestCaseModule(closestModule parse.Node, ancestorChain []parse.Node) bool {
+	if _, ok := closestModule.(*parse.EmbeddedModule); !ok {
+		return c.currentModule != nil && c.currentModule.ModuleKind == TestCaseModule
+	}
+
+	index := slices.Index(ancestorChain, closestModule)
+	if index <= 0 {
+		return false
+	}
+	_, ok := ancestorChain[index-1].(*parse.TestCaseExpression)
+	return ok
+}
+
 func (c *checker) checkEmbeddedModule(node *parse.EmbeddedModule, parent, parentModule parse.Node, ancestorChain []parse.Node) parse.TraversalAction {
 	globals := c.getModGlobalVars(node)
 	patterns := c.getModPatterns(node)
@@ -2608,8 +3445,24 @@ func (checker *checker) postCheckSingleNode(node, parent, scopeNode parse.Node,
 					onError: func(n parse.Node, msg string) {
 						checker.addError(n, msg)
 					},
+					onWarning: func(n parse.Node, msg string) {
+						checker.addWarning(n, msg)
+					},
 				})
-			} //else: the manifest of regular modules is already checked during the pre-init phase
+			} else {
+				//the manifest of regular modules is already checked during the pre-init phase,
+				//we only report the warnings about the invocation and permissions sections.
+				if invocationDesc, ok := n.PropValue(MANIFEST_INVOCATION_SECTION_NAME); ok {
+					if invocationObj, ok := invocationDesc.(*parse.ObjectLiteral); ok {
+						checkInvocationObject(invocationObj, n, func(n parse.Node, msg string) {}, checker.addWarning, nil)
+					}
+				}
+				if permsDesc, ok := n.PropValue(MANIFEST_PERMS_SECTION_NAME); ok {
+					if permsObj, ok := permsDesc.(*parse.ObjectLiteral); ok {
+						checkPermissionListingObject(permsObj, func(n parse.Node, msg string) {}, checker.addWarning)
+					}
+				}
+			}
 		}
 	case *parse.ForStatement, *parse.WalkStatement:
 		varsBefore := checker.store[node].(map[string]localVarInfo)
@@ -2642,13 +3495,13 @@ func checkVisibilityInitializationBlock(propInfo *propertyInfo, block *parse.Ini
 		}
 
 		switch prop.Name() {
-		case "public":
+		case VISIBILITY_DESC_PUBLIC_KEY:
 			_, ok := prop.Value.(*parse.KeyListExpression)
 			if !ok {
 				onError(prop, VAL_SHOULD_BE_KEYLIST_LIT)
 				return
 			}
-		case "visible_by":
+		case VISIBILITY_DESC_VISIBLE_BY_KEY:
 			dict, ok := prop.Value.(*parse.DictionaryLiteral)
 			if !ok {
 				onError(prop, VAL_SHOULD_BE_DICT_LIT)
@@ -2659,7 +3512,7 @@ func checkVisibilityInitializationBlock(propInfo *propertyInfo, block *parse.Ini
 				switch keyNode := entry.Key.(type) {
 				case *parse.UnambiguousIdentifierLiteral:
 					switch keyNode.Name {
-					case "self":
+					case VISIBILITY_DESC_SELF_KEY:
 						_, ok := entry.Value.(*parse.KeyListExpression)
 						if !ok {
 							onError(entry, VAL_SHOULD_BE_KEYLIST_LIT)
@@ -2737,6 +3590,10 @@ func shallowCheckObjectRecordProperties(
 
 		extractionExpr, isValid := element.Expr.(*parse.ExtractionExpression)
 		if !isValid {
+			//the error is not reported twice if the parser has already reported it.
+			if element.Err == nil {
+				addError(element, SPREAD_ELEMENT_SHOULD_BE_EXTRACTION_EXPR)
+			}
 			continue
 		}
 
@@ -2788,6 +3645,10 @@ type StaticCheckError struct {
 	Message        string
 	LocatedMessage string
 	Location       parse.SourcePositionStack
+
+	//Locations related to the error that tools can show alongside it,
+	//e.g. the location of the first definition of a struct declared twice.
+	RelatedLocations []parse.SourcePositionStack
 }
 
 func NewStaticCheckError(s string, location parse.SourcePositionStack) *StaticCheckError {
@@ -2811,6 +3672,17 @@ func (err StaticCheckError) MessageWithoutLocation() string {
 	return err.Message
 }
 
+// Is returns true if target is the category of the error (ErrMisplacement, ErrDuplicateDeclaration or ErrUndeclaredVariable),
+// the category is derived from the message. Note that the combined error returned by StaticCheck does not match the
+// categories, (*StaticCheckData).Errors should be used instead.
+func (err StaticCheckError) Is(target error) bool {
+	switch target {
+	case ErrMisplacement, ErrDuplicateDeclaration, ErrUndeclaredVariable:
+		return getStaticCheckErrorCategory(strings.TrimPrefix(err.Message, CHECK_ERR_PREFIX)) == target
+	}
+	return false
+}
+
 func (err StaticCheckError) LocationStack() parse.SourcePositionStack {
 	return err.Location
 }
@@ -1,6 +1,8 @@
 package core
 
 import (
+	"slices"
+	"sync"
 	"sync/atomic"
 
 	"github.com/inoxlang/inox/internal/parse"
@@ -14,6 +16,8 @@ type StaticCheckData struct {
 	fnData      map[*parse.FunctionExpression]*FunctionStaticData
 	mappingData map[*parse.MappingExpression]*MappingStaticData
 
+	declaredFunctions []DeclaredFunction
+
 	//.errors property accessible from scripts
 	errorsPropSet atomic.Bool
 	errorsProp    *Tuple
@@ -21,6 +25,13 @@ type StaticCheckData struct {
 	//.warnings property accessible from scripts
 	warningsPropSet atomic.Bool
 	warningsProp    *Tuple
+
+	//index of the source positions of the nodes of the checked chunk, it is built on the first call to SourcePositions.
+	//positionIndexChunk is nil if StaticCheckInput.IndexSourcePositions was false.
+	positionIndexChunk      *parse.ParsedChunkSource
+	positionIndexSourceName string //if not empty it replaces the name of the chunk
+	positionIndexOnce       sync.Once
+	sourcePositions         map[parse.Node]parse.SourcePositionRange
 }
 
 // Errors returns all errors in the code after a static check, the result should not be modified.
@@ -28,6 +39,128 @@ func (d *StaticCheckData) Errors() []*StaticCheckError {
 	return d.errors
 }
 
+// HasErrors returns true if there is at least one error.
+func (d *StaticCheckData) HasErrors() bool {
+	return len(d.errors) > 0
+}
+
+// HasWarnings returns true if there is at least one warning.
+func (d *StaticCheckData) HasWarnings() bool {
+	return len(d.warnings) > 0
+}
+
+// NodeErrors returns the errors whose innermost source position is located in the span of node (node included).
+// sourceName is the name of the chunk containing the node, it should be equal to StaticCheckInput.SourceNameOverride if the
+// override was set.
+func (d *StaticCheckData) NodeErrors(node parse.Node, sourceName string) []*StaticCheckError {
+	var errors []*StaticCheckError
+	nodeSpan := node.Base().Span
+
+	for _, err := range d.errors {
+		if len(err.Location) == 0 {
+			continue
+		}
+
+		innermostPosition := err.Location[len(err.Location)-1]
+		if innermostPosition.SourceName != sourceName {
+			continue
+		}
+
+		if innermostPosition.Span.Start >= nodeSpan.Start && innermostPosition.Span.End <= nodeSpan.End {
+			errors = append(errors, err)
+		}
+	}
+
+	return errors
+}
+
+// SourcePositions returns an index of the source positions of the nodes of the checked chunk, nil is returned if the
+// positions were not indexed (see StaticCheckInput.IndexSourcePositions). The result should not be modified.
+func (d *StaticCheckData) SourcePositions() map[parse.Node]parse.SourcePositionRange {
+	if d.positionIndexChunk == nil {
+		return nil
+	}
+
+	d.positionIndexOnce.Do(d.buildSourcePositionIndex)
+	return d.sourcePositions
+}
+
+func (d *StaticCheckData) buildSourcePositionIndex() {
+	chunk := d.positionIndexChunk
+	d.sourcePositions = map[parse.Node]parse.SourcePositionRange{}
+
+	parse.Walk(chunk.Node, func(node, parent, scopeNode parse.Node, ancestorChain []parse.Node, after bool) (parse.TraversalAction, error) {
+		position := chunk.GetSourcePosition(node.Base().Span)
+		if d.positionIndexSourceName != "" {
+			position.SourceName = d.positionIndexSourceName
+		}
+		d.sourcePositions[node] = position
+		return parse.ContinueTraversal, nil
+	}, nil)
+}
+
+// Merge returns a new StaticCheckData containing the errors, warnings, declared functions, function data and mapping
+// data of d and other, d and other are not modified. The data of functions and mapping expressions present in both
+// d and other is combined.
+func (d *StaticCheckData) Merge(other *StaticCheckData) *StaticCheckData {
+	merged := &StaticCheckData{
+		errors:   append(slices.Clone(d.errors), other.errors...),
+		warnings: append(slices.Clone(d.warnings), other.warnings...),
+	}
+
+	merged.mergeFnAndMappingData(d)
+	merged.mergeFnAndMappingData(other)
+	return merged
+}
+
+// mergeFnAndMappingData adds the declared functions, function data and mapping data of other to d, the entries
+// of other are copied.
+func (d *StaticCheckData) mergeFnAndMappingData(other *StaticCheckData) {
+	d.declaredFunctions = append(d.declaredFunctions, other.declaredFunctions...)
+
+	if d.fnData == nil && len(other.fnData) != 0 {
+		d.fnData = map[*parse.FunctionExpression]*FunctionStaticData{}
+	}
+
+	for fnExpr, otherFnData := range other.fnData {
+		fnData := d.fnData[fnExpr]
+		if fnData == nil {
+			d.fnData[fnExpr] = &FunctionStaticData{
+				capturedGlobals: slices.Clone(otherFnData.capturedGlobals),
+				assignGlobal:    otherFnData.assignGlobal,
+			}
+			continue
+		}
+
+		for _, name := range otherFnData.capturedGlobals {
+			if !utils.SliceContains(fnData.capturedGlobals, name) {
+				fnData.capturedGlobals = append(fnData.capturedGlobals, name)
+			}
+		}
+		fnData.assignGlobal = fnData.assignGlobal || otherFnData.assignGlobal
+	}
+
+	if d.mappingData == nil && len(other.mappingData) != 0 {
+		d.mappingData = map[*parse.MappingExpression]*MappingStaticData{}
+	}
+
+	for expr, otherMappingData := range other.mappingData {
+		mappingData := d.mappingData[expr]
+		if mappingData == nil {
+			d.mappingData[expr] = &MappingStaticData{
+				referencedGlobals: slices.Clone(otherMappingData.referencedGlobals),
+			}
+			continue
+		}
+
+		for _, name := range otherMappingData.referencedGlobals {
+			if !utils.SliceContains(mappingData.referencedGlobals, name) {
+				mappingData.referencedGlobals = append(mappingData.referencedGlobals, name)
+			}
+		}
+	}
+}
+
 func (d *StaticCheckData) ErrorTuple() *Tuple {
 	if d.errorsPropSet.CompareAndSwap(false, true) {
 		errors := make([]Serializable, len(d.errors))
@@ -82,6 +215,21 @@ func (*StaticCheckData) PropertyNames(ctx *Context) []string {
 	return STATIC_CHECK_DATA_PROP_NAMES
 }
 
+// FunctionNames returns the names and positions of the functions declared in the checked code in the order they were
+// checked, this is intended for outline and symbol views. The result should not be modified.
+func (d *StaticCheckData) FunctionNames() []DeclaredFunction {
+	return d.declaredFunctions
+}
+
+// A DeclaredFunction is a named function of the checked code: a top-level function declaration, a struct method or
+// an object method (function expression that is the value of an object property).
+type DeclaredFunction struct {
+	Name     string
+	Position parse.SourcePositionRange //position of the name
+	IsMethod bool
+	Function *parse.FunctionExpression
+}
+
 type FunctionStaticData struct {
 	capturedGlobals []string
 	assignGlobal    bool
@@ -1,14 +1,25 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
 	permkind "github.com/inoxlang/inox/internal/core/permkind"
+	"github.com/inoxlang/inox/internal/globals/globalnames"
 	"github.com/inoxlang/inox/internal/parse"
 )
 
+var (
+	//categories of static check errors, see StaticCheckError.Is.
+
+	ErrMisplacement         = errors.New("misplacement")
+	ErrDuplicateDeclaration = errors.New("duplicate declaration")
+	ErrUndeclaredVariable   = errors.New("undeclared variable")
+)
+
 const (
+	TOO_MANY_ERRORS                              = "too many errors, the next errors are not reported"
 	MODULE_IMPORTS_NOT_ALLOWED_IN_INCLUDED_CHUNK = "modules imports are not allowed in included chunks"
 
 	//global constant declarations
@@ -21,7 +32,8 @@ const (
 
 	//kind section
 	KIND_SECTION_SHOULD_BE_A_STRING_LITERAL             = "the '" + MANIFEST_KIND_SECTION_NAME + "' section of the manifest should have a string value (string literal)"
-	INVALID_KIND_SECTION_EMBEDDED_MOD_KINDS_NOT_ALLOWED = "invalid '" + MANIFEST_KIND_SECTION_NAME + "' section: embedded module kinds are not allowed"
+	INVALID_KIND_SECTION_EMBEDDED_MOD_KINDS_NOT_ALLOWED = "invalid '" + MANIFEST_KIND_SECTION_NAME + "' section: embedded module kinds are not allowed, " +
+		"the kind of an embedded module (e.g. the module of a lthread) is determined by the way it is created and cannot be declared"
 
 	//permissions section
 	PERMS_SECTION_SHOULD_BE_AN_OBJECT     = "the '" + MANIFEST_PERMS_SECTION_NAME + "' section of the manifest should be an object"
@@ -43,6 +55,8 @@ const (
 	//permissions
 	NO_PERM_DESCRIBED_BY_THIS_TYPE_OF_VALUE         = "there is no permission described by this type of value"
 	NO_PERM_DESCRIBED_BY_STRINGS                    = "there is no permission described by strings"
+	PERM_NOT_APPLICABLE_TO_MEM_HOST                 = "write, update and delete permissions are not applicable to the memory host (mem://" + MEM_HOSTNAME + ")"
+	EMPTY_PERMISSION_KIND_VALUE                     = "the permission kind is followed by an empty list or object, it grants no permissions"
 	MAYBE_YOU_MEANT_TO_WRITE_A_PATH_LITERAL         = "maybe you meant to write a path literal such as /dir/ or /data.json (always unquoted)"
 	MAYBE_YOU_MEANT_TO_WRITE_A_PATH_PATTERN_LITERAL = "maybe you meant to write a path pattern literal such as %/... or %/*.json (always unquoted)"
 	MAYBE_YOU_MEANT_TO_WRITE_A_URL_LITERAL          = "maybe you meant to write a url literal such as https://example.com/ (always unquoted)"
@@ -70,9 +84,12 @@ const (
 	A_BOOL_LIT_IS_EXPECTED                                        = "a boolean literal is expected"
 	SCHEME_NOT_DB_SCHEME_OR_IS_NOT_SUPPORTED                      = "this scheme is not a database scheme or is not supported"
 	THE_DATABASES_SECTION_SHOULD_BE_PRESENT                       = "the databases section should be present because the auto invocation of the module depends on one or more database(s)"
+	ASYNC_INVOCATION_HAS_NO_EFFECT_WITHOUT_VALID_TRIGGER          = "." + MANIFEST_INVOCATION__ASYNC_PROP_NAME + " has no effect because there is no valid ." + MANIFEST_INVOCATION__ON_ADDED_ELEM_PROP_NAME + " property"
 
 	HOST_DEFS_SECTION_SHOULD_BE_A_DICT = "the '" + MANIFEST_HOST_DEFINITIONS_SECTION_NAME + "' section of the manifest should be a dictionary with host keys"
 	HOST_SCHEME_NOT_SUPPORTED          = "the host's scheme is not supported"
+	HOST_DEFS_KEYS_SHOULD_BE_HOST_LITS = "the keys of the '" + MANIFEST_HOST_DEFINITIONS_SECTION_NAME + "' section should be host literals (e.g. ldb://main)"
+	MANIFEST_SHOULD_BE_NEAR_TOP        = "the manifest should be near the top of the module: only constant declarations and the preinit statement are allowed before it"
 
 	//included chunk
 	AN_INCLUDED_CHUNK_SHOULD_ONLY_CONTAIN_DEFINITIONS = "an included chunk should only contain definitions (functions, patterns, ...)"
@@ -96,6 +113,7 @@ const (
 	CANNOT_CHECK_OBJECT_PROP_WITHOUT_PARENT       = "checking an ObjectProperty node requires the parent ObjectLiteral node"
 	CANNOT_CHECK_OBJECT_METAPROP_WITHOUT_PARENT   = "checking an ObjectMetaProperty node requires the parent ObjectLiteral node"
 	OBJ_REC_LIT_CANNOT_HAVE_METAPROP_KEYS         = "object-like literals cannot have metaproperty keys, metaproperty keys have a (single) starting underscore '_' and a (single) trailing underscore"
+	SPREAD_ELEMENT_SHOULD_BE_EXTRACTION_EXPR      = "the expression of a spread element should be an extraction expression (e.g. ...$obj.{a, b})"
 	CANNOT_CHECK_MANIFEST_WITHOUT_PARENT          = "checking a Manifest node requires the parent node"
 	CANNOT_CHECK_STRUCT_METHOD_DEF_WITHOUT_PARENT = "checking the definition of a struct method requires the parent node"
 
@@ -106,27 +124,41 @@ const (
 	//object pattern literals
 	UNEXPECTED_OTHER_PROPS_EXPR_OTHERPROPS_NO_IS_PRESENT = "unexpected otherprops expression: no other properties are allowed since otherprops(no) is present"
 
-	MISPLACED_SENDVAL_EXPR                 = "sendval expressions are only usable within methods of object extensions, metaproperty initialization blocks and in lifetime jobs"
-	MISPLACED_RECEPTION_HANDLER_EXPRESSION = "misplaced reception handler expression is misplaced, it should be an element (no key) of an object literal"
+	MISPLACED_SENDVAL_EXPR                                       = "sendval expressions are only usable within methods of object extensions, metaproperty initialization blocks and in lifetime jobs"
+	MISPLACED_RECEPTION_HANDLER_EXPRESSION                       = "misplaced reception handler expression is misplaced, it should be an element (no key) of an object literal"
+	RECEPTION_HANDLER_PATTERN_SHOULD_BE_OBJECT_OR_RECORD_PATTERN = "the pattern of a reception handler should be an object pattern literal or a record pattern literal"
+	RECEPTION_HANDLER_FN_SHOULD_TAKE_ONE_PARAM                   = "the function of a reception handler should take exactly one parameter: the received message"
 
 	INVALID_MAPPING_ENTRY_KEY_ONLY_SIMPL_LITS_AND_PATT_IDENTS      = "invalid mapping entry key: only simple value literals and pattern identifiers are supported"
 	ONLY_GLOBALS_ARE_ACCESSIBLE_FROM_RIGHT_SIDE_OF_MAPPING_ENTRIES = "only globals are accessible from the right side of mapping entries"
 
 	MISPLACED_RUNTIME_TYPECHECK_EXPRESSION                         = "misplaced runtime typecheck expression: for now runtime typechecks are only supported as arguments in function calls (ex: map ~$ .title)"
 	MISPLACED_COMPUTE_EXPR_SHOULD_BE_IN_DYNAMIC_MAPPING_EXPR_ENTRY = "misplaced compute expression: compute expressions are only allowed on the right side of a dynamic Mapping entry"
+	MISPLACED_COMPUTE_EXPR_IN_STATIC_MAPPING_ENTRY_KEY             = "misplaced compute expression: compute expressions are not allowed in the key of a static Mapping entry, they are only allowed on the right side of a dynamic entry (e.g. n 0 => comp n)"
 	MISPLACE_YIELD_STATEMENT_ONLY_ALLOWED_IN_EMBEDDED_MODULES      = "misplaced yield statement: yield statements are only allowed in embedded modules"
 	MISPLACED_INCLUSION_IMPORT_STATEMENT_TOP_LEVEL_STMT            = "misplaced inclusion import statement: it should be located at the module's top level or a the top level of the preinit block"
 	MISPLACED_MOD_IMPORT_STATEMENT_TOP_LEVEL_STMT                  = "misplaced module import statement: it should be located at the top level"
 	MISPLACED_PATTERN_DEF_STATEMENT_TOP_LEVEL_STMT                 = "misplaced pattern definition statement: it should be located at the top level"
 	MISPLACED_PATTERN_NS_DEF_STATEMENT_TOP_LEVEL_STMT              = "misplaced pattern namespace definition statement: it should be located at the top level"
 	MISPLACED_HOST_ALIAS_DEF_STATEMENT_TOP_LEVEL_STMT              = "misplaced host alias definition statement: it should be located at the top level"
+	MISPLACED_GLOBAL_VAR_DECLS_TOP_LEVEL_STMT                      = "misplaced global variable declaration(s): it should be located at the top level"
 	MISPLACED_READONLY_PATTERN_EXPRESSION                          = "misplaced readonly pattern expression: they are only allowed as the type of function parameters"
 	MISPLACED_EXTEND_STATEMENT_TOP_LEVEL_STMT                      = "misplaced extend statement: it should be located at the top level"
+	CANNOT_EXTEND_NON_OBJECT_PATTERN                               = "only object patterns can be extended"
 	MISPLACED_STRUCT_DEF_TOP_LEVEL_STMT                            = "misplaced struct definition: it should be located at the top level"
 
-	INVALID_MEM_HOST_ONLY_VALID_VALUE                                 = "invalid mem:// host, only valid value is " + MEM_HOSTNAME
-	LOWER_BOUND_OF_INT_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND   = "the lower bound of an integer range literal should be smaller than the upper bound"
-	LOWER_BOUND_OF_FLOAT_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND = "the lower bound of a float range literal should be smaller than the upper bound"
+	INVALID_MEM_HOST_ONLY_VALID_VALUE                                    = "invalid mem:// host, only valid value is " + MEM_HOSTNAME
+	LOWER_BOUND_OF_INT_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND      = "the lower bound of an integer range literal should be smaller than the upper bound"
+	LOWER_BOUND_OF_FLOAT_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND    = "the lower bound of a float range literal should be smaller than the upper bound"
+	LOWER_BOUND_OF_QUANTITY_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND = "the lower bound of a quantity range literal should be smaller than the upper bound"
+	QUANTITY_RANGE_BOUNDS_UNIT_MISMATCH                                  = "the bounds of a quantity range literal should have the same kind of unit"
+	RANGE_LITERAL_HAS_NO_EFFECT                                          = "this range literal has no effect, it is not used"
+	UNUSED_PATTERN_DEFINITION                                            = "this pattern is never referenced, you may want to remove its definition"
+	CONSTANT_CONDITION                                                   = "this condition is a constant, it may be a debugging leftover"
+	ASSERTION_ALWAYS_FAILS                                               = "this assertion always fails because its condition is false"
+	MULTI_ASSIGN_ARITY_MISMATCH                                          = "the number of assigned variables does not match the number of elements in the literal"
+	ASSERTION_DEPENDS_ON_MUTABLE_GLOBAL                                  = "this assertion depends on a global variable that is not constant, its result may depend on when it is evaluated"
+	CONSIDER_MAKING_METHOD                                               = "the only global captured by this function is an object, consider making the function a method of this object"
 
 	//lifetime job
 	MISSING_LIFETIMEJOB_SUBJECT_PATTERN_NOT_AN_IMPLICIT_OBJ_PROP = "missing subject pattern of lifetime job: subject can only be ommitted for lifetime jobs that are implicit object properties"
@@ -158,7 +190,11 @@ const (
 	//test suites & cases
 	TEST_CASES_NOT_ALLOWED_IF_SUBSUITES_ARE_PRESENT     = "test cases are not allowed if sub suites are presents"
 	TEST_CASE_STMTS_NOT_ALLOWED_OUTSIDE_OF_TEST_SUITES  = "test case statements are not allowed outside of test suites"
+	CURRENT_TEST_ONLY_AVAILABLE_IN_TEST_CASES           = "the current test (" + globalnames.CURRENT_TEST + ") is only available in test cases"
 	TEST_SUITE_STMTS_NOT_ALLOWED_INSIDE_TEST_CASE_STMTS = "test suite statements are not allowed in test case statements"
+	TEST_STMTS_IN_MODULE_WITHOUT_KIND_SECTION           = "the module contains top-level test statements but its manifest has no '" +
+		MANIFEST_KIND_SECTION_NAME + "' section, you may want to add " + MANIFEST_KIND_SECTION_NAME + `: "spec"`
+	RETURN_VALUE_IGNORED_IN_TEST_MODULE = "the value returned by a test suite or test case module is ignored, you may want to use a bare return statement"
 
 	//new expressions
 	A_STRUCT_TYPE_NAME_IS_EXPECTED = "a struct type name is expected"
@@ -182,6 +218,10 @@ func fmtForbiddenNodeInLimitsSection(n parse.Node) string {
 		MANIFEST_LIMITS_SECTION_NAME, n)
 }
 
+func fmtLimitValueShouldBeQuantityOrRate(name string) string {
+	return fmt.Sprintf("the value of the limit '%s' should be a quantity, a rate, an integer or a global variable", name)
+}
+
 func fmtForbiddenNodeInEnvSection(n parse.Node) string {
 	return fmt.Sprintf(
 		"invalid %s section: invalid node %T, only variables, simple literals & named patterns are allowed",
@@ -206,6 +246,22 @@ func fmtForbiddenNodeInHostDefinitionsSection(n parse.Node) string {
 		MANIFEST_HOST_DEFINITIONS_SECTION_NAME, n)
 }
 
+func fmtNotHostLiteralInHostDefinitionsSection(n parse.Node) string {
+	if _, ok := n.(*parse.URLLiteral); ok {
+		return HOST_DEFS_KEYS_SHOULD_BE_HOST_LITS + ", URLs are not allowed: the path should be removed"
+	}
+	return HOST_DEFS_KEYS_SHOULD_BE_HOST_LITS
+}
+
+func fmtHostSchemeNotSupported(scheme Scheme) string {
+	return fmt.Sprintf("%s: %s", HOST_SCHEME_NOT_SUPPORTED, scheme)
+}
+
+func fmtInvalidURLInHostDefinitionsSection(url string) string {
+	return fmt.Sprintf("invalid key in the '%s' section: %s is an invalid URL, a host literal is expected (e.g. ldb://main)",
+		MANIFEST_HOST_DEFINITIONS_SECTION_NAME, url)
+}
+
 func fmtForbiddenNodeInParametersSection(n parse.Node) string {
 	return fmt.Sprintf("invalid %s description: forbidden node %T", MANIFEST_PARAMS_SECTION_NAME, n)
 }
@@ -338,10 +394,18 @@ func fmtPatternNamespaceAlreadyDeclared(name string) string {
 	return fmt.Sprintf("pattern namespace %%%s is already declared", name)
 }
 
+func fmtNameCollidesWithStructType(name string) string {
+	return fmt.Sprintf("the name '%s' collides with the name of a struct type, %%%s would be ambiguous", name, name)
+}
+
 func fmtStructTypeIsNotDefined(name string) string {
 	return fmt.Sprintf("struct type '%s' is not defined", name)
 }
 
+func fmtStructHasNoFieldNamed(structName, fieldName string) string {
+	return fmt.Sprintf("struct type '%s' has no field named '%s'", structName, fieldName)
+}
+
 func fmtCannotPassGlobalThatIsNotDeclaredToLThread(name string) string {
 	return fmt.Sprintf("cannot pass global variable '%s' to lthread, '%s' is not declared", name, name)
 }
@@ -366,6 +430,14 @@ func fmtGlobalVarIsNotDeclared(name string) string {
 	return fmt.Sprintf("global variable '%s' is not declared", name)
 }
 
+func fmtParameterShadowsExtendedField(name string) string {
+	return fmt.Sprintf("parameter '%s' has the same name as a property of the extended pattern, you may want to rename it", name)
+}
+
+func fmtGlobalVarCouldBeConstant(name string) string {
+	return fmt.Sprintf("global variable '%s' is never reassigned, it could be declared in the constant declarations at the top of the module (const (...))", name)
+}
+
 func fmtPatternIsNotDeclared(name string) string {
 	return fmt.Sprintf("pattern %%%s is not declared", name)
 }
@@ -374,6 +446,10 @@ func fmtPatternNamespaceIsNotDeclared(name string) string {
 	return fmt.Sprintf("pattern namespace %%%s is not declared", name)
 }
 
+func fmtInternalStaticCheckFailure(err error) string {
+	return fmt.Sprintf("internal failure of the static check: %s", err.Error())
+}
+
 func fmtObjectDoesNotHaveProp(name string) string {
 	return fmt.Sprintf("object dos not have a .%s property", name)
 }
@@ -393,3 +469,19 @@ func fmtCannotInferPermission(kind string, name string) string {
 func fmtTheXSectionIsNotAllowedForTheCurrentModuleKind(sectionName string, moduleKind ModuleKind) string {
 	return fmt.Sprintf("the %q section is not allowed for the current module kind (%s)", sectionName, moduleKind.String())
 }
+
+// getStaticCheckErrorCategory derives the category of a static check error from its message (without prefix and location),
+// nil is returned if the error has no category.
+func getStaticCheckErrorCategory(msg string) error {
+	switch {
+	case strings.HasPrefix(msg, "misplaced "):
+		return ErrMisplacement
+	case strings.Contains(msg, " is already declared"):
+		return ErrDuplicateDeclaration
+	case strings.HasPrefix(msg, "variable '"), strings.HasPrefix(msg, "local variable '"), strings.HasPrefix(msg, "global variable '"):
+		if strings.Contains(msg, "' is not declared") {
+			return ErrUndeclaredVariable
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/inoxlang/inox/internal/parse"
+	pprint "github.com/inoxlang/inox/internal/prettyprint"
+)
+
+type StaticCheckErrorPrettyPrintConfig struct {
+	Colorize bool
+	Colors   *pprint.PrettyPrintColors //defaults to pprint.DEFAULT_DARKMODE_PRINT_COLORS if Colorize is true
+}
+
+// PrettyPrintStaticCheckError writes the message of a static check error followed by a snippet for each frame of its
+// location stack, similar to compiler diagnostics:
+//
+//	check: <message>
+//	 --> /main.ix:2:5
+//	  |
+//	2 |     a = b
+//	  |         ^
+//
+// The innermost frame of each related location (see StaticCheckError.RelatedLocations) is then written after a
+// 'note: related location' line. getChunk is called to retrieve the source chunk of each frame, only the location
+// is written for frames whose chunk is not found.
+func PrettyPrintStaticCheckError(
+	w io.Writer,
+	err *StaticCheckError,
+	getChunk func(sourceName string) (*parse.ParsedChunkSource, bool),
+	config StaticCheckErrorPrettyPrintConfig,
+) error {
+	buf := bytes.NewBuffer(nil)
+
+	colors := config.Colors
+	if config.Colorize && colors == nil {
+		colors = &pprint.DEFAULT_DARKMODE_PRINT_COLORS
+	}
+
+	writeColorized := func(color []byte, s string) {
+		if config.Colorize {
+			buf.Write(color)
+			buf.WriteString(s)
+			buf.Write(ANSI_RESET_SEQUENCE)
+		} else {
+			buf.WriteString(s)
+		}
+	}
+
+	var errorColor, discreteColor []byte
+	if config.Colorize {
+		errorColor = colors.ErrorColor
+		discreteColor = colors.DiscreteColor
+	}
+
+	writeColorized(errorColor, err.Message)
+	buf.WriteByte('\n')
+
+	writeFrame := func(frame parse.SourcePositionRange, caretColor []byte) {
+		lineNumber := strconv.Itoa(int(frame.StartLine))
+		margin := strings.Repeat(" ", len(lineNumber))
+
+		buf.WriteString(margin)
+		writeColorized(discreteColor, "--> ")
+		buf.WriteString(frame.SourceName + ":" + lineNumber + ":" + strconv.Itoa(int(frame.StartColumn)))
+		buf.WriteByte('\n')
+
+		chunk, ok := getChunk(frame.SourceName)
+		if !ok {
+			return
+		}
+
+		line, caretOffset, caretCount, ok := getStaticCheckErrorLine(chunk, frame.Span)
+		if !ok {
+			return
+		}
+
+		writeColorized(discreteColor, margin+" |")
+		buf.WriteByte('\n')
+
+		writeColorized(discreteColor, lineNumber+" | ")
+		buf.WriteString(string(line))
+		buf.WriteByte('\n')
+
+		writeColorized(discreteColor, margin+" | ")
+
+		//keep the tabs of the line to align the carets.
+		for _, r := range line[:caretOffset] {
+			if r == '\t' {
+				buf.WriteByte('\t')
+			} else {
+				buf.WriteByte(' ')
+			}
+		}
+		writeColorized(caretColor, strings.Repeat("^", caretCount))
+		buf.WriteByte('\n')
+	}
+
+	for _, frame := range err.Location {
+		writeFrame(frame, errorColor)
+	}
+
+	for _, location := range err.RelatedLocations {
+		if len(location) == 0 {
+			continue
+		}
+		writeColorized(discreteColor, "note: related location")
+		buf.WriteByte('\n')
+		writeFrame(location[len(location)-1], discreteColor)
+	}
+
+	_, writeErr := w.Write(buf.Bytes())
+	return writeErr
+}
+
+// getStaticCheckErrorLine returns the line containing the start of span, the offset of the span in the line,
+// and the number of characters of the span that are on the line (at least 1).
+func getStaticCheckErrorLine(chunk *parse.ParsedChunkSource, span parse.NodeSpan) (line []rune, offset int, count int, ok bool) {
+	runes := chunk.Runes()
+	start := int(span.Start)
+
+	if start < 0 || start > len(runes) {
+		return nil, 0, 0, false
+	}
+
+	lineStart := start
+	for lineStart > 0 && runes[lineStart-1] != '\n' {
+		lineStart--
+	}
+
+	lineEnd := start
+	for lineEnd < len(runes) && runes[lineEnd] != '\n' {
+		lineEnd++
+	}
+
+	line = runes[lineStart:lineEnd]
+	line = []rune(strings.TrimSuffix(string(line), "\r"))
+	offset = min(start-lineStart, len(line))
+	count = max(1, min(int(span.End), lineStart+len(line))-start)
+
+	return line, offset, count, true
+}
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/inoxlang/inox/internal/parse"
+	pprint "github.com/inoxlang/inox/internal/prettyprint"
+	"github.com/inoxlang/inox/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrettyPrintStaticCheckError(t *testing.T) {
+
+	getChunkFn := func(chunks ...*parse.ParsedChunkSource) func(string) (*parse.ParsedChunkSource, bool) {
+		return func(sourceName string) (*parse.ParsedChunkSource, bool) {
+			for _, chunk := range chunks {
+				if chunk.Name() == sourceName {
+					return chunk, true
+				}
+			}
+			return nil, false
+		}
+	}
+
+	getFirstCheckError := func(t *testing.T, chunk *parse.ParsedChunkSource) *StaticCheckError {
+		ctx := NewContexWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		data, err := StaticCheck(StaticCheckInput{
+			State: ctx.state,
+			Node:  chunk.Node,
+			Chunk: chunk,
+		})
+
+		if !assert.Error(t, err) || !assert.NotEmpty(t, data.Errors()) {
+			t.FailNow()
+		}
+		return data.Errors()[0]
+	}
+
+	t.Run("single frame", func(t *testing.T) {
+		chunk := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "/main.ix",
+			CodeString: "manifest {}\nx = (1 + y)",
+		}))
+
+		checkErr := getFirstCheckError(t, chunk)
+
+		buf := bytes.NewBuffer(nil)
+		err := PrettyPrintStaticCheckError(buf, checkErr, getChunkFn(chunk), StaticCheckErrorPrettyPrintConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		expected := checkErr.Message + "\n" +
+			" --> /main.ix:2:10\n" +
+			"  |\n" +
+			"2 | x = (1 + y)\n" +
+			"  |          ^\n"
+
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("the carets should be aligned with a line indented with tabs", func(t *testing.T) {
+		chunk := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "/main.ix",
+			CodeString: "manifest {}\nfn f(){\n\t\treturn yy\n}",
+		}))
+
+		checkErr := getFirstCheckError(t, chunk)
+
+		buf := bytes.NewBuffer(nil)
+		err := PrettyPrintStaticCheckError(buf, checkErr, getChunkFn(chunk), StaticCheckErrorPrettyPrintConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Contains(t, buf.String(), "3 | \t\treturn yy\n  | \t\t       ^^\n")
+	})
+
+	t.Run("several frames", func(t *testing.T) {
+		mainChunk := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "/main.ix",
+			CodeString: "manifest {}\nimport ./dep.ix",
+		}))
+
+		depChunk := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "/dep.ix",
+			CodeString: "includable-chunk\na = b",
+		}))
+
+		importStmt := parse.FindNode(mainChunk.Node, (*parse.InclusionImportStatement)(nil), nil)
+		ident := parse.FindNodes(depChunk.Node, (*parse.IdentifierLiteral)(nil), nil)[1]
+
+		checkErr := NewStaticCheckError(fmtVarIsNotDeclared("b"), parse.SourcePositionStack{
+			mainChunk.GetSourcePosition(importStmt.Span),
+			depChunk.GetSourcePosition(ident.Span),
+		})
+
+		buf := bytes.NewBuffer(nil)
+		err := PrettyPrintStaticCheckError(buf, checkErr, getChunkFn(mainChunk, depChunk), StaticCheckErrorPrettyPrintConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		expected := checkErr.Message + "\n" +
+			" --> /main.ix:2:1\n" +
+			"  |\n" +
+			"2 | import ./dep.ix\n" +
+			"  | ^^^^^^^^^^^^^^^\n" +
+			" --> /dep.ix:2:5\n" +
+			"  |\n" +
+			"2 | a = b\n" +
+			"  |     ^\n"
+
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("related location", func(t *testing.T) {
+		chunk := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "/main.ix",
+			CodeString: "manifest {}\nstruct Point {}\nstruct Point {}",
+		}))
+
+		checkErr := getFirstCheckError(t, chunk)
+		if !assert.Len(t, checkErr.RelatedLocations, 1) {
+			return
+		}
+
+		buf := bytes.NewBuffer(nil)
+		err := PrettyPrintStaticCheckError(buf, checkErr, getChunkFn(chunk), StaticCheckErrorPrettyPrintConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		expected := checkErr.Message + "\n" +
+			" --> /main.ix:3:8\n" +
+			"  |\n" +
+			"3 | struct Point {}\n" +
+			"  |        ^^^^^\n" +
+			"note: related location\n" +
+			" --> /main.ix:2:8\n" +
+			"  |\n" +
+			"2 | struct Point {}\n" +
+			"  |        ^^^^^\n"
+
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("chunk not found", func(t *testing.T) {
+		chunk := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "/main.ix",
+			CodeString: "manifest {}\nx = y",
+		}))
+
+		checkErr := getFirstCheckError(t, chunk)
+
+		buf := bytes.NewBuffer(nil)
+		err := PrettyPrintStaticCheckError(buf, checkErr, getChunkFn(), StaticCheckErrorPrettyPrintConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, checkErr.Message+"\n --> /main.ix:2:5\n", buf.String())
+	})
+
+	t.Run("colorized", func(t *testing.T) {
+		chunk := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "/main.ix",
+			CodeString: "manifest {}\nx = y",
+		}))
+
+		checkErr := getFirstCheckError(t, chunk)
+
+		buf := bytes.NewBuffer(nil)
+		err := PrettyPrintStaticCheckError(buf, checkErr, getChunkFn(chunk), StaticCheckErrorPrettyPrintConfig{
+			Colorize: true,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		rendered := buf.String()
+		errorColor := string(pprint.DEFAULT_DARKMODE_PRINT_COLORS.ErrorColor)
+
+		assert.True(t, strings.HasPrefix(rendered, errorColor+checkErr.Message+ANSI_RESET_SEQUENCE_STRING))
+		assert.Contains(t, rendered, errorColor+"^"+ANSI_RESET_SEQUENCE_STRING)
+		assert.Equal(t, checkErr.Message+"\n"+
+			" --> /main.ix:2:5\n"+
+			"  |\n"+
+			"2 | x = y\n"+
+			"  |     ^\n",
+			utils.StripANSISequences(rendered))
+	})
+}
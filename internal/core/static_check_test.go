@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -51,6 +52,20 @@ func TestCheck(t *testing.T) {
 		return NewStaticCheckError(s, parse.SourcePositionStack{chunk.GetSourcePosition(node.Base().Span)})
 	}
 
+	makeWarning := func(node parse.Node, chunk *parse.ParsedChunkSource, s string) *StaticCheckWarning {
+		return NewStaticCheckWarning(s, parse.SourcePositionStack{chunk.GetSourcePosition(node.Base().Span)})
+	}
+
+	staticCheck := func(input StaticCheckInput) (*StaticCheckData, error) {
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		if input.State == nil {
+			input.State = NewGlobalState(ctx)
+		}
+		return StaticCheck(input)
+	}
+
 	staticCheckNoData := func(input StaticCheckInput) error {
 		ctx := NewContext(ContextConfig{})
 		defer ctx.CancelGracefully()
@@ -167,6 +182,23 @@ func TestCheck(t *testing.T) {
 			assert.NoError(t, err)
 		})
 
+		t.Run("spread element that is not an extraction expression but has no parsing error", func(t *testing.T) {
+			n, src := mustParseCode(`
+				e = {a: 1}
+				{"b": 1, ... $e.{a}}
+			`)
+
+			//replace the extraction expression with the extracted object ($e).
+			spreadElement := parse.FindNode(n, (*parse.PropertySpreadElement)(nil), nil)
+			spreadElement.Expr = spreadElement.Expr.(*parse.ExtractionExpression).Object
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(spreadElement, src, SPREAD_ELEMENT_SHOULD_BE_EXTRACTION_EXPR),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
 		t.Run("key is too long", func(t *testing.T) {
 			name := strings.Repeat("a", MAX_NAME_BYTE_LEN+1)
 			code := strings.Replace(`{"a":1}`, "a", name, 1)
@@ -348,6 +380,23 @@ func TestCheck(t *testing.T) {
 			assert.NoError(t, err)
 		})
 
+		t.Run("spread element that is not an extraction expression but has no parsing error", func(t *testing.T) {
+			n, src := mustParseCode(`
+				e = #{a: 1}
+				#{"b": 1, ... $e.{a}}
+			`)
+
+			//replace the extraction expression with the extracted object ($e).
+			spreadElement := parse.FindNode(n, (*parse.PropertySpreadElement)(nil), nil)
+			spreadElement.Expr = spreadElement.Expr.(*parse.ExtractionExpression).Object
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(spreadElement, src, SPREAD_ELEMENT_SHOULD_BE_EXTRACTION_EXPR),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
 		t.Run("key is too long", func(t *testing.T) {
 			name := strings.Repeat("a", MAX_NAME_BYTE_LEN+1)
 			code := strings.Replace(`#{"a":1}`, "a", name, 1)
@@ -733,6 +782,36 @@ func TestCheck(t *testing.T) {
 			)
 			assert.Equal(t, expectedErr, err)
 		})
+
+		t.Run("assignment to an existing property of self", func(t *testing.T) {
+			n, src := mustParseCode(`
+				{
+					a: 1
+					on received %{} fn(event){
+						self.a = 2
+					}
+				}
+			`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("assignment to a non existing property of self", func(t *testing.T) {
+			n, src := mustParseCode(`
+				{
+					a: 1
+					on received %{} fn(event){
+						self.b = 2
+					}
+				}
+			`)
+
+			memberExpr := parse.FindNode(n, (*parse.MemberExpression)(nil), nil)
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(memberExpr, src, fmtObjectDoesNotHaveProp("b")),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
 	})
 
 	t.Run("computed member expression", func(t *testing.T) {
@@ -828,6 +907,48 @@ func TestCheck(t *testing.T) {
 			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
 		})
 
+		t.Run("single call expression: undeclared function", func(t *testing.T) {
+			n, src := mustParseCode(`
+				go {} do f()
+			`)
+
+			ident := parse.FindNode(n, (*parse.IdentifierLiteral)(nil), func(n *parse.IdentifierLiteral, isUnique bool) bool {
+				return n.Name == "f"
+			})
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(ident, src, fmtVarIsNotDeclared("f")),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("single call expression: local variable", func(t *testing.T) {
+			n, src := mustParseCode(`
+				fn g(){
+					f = fn(){}
+					go {} do f()
+				}
+			`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("single call expression: identifier member expr: undeclared namespace", func(t *testing.T) {
+			n, src := mustParseCode(`
+				go {} do http.read(https://example.com/)
+			`)
+
+			ident := parse.FindNode(n, (*parse.IdentifierLiteral)(nil), func(n *parse.IdentifierLiteral, isUnique bool) bool {
+				return n.Name == "http"
+			})
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(ident, src, fmtVarIsNotDeclared("http")),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
 		t.Run("single call expression: identifier member expr: namespace method", func(t *testing.T) {
 			n, src := mustParseCode(`
 				go {} do http.read(https://example.com/)
@@ -1195,6 +1316,50 @@ func TestCheck(t *testing.T) {
 			assert.Equal(t, expectedErr, err)
 		})
 
+		t.Run("in static key", func(t *testing.T) {
+			n, src := mustParseCode(`Mapping { (comp 1) => 1 }`)
+
+			computeExpr := parse.FindNode(n, (*parse.ComputeExpression)(nil), nil)
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			assert.ErrorContains(t, err, MISPLACED_COMPUTE_EXPR_IN_STATIC_MAPPING_ENTRY_KEY)
+			assert.Contains(t, err.Error(), makeError(computeExpr, src, MISPLACED_COMPUTE_EXPR_IN_STATIC_MAPPING_ENTRY_KEY).Error())
+			assert.NotContains(t, err.Error(), MISPLACED_COMPUTE_EXPR_SHOULD_BE_IN_DYNAMIC_MAPPING_EXPR_ENTRY)
+		})
+
+		t.Run("in right side of dynamic entry of a mapping nested in the right side of a dynamic entry", func(t *testing.T) {
+			n, src := mustParseCode(`Mapping { n 0 => Mapping { m 1 => comp 1 } }`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("in right side of static entry of a mapping nested in the right side of a dynamic entry", func(t *testing.T) {
+			n, src := mustParseCode(`Mapping { n 0 => Mapping { 1 => comp 1 } }`)
+
+			computeExpr := parse.FindNode(n, (*parse.ComputeExpression)(nil), nil)
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(computeExpr, src, MISPLACED_COMPUTE_EXPR_SHOULD_BE_IN_DYNAMIC_MAPPING_EXPR_ENTRY),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("in static key of a mapping nested in the right side of a dynamic entry", func(t *testing.T) {
+			n, src := mustParseCode(`Mapping { n 0 => Mapping { (comp 1) => 1 } }`)
+
+			computeExpr := parse.FindNode(n, (*parse.ComputeExpression)(nil), nil)
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			assert.ErrorContains(t, err, MISPLACED_COMPUTE_EXPR_IN_STATIC_MAPPING_ENTRY_KEY)
+			assert.Contains(t, err.Error(), makeError(computeExpr, src, MISPLACED_COMPUTE_EXPR_IN_STATIC_MAPPING_ENTRY_KEY).Error())
+		})
+
+		t.Run("in key of dynamic entry of a mapping nested in the right side of a dynamic entry", func(t *testing.T) {
+			n, src := mustParseCode(`Mapping { n 0 => Mapping { m (comp 1) => 1 } }`)
+
+			computeExpr := parse.FindNode(n, (*parse.ComputeExpression)(nil), nil)
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			assert.ErrorContains(t, err, MISPLACED_COMPUTE_EXPR_SHOULD_BE_IN_DYNAMIC_MAPPING_EXPR_ENTRY)
+			assert.Contains(t, err.Error(), makeError(computeExpr, src, MISPLACED_COMPUTE_EXPR_SHOULD_BE_IN_DYNAMIC_MAPPING_EXPR_ENTRY).Error())
+		})
+
 		t.Run("top level", func(t *testing.T) {
 			n, src := mustParseCode(`comp 1`)
 
@@ -1410,6 +1575,59 @@ func TestCheck(t *testing.T) {
 			}, data.GetFnData(fnExpr))
 		})
 
+		t.Run("globals captured by a struct method should be listed in the method's data", func(t *testing.T) {
+			ctx := NewContext(ContextConfig{})
+			defer ctx.CancelGracefully()
+
+			n, src := mustParseCode(`
+				$$a = 1
+				struct S {
+					fn m(){ return a }
+				}
+			`)
+
+			fnExpr := parse.FindNode(n, (*parse.FunctionExpression)(nil), nil)
+			data, err := StaticCheck(StaticCheckInput{
+				State: NewGlobalState(ctx),
+				Node:  n,
+				Chunk: src,
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, &FunctionStaticData{
+				capturedGlobals: []string{"a"},
+			}, data.GetFnData(fnExpr))
+		})
+
+		t.Run("a global captured by a global function B referenced by a struct method A should be listed in A's data", func(t *testing.T) {
+			ctx := NewContext(ContextConfig{})
+			defer ctx.CancelGracefully()
+
+			n, src := mustParseCode(`
+				$$a = 1
+				fn f(){
+					return a
+				}
+				struct S {
+					fn m(){ return f }
+				}
+			`)
+
+			fnExpr := parse.FindNodes(n, (*parse.FunctionExpression)(nil), nil)[1]
+			data, err := StaticCheck(StaticCheckInput{
+				State: NewGlobalState(ctx),
+				Node:  n,
+				Chunk: src,
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, &FunctionStaticData{
+				capturedGlobals: []string{"f", "a"},
+			}, data.GetFnData(fnExpr))
+		})
+
 		t.Run("functions assigning a global should be detected", func(t *testing.T) {
 			ctx := NewContext(ContextConfig{})
 			defer ctx.CancelGracefully()
@@ -1563,6 +1781,38 @@ func TestCheck(t *testing.T) {
 			)
 			assert.Equal(t, expectedErr, err)
 		})
+
+		t.Run("declared functions should be listed", func(t *testing.T) {
+			n, src := mustParseCode(`
+				fn f(){}
+
+				obj = {
+					m: fn(){}
+				}
+			`)
+			decl := parse.FindNode(n, (*parse.FunctionDeclaration)(nil), nil)
+			prop := parse.FindNode(n, (*parse.ObjectProperty)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, []DeclaredFunction{
+				{
+					Name:     "f",
+					Position: src.GetSourcePosition(decl.Name.Span),
+					IsMethod: false,
+					Function: decl.Function,
+				},
+				{
+					Name:     "m",
+					Position: src.GetSourcePosition(prop.Key.Base().Span),
+					IsMethod: true,
+					Function: prop.Value.(*parse.FunctionExpression),
+				},
+			}, data.FunctionNames())
+		})
 	})
 
 	t.Run("function expression", func(t *testing.T) {
@@ -1700,6 +1950,45 @@ func TestCheck(t *testing.T) {
 			)
 			assert.Equal(t, expectedErr, err)
 		})
+
+		t.Run("declaration in a function", func(t *testing.T) {
+			n, src := mustParseCode(`
+				fn f(){
+					globalvar a = 0
+				}
+			`)
+			decls := parse.FindNode(n, (*parse.GlobalVariableDeclarations)(nil), nil)
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(decls, src, MISPLACED_GLOBAL_VAR_DECLS_TOP_LEVEL_STMT),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("declaration in a block", func(t *testing.T) {
+			n, src := mustParseCode(`
+				if true {
+					globalvar a = 0
+				}
+			`)
+			decls := parse.FindNode(n, (*parse.GlobalVariableDeclarations)(nil), nil)
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(decls, src, MISPLACED_GLOBAL_VAR_DECLS_TOP_LEVEL_STMT),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("declaration at the top level of an embedded module", func(t *testing.T) {
+			n, src := mustParseCode(`
+				go do {
+					globalvar a = 0
+					return a
+				}
+			`)
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			assert.NoError(t, err)
+		})
 	})
 
 	t.Run("assignment", func(t *testing.T) {
@@ -1848,53 +2137,327 @@ func TestCheck(t *testing.T) {
 			)
 			assert.Equal(t, expectedErr, err)
 		})
-	})
 
-	t.Run("global variable", func(t *testing.T) {
-		t.Run("global is accessible in manifest", func(t *testing.T) {
-			n, src := mustParseCode(`
-				const (
-					a = 1
-				)
-	
-				manifest {
-					limits: {
-						"x": $$a
-					}
-				}
-			`)
-			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
-		})
+		t.Run("list literal with as many elements as variables", func(t *testing.T) {
+			n, src := mustParseCode(`assign a b = [1, 2]`)
 
-		t.Run("global is accessible in module", func(t *testing.T) {
-			n, src := mustParseCode(`
-				const (
-					a = 1
-				)
-	
-				return $$a
-			`)
-			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.warnings)
 		})
 
-		t.Run("global is accessible in function", func(t *testing.T) {
-			n, src := mustParseCode(`
-				const (
-					a = 1
-				)
-	
-				fn f(){
-					return $$a
-				}
-			`)
-			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		t.Run("tuple literal with as many elements as variables", func(t *testing.T) {
+			n, src := mustParseCode(`assign a b = #[1, 2]`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.warnings)
 		})
 
-		t.Run("global variable defined by import statement", func(t *testing.T) {
-			moduleName := "mymod.ix"
-			modpath := writeModuleAndIncludedFiles(t, moduleName, `
-				manifest {}
-				import result ./dep.ix {}
+		t.Run("list literal with less elements than variables", func(t *testing.T) {
+			n, src := mustParseCode(`assign a b = [1]`)
+			listLit := parse.FindNode(n, (*parse.ListLiteral)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(listLit, src, MULTI_ASSIGN_ARITY_MISMATCH),
+			}
+			assert.Equal(t, expectedWarnings, data.warnings)
+		})
+
+		t.Run("list literal with more elements than variables", func(t *testing.T) {
+			n, src := mustParseCode(`assign a b = [1, 2, 3]`)
+			listLit := parse.FindNode(n, (*parse.ListLiteral)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(listLit, src, MULTI_ASSIGN_ARITY_MISMATCH),
+			}
+			assert.Equal(t, expectedWarnings, data.warnings)
+		})
+
+		t.Run("tuple literal with less elements than variables", func(t *testing.T) {
+			n, src := mustParseCode(`assign a b = #[1]`)
+			tupleLit := parse.FindNode(n, (*parse.TupleLiteral)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(tupleLit, src, MULTI_ASSIGN_ARITY_MISMATCH),
+			}
+			assert.Equal(t, expectedWarnings, data.warnings)
+		})
+
+		t.Run("nillable: list literal with less elements than variables", func(t *testing.T) {
+			n, src := mustParseCode(`assign? a b = [1]`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.warnings)
+		})
+
+		t.Run("nillable: list literal with more elements than variables", func(t *testing.T) {
+			n, src := mustParseCode(`assign? a b = [1, 2, 3]`)
+			listLit := parse.FindNode(n, (*parse.ListLiteral)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(listLit, src, MULTI_ASSIGN_ARITY_MISMATCH),
+			}
+			assert.Equal(t, expectedWarnings, data.warnings)
+		})
+
+		t.Run("list literal with a spread element", func(t *testing.T) {
+			n, src := mustParseCode(`
+				l = [1]
+				assign a b = [...l]
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.warnings)
+		})
+
+		t.Run("non-literal right side", func(t *testing.T) {
+			n, src := mustParseCode(`
+				l = [1]
+				assign a b = l
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.warnings)
+		})
+	})
+
+	t.Run("global variable that could be a constant", func(t *testing.T) {
+		t.Run("declared global variable that is only read", func(t *testing.T) {
+			n, src := mustParseCode(`
+				globalvar a = 1
+				return a
+			`)
+			decl := parse.FindNode(n, (*parse.GlobalVariableDeclaration)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src, WarnGlobalCouldBeConst: true})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(decl, src, fmtGlobalVarCouldBeConstant("a")),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
+		})
+
+		t.Run("assigned global variable that is only read", func(t *testing.T) {
+			n, src := mustParseCode(`
+				$$a = 1
+				return $$a
+			`)
+			assignment := parse.FindNode(n, (*parse.Assignment)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src, WarnGlobalCouldBeConst: true})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(assignment, src, fmtGlobalVarCouldBeConstant("a")),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
+		})
+
+		t.Run("reassigned global variable", func(t *testing.T) {
+			n, src := mustParseCode(`
+				globalvar a = 1
+				$$a = 2
+				return a
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src, WarnGlobalCouldBeConst: true})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
+		})
+
+		t.Run("global variable that is never read", func(t *testing.T) {
+			n, src := mustParseCode(`$$a = 1`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src, WarnGlobalCouldBeConst: true})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
+		})
+
+		t.Run("option is not enabled", func(t *testing.T) {
+			n, src := mustParseCode(`
+				globalvar a = 1
+				return a
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
+		})
+		t.Run("global variable declared in an included chunk and only read", func(t *testing.T) {
+			moduleName := "mymod.ix"
+			modpath := writeModuleAndIncludedFiles(t, moduleName, `
+				manifest {}
+				import ./dep.ix
+				return a
+			`, map[string]string{"./dep.ix": "includable-chunk\n globalvar a = 1"})
+
+			mod, err := ParseLocalModule(modpath, ModuleParsingConfig{Context: createParsingContext(modpath)})
+			assert.NoError(t, err)
+
+			data, err := staticCheck(StaticCheckInput{
+				Module:                 mod,
+				Node:                   mod.MainChunk.Node,
+				Chunk:                  mod.MainChunk,
+				WarnGlobalCouldBeConst: true,
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			importStmt := parse.FindNode(mod.MainChunk.Node, (*parse.InclusionImportStatement)(nil), nil)
+			includedChunk := mod.InclusionStatementMap[importStmt]
+			decl := parse.FindNode(includedChunk.Node, (*parse.GlobalVariableDeclaration)(nil), nil)
+
+			//the warning should be located in the included chunk.
+			expectedWarning := NewStaticCheckWarning(fmtGlobalVarCouldBeConstant("a"), parse.SourcePositionStack{
+				mod.MainChunk.GetSourcePosition(importStmt.Span),
+				includedChunk.GetSourcePosition(decl.Span),
+			})
+			assert.Contains(t, data.Warnings(), expectedWarning)
+		})
+
+		t.Run("global variable declared in an included chunk and reassigned in the module", func(t *testing.T) {
+			moduleName := "mymod.ix"
+			modpath := writeModuleAndIncludedFiles(t, moduleName, `
+				manifest {}
+				import ./dep.ix
+				$$a = 2
+				return a
+			`, map[string]string{"./dep.ix": "includable-chunk\n globalvar a = 1"})
+
+			mod, err := ParseLocalModule(modpath, ModuleParsingConfig{Context: createParsingContext(modpath)})
+			assert.NoError(t, err)
+
+			data, err := staticCheck(StaticCheckInput{
+				Module:                 mod,
+				Node:                   mod.MainChunk.Node,
+				Chunk:                  mod.MainChunk,
+				WarnGlobalCouldBeConst: true,
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for _, warning := range data.Warnings() {
+				assert.NotContains(t, warning.Message, fmtGlobalVarCouldBeConstant("a"))
+			}
+		})
+
+		t.Run("global variable of the module assigned in an included chunk", func(t *testing.T) {
+			moduleName := "mymod.ix"
+			modpath := writeModuleAndIncludedFiles(t, moduleName, `
+				manifest {}
+				globalvar a = 1
+				import ./dep.ix
+				return a
+			`, map[string]string{"./dep.ix": "includable-chunk\n fn f(){ $$a = 2 }"})
+
+			mod, err := ParseLocalModule(modpath, ModuleParsingConfig{Context: createParsingContext(modpath)})
+			assert.NoError(t, err)
+
+			data, _ := staticCheck(StaticCheckInput{
+				Module:                 mod,
+				Node:                   mod.MainChunk.Node,
+				Chunk:                  mod.MainChunk,
+				WarnGlobalCouldBeConst: true,
+			})
+
+			for _, warning := range data.Warnings() {
+				assert.NotContains(t, warning.Message, fmtGlobalVarCouldBeConstant("a"))
+			}
+		})
+	})
+
+	t.Run("global variable", func(t *testing.T) {
+		t.Run("global is accessible in manifest", func(t *testing.T) {
+			n, src := mustParseCode(`
+				const (
+					a = 1
+				)
+	
+				manifest {
+					limits: {
+						"x": $$a
+					}
+				}
+			`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("global is accessible in module", func(t *testing.T) {
+			n, src := mustParseCode(`
+				const (
+					a = 1
+				)
+	
+				return $$a
+			`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("global is accessible in function", func(t *testing.T) {
+			n, src := mustParseCode(`
+				const (
+					a = 1
+				)
+	
+				fn f(){
+					return $$a
+				}
+			`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("global variable defined by import statement", func(t *testing.T) {
+			moduleName := "mymod.ix"
+			modpath := writeModuleAndIncludedFiles(t, moduleName, `
+				manifest {}
+				import result ./dep.ix {}
 				$$result
 			`, map[string]string{
 				"./dep.ix": `
@@ -2004,6 +2567,33 @@ func TestCheck(t *testing.T) {
 	})
 
 	t.Run("manifest", func(t *testing.T) {
+		t.Run("manifest after constant declarations and preinit statement", func(t *testing.T) {
+			n, src := mustParseCode(`
+				const (
+					A = 1
+				)
+
+				preinit {}
+
+				manifest {}
+			`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("manifest after an assignment", func(t *testing.T) {
+			n, src := mustParseCode(`
+				a = 1
+				manifest {}
+			`)
+			call := parse.FindNode(n, (*parse.CallExpression)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(call, src, MANIFEST_SHOULD_BE_NEAR_TOP),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
 		t.Run("parameters section not allowed in embedded module manifest", func(t *testing.T) {
 			n, src := mustParseCode(`
 				manifest {
@@ -2189,38 +2779,117 @@ func TestCheck(t *testing.T) {
 			`)
 			assert.Error(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
 		})
-	})
 
-	t.Run("test suite statements", func(t *testing.T) {
-		t.Run("empty", func(t *testing.T) {
+		t.Run("async invocation without an on-added-element property", func(t *testing.T) {
 			n, src := mustParseCode(`
-				manifest {}
-
-				testsuite {
+				manifest {
+					invocation: {
+						async: true
+					}
 				}
 			`)
+			boolLit := parse.FindNode(n, (*parse.BooleanLiteral)(nil), nil)
 
-			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(boolLit, src, ASYNC_INVOCATION_HAS_NO_EFFECT_WITHOUT_VALID_TRIGGER),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
 		})
 
-		t.Run("should have its own local scope", func(t *testing.T) {
+		t.Run("empty list of read permissions", func(t *testing.T) {
 			n, src := mustParseCode(`
-				a = 1
-				testsuite { 
-					a
+				manifest {
+					permissions: {
+						read: []
+					}
 				}
 			`)
+			listLit := parse.FindNode(n, (*parse.ListLiteral)(nil), nil)
 
-			identLiteral := parse.FindNodes(n, (*parse.IdentifierLiteral)(nil), nil)[1]
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
 
-			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
-			expectedErr := utils.CombineErrors(
-				makeError(identLiteral, src, fmtVarIsNotDeclared("a")),
-			)
-			assert.Equal(t, expectedErr, err)
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(listLit, src, EMPTY_PERMISSION_KIND_VALUE),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
 		})
 
-		t.Run("should inherit globals", func(t *testing.T) {
+		t.Run("empty object of read permissions", func(t *testing.T) {
+			n, src := mustParseCode(`
+				manifest {
+					permissions: {
+						read: {}
+					}
+				}
+			`)
+			permsObj := parse.FindNodes(n, (*parse.ObjectLiteral)(nil), nil)[2]
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(permsObj, src, EMPTY_PERMISSION_KIND_VALUE),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
+		})
+
+		t.Run("non-empty list of read permissions", func(t *testing.T) {
+			n, src := mustParseCode(`
+				manifest {
+					permissions: {
+						read: [/a.txt]
+					}
+				}
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
+		})
+	})
+
+	t.Run("test suite statements", func(t *testing.T) {
+		t.Run("empty", func(t *testing.T) {
+			n, src := mustParseCode(`
+				manifest {}
+
+				testsuite {
+				}
+			`)
+
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("should have its own local scope", func(t *testing.T) {
+			n, src := mustParseCode(`
+				a = 1
+				testsuite { 
+					a
+				}
+			`)
+
+			identLiteral := parse.FindNodes(n, (*parse.IdentifierLiteral)(nil), nil)[1]
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(identLiteral, src, fmtVarIsNotDeclared("a")),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("should inherit globals", func(t *testing.T) {
 			n, src := mustParseCode(`
 				$$a = 1
 				testsuite { 
@@ -2419,6 +3088,151 @@ func TestCheck(t *testing.T) {
 			assert.Equal(t, expectedErr, err)
 		})
 
+		t.Run("module without a kind section", func(t *testing.T) {
+			n, src := mustParseCode(`
+				manifest {}
+
+				testsuite {
+				}
+			`)
+
+			testSuite := parse.FindNode(n, (*parse.TestSuiteExpression)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(testSuite, src, TEST_STMTS_IN_MODULE_WITHOUT_KIND_SECTION),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
+		})
+
+		t.Run("module without a kind section: error", func(t *testing.T) {
+			n, src := mustParseCode(`
+				manifest {}
+
+				testsuite {
+				}
+			`)
+
+			testSuite := parse.FindNode(n, (*parse.TestSuiteExpression)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src, ErrorOnTestStatementsWithoutKind: true})
+			expectedErr := utils.CombineErrors(
+				makeError(testSuite, src, TEST_STMTS_IN_MODULE_WITHOUT_KIND_SECTION),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("module with a kind section", func(t *testing.T) {
+			n, src := mustParseCode(`
+				manifest {
+					kind: "spec"
+				}
+
+				testsuite {
+				}
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Empty(t, data.Warnings())
+		})
+	})
+
+	t.Run("return statement in test module", func(t *testing.T) {
+		t.Run("value", func(t *testing.T) {
+			n, src := mustParseCode(`
+				manifest {}
+
+				return 1
+			`)
+
+			returnStmt := parse.FindNode(n, (*parse.ReturnStatement)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{
+				Node:  n,
+				Chunk: src,
+				Module: &Module{
+					MainChunk:  src,
+					ModuleKind: TestSuiteModule,
+				},
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(returnStmt, src, RETURN_VALUE_IGNORED_IN_TEST_MODULE),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
+		})
+
+		t.Run("bare return", func(t *testing.T) {
+			n, src := mustParseCode(`
+				manifest {}
+
+				return
+			`)
+
+			data, err := staticCheck(StaticCheckInput{
+				Node:  n,
+				Chunk: src,
+				Module: &Module{
+					MainChunk:  src,
+					ModuleKind: TestSuiteModule,
+				},
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Empty(t, data.Warnings())
+		})
+
+		t.Run("value returned by a function", func(t *testing.T) {
+			n, src := mustParseCode(`
+				manifest {}
+
+				fn f(){
+					return 1
+				}
+			`)
+
+			data, err := staticCheck(StaticCheckInput{
+				Node:  n,
+				Chunk: src,
+				Module: &Module{
+					MainChunk:  src,
+					ModuleKind: TestSuiteModule,
+				},
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Empty(t, data.Warnings())
+		})
+
+		t.Run("value returned by a regular module", func(t *testing.T) {
+			n, src := mustParseCode(`
+				manifest {}
+
+				return 1
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Empty(t, data.Warnings())
+		})
 	})
 
 	t.Run("test case statements", func(t *testing.T) {
@@ -2533,6 +3347,44 @@ func TestCheck(t *testing.T) {
 			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
 		})
 
+		t.Run("referencing __test in a test case", func(t *testing.T) {
+			n, src := mustParseCode(`
+				return testcase { __test }
+			`)
+
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("referencing __test in a spawned module inside a test case", func(t *testing.T) {
+			n, src := mustParseCode(`
+				return testcase {
+					go do { return $$__test }
+				}
+			`)
+
+			globalVar := parse.FindNode(n, (*parse.GlobalVariable)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(globalVar, src, CURRENT_TEST_ONLY_AVAILABLE_IN_TEST_CASES),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("referencing __test in a regular spawned module", func(t *testing.T) {
+			n, src := mustParseCode(`
+				go do { return __test }
+			`)
+
+			identLiteral := parse.FindNode(n, (*parse.IdentifierLiteral)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(identLiteral, src, CURRENT_TEST_ONLY_AVAILABLE_IN_TEST_CASES),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
 		t.Run("should not inherit the `dbs` global", func(t *testing.T) {
 			n, src := mustParseCode(`
 				globalvar dbs = {}
@@ -2776,6 +3628,48 @@ func TestCheck(t *testing.T) {
 				Chunk:  mod.MainChunk,
 			}))
 		})
+
+		t.Run("internal failure of the check of an included chunk should be recovered if enabled", func(t *testing.T) {
+			moduleName := "mymod.ix"
+			modpath := writeModuleAndIncludedFiles(t, moduleName, `
+				manifest {}
+				import ./dep.ix
+			`, map[string]string{"./dep.ix": "includable-chunk\n a = 1"})
+
+			mod, err := ParseLocalModule(modpath, ModuleParsingConfig{Context: createParsingContext(modpath)})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			//replace the included chunk with a chunk without a node in order to cause a panic.
+			inclusionStmt := parse.FindNode(mod.MainChunk.Node, (*parse.InclusionImportStatement)(nil), nil)
+			mod.InclusionStatementMap[inclusionStmt] = &IncludedChunk{ParsedChunkSource: &parse.ParsedChunkSource{}}
+
+			assert.Panics(t, func() {
+				staticCheckNoData(StaticCheckInput{
+					Module: mod,
+					Node:   mod.MainChunk.Node,
+					Chunk:  mod.MainChunk,
+				})
+			})
+
+			data, err := staticCheck(StaticCheckInput{
+				Module:  mod,
+				Node:    mod.MainChunk.Node,
+				Chunk:   mod.MainChunk,
+				Recover: true,
+			})
+
+			if !assert.Error(t, err) {
+				return
+			}
+			assert.Contains(t, err.Error(), "internal failure of the static check")
+
+			if assert.NotNil(t, data) && assert.Len(t, data.Errors(), 1) {
+				assert.Contains(t, data.Errors()[0].Message, "internal failure of the static check")
+			}
+			assert.Empty(t, data.Warnings())
+		})
 	})
 
 	t.Run("import statement", func(t *testing.T) {
@@ -3430,6 +4324,46 @@ func TestCheck(t *testing.T) {
 			assert.Equal(t, expectedErr, err)
 		})
 
+		t.Run("forbidden node in expression allowed by the input predicate", func(t *testing.T) {
+			n, src := mustParseCode(`
+				fn f(){
+					return 1
+				}
+				assert (f() == 1)
+			`)
+
+			err := staticCheckNoData(StaticCheckInput{
+				Node:  n,
+				Chunk: src,
+				IsNodeAllowedInAssertions: func(node parse.Node) bool {
+					_, ok := node.(*parse.CallExpression)
+					return ok
+				},
+			})
+			assert.NoError(t, err)
+		})
+
+		t.Run("forbidden node in expression not allowed by the input predicate", func(t *testing.T) {
+			n, src := mustParseCode(`
+				x = 0
+				assert (x > f())
+			`)
+			callNode := parse.FindNode(n, (*parse.CallExpression)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{
+				Node:  n,
+				Chunk: src,
+				IsNodeAllowedInAssertions: func(node parse.Node) bool {
+					_, ok := node.(*parse.SpawnExpression)
+					return ok
+				},
+			})
+			expectedErr := utils.CombineErrors(
+				makeError(callNode, src, fmtFollowingNodeTypeNotAllowedInAssertions(callNode)),
+				makeError(callNode, src, fmtVarIsNotDeclared("f")),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
 	})
 
 	t.Run("lifetimejob expression", func(t *testing.T) {
@@ -3502,7 +4436,7 @@ func TestCheck(t *testing.T) {
 
 		t.Run("misplaced", func(t *testing.T) {
 			n, src := mustParseCode(`
-				on received %{} fn(){}
+				on received %{} fn(msg){}
 			`)
 
 			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
@@ -3512,24 +4446,75 @@ func TestCheck(t *testing.T) {
 			assert.Equal(t, expectedErr, err)
 		})
 
-		t.Run("element of an object literal", func(t *testing.T) {
+		t.Run("element of an object literal, object pattern", func(t *testing.T) {
 			n, src := mustParseCode(`
 				{
-					on received %{} fn(){}
+					on received %{} fn(msg){}
 				}
 			`)
 
 			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
 		})
 
-	})
-
-	t.Run("host alias definition", func(t *testing.T) {
-		t.Run("redeclaration", func(t *testing.T) {
+		t.Run("integer pattern", func(t *testing.T) {
 			n, src := mustParseCode(`
-				@host = https://localhost
-				@host = https://localhost
-			`)
+				{
+					on received %int fn(msg){}
+				}
+			`)
+
+			handler := parse.FindNode(n, (*parse.ReceptionHandlerExpression)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{
+				Node:     n,
+				Chunk:    src,
+				Patterns: map[string]Pattern{"int": INT_PATTERN},
+			})
+			expectedErr := utils.CombineErrors(
+				makeError(handler.Pattern, src, RECEPTION_HANDLER_PATTERN_SHOULD_BE_OBJECT_OR_RECORD_PATTERN),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("handler without parameters", func(t *testing.T) {
+			n, src := mustParseCode(`
+				{
+					on received %{} fn(){}
+				}
+			`)
+
+			fnExpr := parse.FindNode(n, (*parse.FunctionExpression)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(fnExpr, src, RECEPTION_HANDLER_FN_SHOULD_TAKE_ONE_PARAM),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("handler with two parameters", func(t *testing.T) {
+			n, src := mustParseCode(`
+				{
+					on received %{} fn(msg, other){}
+				}
+			`)
+
+			fnExpr := parse.FindNode(n, (*parse.FunctionExpression)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(fnExpr, src, RECEPTION_HANDLER_FN_SHOULD_TAKE_ONE_PARAM),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+	})
+
+	t.Run("host alias definition", func(t *testing.T) {
+		t.Run("redeclaration", func(t *testing.T) {
+			n, src := mustParseCode(`
+				@host = https://localhost
+				@host = https://localhost
+			`)
 			def := parse.FindNodes(n, (*parse.HostAliasDefinition)(nil), nil)[1]
 
 			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
@@ -3584,6 +4569,158 @@ func TestCheck(t *testing.T) {
 			)
 			assert.Equal(t, expectedErr, err)
 		})
+
+		t.Run("name of a struct type defined before", func(t *testing.T) {
+			n, src := mustParseCode(`
+				struct Foo {}
+				pattern Foo = 0
+			`)
+			def := parse.FindNode(n, (*parse.PatternDefinition)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(def, src, fmtNameCollidesWithStructType("Foo")),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("name of a struct type defined after", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern Foo = 0
+				struct Foo {}
+			`)
+			def := parse.FindNode(n, (*parse.PatternDefinition)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(def, src, fmtNameCollidesWithStructType("Foo")),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("name of a struct type defined in another module", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern Foo = 0
+				go do {
+					struct Foo {}
+				}
+			`)
+
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("unused", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern p = 0
+				pattern q = 1
+				return %q
+			`)
+			def := parse.FindNodes(n, (*parse.PatternDefinition)(nil), nil)[0]
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src, WarnUnusedPatterns: true})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(def, src, UNUSED_PATTERN_DEFINITION),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
+		})
+
+		t.Run("unused: warning not enabled", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern p = 0
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
+		})
+
+		t.Run("used by another pattern", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern p = 0
+				pattern q = %{a: %p}
+				return %q
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src, WarnUnusedPatterns: true})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
+		})
+
+		t.Run("used in a function", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern p = 0
+				fn f(){
+					return %p
+				}
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src, WarnUnusedPatterns: true})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
+		})
+
+		t.Run("used in the manifest", func(t *testing.T) {
+			n, src := mustParseCode(`
+				preinit {
+					pattern p = %str("a"+)
+				}
+				manifest {
+					preinit-files: {
+						F: {
+							path: /file.txt
+							pattern: %p
+						}
+					}
+				}
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src, WarnUnusedPatterns: true})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
+		})
+
+		t.Run("referenced by a lazy pattern before being defined", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern p = @ %{a: %q}
+				pattern q = 0
+				return %p
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src, WarnUnusedPatterns: true})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
+		})
+
+		t.Run("lazy pattern only referenced by itself", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern tree = @ %{children: []%tree}
+			`)
+			def := parse.FindNode(n, (*parse.PatternDefinition)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src, WarnUnusedPatterns: true})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(def, src, UNUSED_PATTERN_DEFINITION),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
+		})
 	})
 
 	t.Run("pattern namespace definition", func(t *testing.T) {
@@ -3635,6 +4772,28 @@ func TestCheck(t *testing.T) {
 			n, src := mustParseCode(`
 				pattern p = @ %str( s )
 			`)
+			pattern := parse.FindNode(n, (*parse.PatternIdentifierLiteral)(nil), func(n *parse.PatternIdentifierLiteral, _ bool) bool {
+				return n.Name == "s"
+			})
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(pattern, src, fmtPatternIsNotDeclared("s")),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("self reference in lazy pattern definition", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern tree = @ %{children: []%tree}
+			`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("pattern defined later referenced in lazy pattern definition", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern p = @ %str( s )
+				pattern s = "s"
+			`)
 			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
 		})
 
@@ -3785,76 +4944,377 @@ func TestCheck(t *testing.T) {
 			)
 			assert.Equal(t, expectedErr, err)
 		})
-	})
 
-	t.Run("float range literal", func(t *testing.T) {
-		t.Run("ok", func(t *testing.T) {
-			n, src := mustParseCode(`1.0..2.0`)
-			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
-		})
+		t.Run("standalone range literal", func(t *testing.T) {
+			n, src := mustParseCode(`1..10`)
 
-		t.Run("no upper bound", func(t *testing.T) {
-			n, src := mustParseCode(`1.0..`)
-			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(n.Statements[0], src, RANGE_LITERAL_HAS_NO_EFFECT),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
 		})
 
-		t.Run("upper bound should be smaller than lower bound", func(t *testing.T) {
-			n, src := mustParseCode(`1.0..0.0`)
+		t.Run("range literal in the head of a for statement", func(t *testing.T) {
+			n, src := mustParseCode(`for i in 1..10 {}`)
 
-			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
-			expectedErr := utils.CombineErrors(
-				makeError(n.Statements[0], src, LOWER_BOUND_OF_FLOAT_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND),
-			)
-			assert.Equal(t, expectedErr, err)
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
 		})
 	})
 
-	t.Run("quantity range literal", func(t *testing.T) {
-		t.Run("ok", func(t *testing.T) {
-			n, src := mustParseCode(`1x..2x`)
-			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+	t.Run("constant condition", func(t *testing.T) {
+		t.Run("experimental feature not enabled", func(t *testing.T) {
+			n, src := mustParseCode(`if true {}`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
 		})
 
-		t.Run("no upper bound", func(t *testing.T) {
-			n, src := mustParseCode(`1x..`)
-			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		t.Run("if statement with a boolean literal as condition", func(t *testing.T) {
+			n, src := mustParseCode(`if true {}`)
+			boolLit := parse.FindNode(n, (*parse.BooleanLiteral)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{
+				Node:                 n,
+				Chunk:                src,
+				ExperimentalFeatures: map[string]bool{CONSTANT_CONDITION_EXPERIMENTAL_FEATURE: true},
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(boolLit, src, CONSTANT_CONDITION),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
 		})
-	})
 
-	t.Run("match statement", func(t *testing.T) {
-		t.Run("group matching variable shadows a global", func(t *testing.T) {
+		t.Run("assertion with false as condition", func(t *testing.T) {
+			n, src := mustParseCode(`assert false`)
+			boolLit := parse.FindNode(n, (*parse.BooleanLiteral)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{
+				Node:                 n,
+				Chunk:                src,
+				ExperimentalFeatures: map[string]bool{CONSTANT_CONDITION_EXPERIMENTAL_FEATURE: true},
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(boolLit, src, ASSERTION_ALWAYS_FAILS),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
+		})
+
+		t.Run("if statement with a non-constant condition", func(t *testing.T) {
 			n, src := mustParseCode(`
-				$$m = 1
-				match 1 {
-					%/{:a} m { }
-				}
+				a = 1
+				if (a > 0) {}
 			`)
-			variable := parse.FindNode(n, (*parse.IdentifierLiteral)(nil), nil)
-			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
-			expectedErr := utils.CombineErrors(
-				makeError(variable, src, fmtCannotShadowGlobalVariable("m")),
-			)
-			assert.Equal(t, expectedErr, err)
+
+			data, err := staticCheck(StaticCheckInput{
+				Node:                 n,
+				Chunk:                src,
+				ExperimentalFeatures: map[string]bool{CONSTANT_CONDITION_EXPERIMENTAL_FEATURE: true},
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
 		})
+	})
 
-		t.Run("group matching variable shadows a local variable", func(t *testing.T) {
+	t.Run("assertion depending on a global", func(t *testing.T) {
+		t.Run("constant global", func(t *testing.T) {
 			n, src := mustParseCode(`
-				m = 1
-				match 1 {
-					%/{:a} m { }
-				}
+				const (
+					A = 1
+				)
+				assert (A == 1)
 			`)
-			variable := parse.FindNode(n, (*parse.IdentifierLiteral)(nil), nil)
-			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
-			expectedErr := utils.CombineErrors(
-				makeError(variable, src, fmtCannotShadowLocalVariable("m")),
-			)
-			assert.Equal(t, expectedErr, err)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
 		})
 
-		t.Run("group matching variables with same name", func(t *testing.T) {
+		t.Run("mutable global", func(t *testing.T) {
 			n, src := mustParseCode(`
-				match 1 {
+				globalvar a = 1
+				assert (a == 1)
+			`)
+			assertion := parse.FindNode(n, (*parse.AssertionStatement)(nil), nil)
+			ident := parse.FindNode(assertion, (*parse.IdentifierLiteral)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(ident, src, ASSERTION_DEPENDS_ON_MUTABLE_GLOBAL),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
+		})
+
+		t.Run("mutable global variable", func(t *testing.T) {
+			n, src := mustParseCode(`
+				$$a = 1
+				assert ($$a == 1)
+			`)
+			assertion := parse.FindNode(n, (*parse.AssertionStatement)(nil), nil)
+			globalVar := parse.FindNode(assertion, (*parse.GlobalVariable)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(globalVar, src, ASSERTION_DEPENDS_ON_MUTABLE_GLOBAL),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
+		})
+	})
+
+	t.Run("function that could be a method", func(t *testing.T) {
+		features := map[string]bool{CONSIDER_MAKING_METHOD_EXPERIMENTAL_FEATURE: true}
+
+		t.Run("only captured global is an object", func(t *testing.T) {
+			n, src := mustParseCode(`
+				$$counter = {count: 0}
+				fn get(){
+					return counter.count
+				}
+			`)
+			decl := parse.FindNode(n, (*parse.FunctionDeclaration)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src, ExperimentalFeatures: features})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(decl.Name, src, CONSIDER_MAKING_METHOD),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
+		})
+
+		t.Run("function capturing an object and another global", func(t *testing.T) {
+			n, src := mustParseCode(`
+				$$counter = {count: 0}
+				$$step = 1
+				fn get(){
+					return (counter.count + step)
+				}
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src, ExperimentalFeatures: features})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
+		})
+
+		t.Run("only captured global is not an object", func(t *testing.T) {
+			n, src := mustParseCode(`
+				$$step = 1
+				fn get(){
+					return step
+				}
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src, ExperimentalFeatures: features})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
+		})
+
+		t.Run("disabled by default", func(t *testing.T) {
+			n, src := mustParseCode(`
+				$$counter = {count: 0}
+				fn get(){
+					return counter.count
+				}
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
+		})
+	})
+
+	t.Run("experimental features", func(t *testing.T) {
+		n, src := mustParseCode(`
+			$$counter = {count: 0}
+			fn get(){
+				return counter.count
+			}
+			if true {}
+		`)
+		ifStmt := parse.FindNode(n, (*parse.IfStatement)(nil), nil)
+
+		data, err := staticCheck(StaticCheckInput{
+			Node:                 n,
+			Chunk:                src,
+			ExperimentalFeatures: map[string]bool{CONSTANT_CONDITION_EXPERIMENTAL_FEATURE: true},
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		//the consider-making-method feature should remain disabled.
+		expectedWarnings := []*StaticCheckWarning{
+			makeWarning(ifStmt.Test, src, CONSTANT_CONDITION),
+		}
+		assert.Equal(t, expectedWarnings, data.Warnings())
+	})
+
+	t.Run("float range literal", func(t *testing.T) {
+		t.Run("ok", func(t *testing.T) {
+			n, src := mustParseCode(`1.0..2.0`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("no upper bound", func(t *testing.T) {
+			n, src := mustParseCode(`1.0..`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("upper bound should be smaller than lower bound", func(t *testing.T) {
+			n, src := mustParseCode(`1.0..0.0`)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(n.Statements[0], src, LOWER_BOUND_OF_FLOAT_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+	})
+
+	t.Run("quantity range literal", func(t *testing.T) {
+		t.Run("ok", func(t *testing.T) {
+			n, src := mustParseCode(`1x..2x`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("no upper bound", func(t *testing.T) {
+			n, src := mustParseCode(`1x..`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("same unit family with different multipliers", func(t *testing.T) {
+			n, src := mustParseCode(`(1kB..2MB)`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("durations with different units", func(t *testing.T) {
+			n, src := mustParseCode(`(1s..1h30mn)`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("equal bounds", func(t *testing.T) {
+			n, src := mustParseCode(`(1mn..60s)`)
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src}))
+		})
+
+		t.Run("unit mismatch", func(t *testing.T) {
+			n, src := mustParseCode(`(1x..2B)`)
+			rangeLit := parse.FindNode(n, (*parse.QuantityRangeLiteral)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(rangeLit, src, QUANTITY_RANGE_BOUNDS_UNIT_MISMATCH),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("unit mismatch: bounds with the same value type", func(t *testing.T) {
+			n, src := mustParseCode(`(1x..50%)`)
+			rangeLit := parse.FindNode(n, (*parse.QuantityRangeLiteral)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(rangeLit, src, QUANTITY_RANGE_BOUNDS_UNIT_MISMATCH),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("upper bound should be smaller than lower bound", func(t *testing.T) {
+			n, src := mustParseCode(`(2kB..1kB)`)
+			rangeLit := parse.FindNode(n, (*parse.QuantityRangeLiteral)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(rangeLit, src, LOWER_BOUND_OF_QUANTITY_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("upper bound should be smaller than lower bound: durations", func(t *testing.T) {
+			n, src := mustParseCode(`(1h..30mn)`)
+			rangeLit := parse.FindNode(n, (*parse.QuantityRangeLiteral)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(rangeLit, src, LOWER_BOUND_OF_QUANTITY_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+	})
+
+	t.Run("match statement", func(t *testing.T) {
+		t.Run("group matching variable shadows a global", func(t *testing.T) {
+			n, src := mustParseCode(`
+				$$m = 1
+				match 1 {
+					%/{:a} m { }
+				}
+			`)
+			variable := parse.FindNode(n, (*parse.IdentifierLiteral)(nil), nil)
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(variable, src, fmtCannotShadowGlobalVariable("m")),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("group matching variable shadows a local variable", func(t *testing.T) {
+			n, src := mustParseCode(`
+				m = 1
+				match 1 {
+					%/{:a} m { }
+				}
+			`)
+			variable := parse.FindNode(n, (*parse.IdentifierLiteral)(nil), nil)
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(variable, src, fmtCannotShadowLocalVariable("m")),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("group matching variables with same name", func(t *testing.T) {
+			n, src := mustParseCode(`
+				match 1 {
 					%/{:a} m { }
 					%/a/{:a} m { }
 				}
@@ -3939,6 +5399,50 @@ func TestCheck(t *testing.T) {
 			assert.Equal(t, expectedErr, err)
 		})
 
+		t.Run("extended object pattern", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern p = {a: 1}
+				extend p {}
+			`)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			assert.NoError(t, err)
+		})
+
+		t.Run("extended pattern defined as an integer literal", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern p = 1
+				extend p {}
+			`)
+
+			extendStmt := parse.FindNode(n, (*parse.ExtendStatement)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(extendStmt.ExtendedPattern, src, CANNOT_EXTEND_NON_OBJECT_PATTERN),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("extended pattern defined as a list pattern literal", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern p = []int
+				extend p {}
+			`)
+
+			extendStmt := parse.FindNode(n, (*parse.ExtendStatement)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{
+				Node:     n,
+				Chunk:    src,
+				Patterns: map[string]Pattern{"int": INT_PATTERN},
+			})
+			expectedErr := utils.CombineErrors(
+				makeError(extendStmt.ExtendedPattern, src, CANNOT_EXTEND_NON_OBJECT_PATTERN),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
 		t.Run("should not have variables in property expressions: identifier referring to a global variable", func(t *testing.T) {
 			n, src := mustParseCode(`
 				pattern p = {a: 1}
@@ -4026,6 +5530,42 @@ func TestCheck(t *testing.T) {
 			)
 			assert.Equal(t, expectedErr, err)
 		})
+
+		t.Run("parameter of an extension method with the same name as a property of the extended pattern", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern p = {a: 1}
+				extend p {
+					f: fn(a){}
+				}
+			`)
+
+			param := parse.FindNode(n, (*parse.FunctionParameter)(nil), nil)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedWarnings := []*StaticCheckWarning{
+				makeWarning(param, src, fmtParameterShadowsExtendedField("a")),
+			}
+			assert.Equal(t, expectedWarnings, data.Warnings())
+		})
+
+		t.Run("parameter of an extension method with a name that is not a property of the extended pattern", func(t *testing.T) {
+			n, src := mustParseCode(`
+				pattern p = {a: 1}
+				extend p {
+					f: fn(b){}
+				}
+			`)
+
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Empty(t, data.Warnings())
+		})
 	})
 	t.Run("struct definition statement", func(t *testing.T) {
 		t.Run("should be located at the top level: in function declaration", func(t *testing.T) {
@@ -4198,13 +5738,22 @@ func TestCheck(t *testing.T) {
 				}
 			`)
 
-			duplicateDef := parse.FindNodes(n, (*parse.StructDefinition)(nil), nil)[1]
+			defs := parse.FindNodes(n, (*parse.StructDefinition)(nil), nil)
+			firstDef, duplicateDef := defs[0], defs[1]
 
-			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
-			expectedErr := utils.CombineErrors(
-				makeError(duplicateDef.Name, src, fmtInvalidStructDefAlreadyDeclared("MyStruct")),
-			)
-			assert.Equal(t, expectedErr, err)
+			data, err := staticCheck(StaticCheckInput{Node: n, Chunk: src})
+
+			duplicateErr := NewStaticCheckError(fmtInvalidStructDefAlreadyDeclared("MyStruct"), parse.SourcePositionStack{
+				src.GetSourcePosition(duplicateDef.Name.Base().Span),
+			})
+			duplicateErr.RelatedLocations = []parse.SourcePositionStack{
+				{src.GetSourcePosition(firstDef.Name.Base().Span)},
+			}
+			assert.Equal(t, utils.CombineErrors(duplicateErr), err)
+
+			//the error should only be located in the duplicate definition.
+			assert.Equal(t, []*StaticCheckError{duplicateErr}, data.NodeErrors(duplicateDef, src.Name()))
+			assert.Empty(t, data.NodeErrors(firstDef, src.Name()))
 		})
 
 		t.Run("duplicate definition, first definition in included chunk", func(t *testing.T) {
@@ -4220,18 +5769,31 @@ func TestCheck(t *testing.T) {
 			mod, err := ParseLocalModule(modpath, ModuleParsingConfig{Context: createParsingContext(modpath)})
 			assert.NoError(t, err)
 
-			err = staticCheckNoData(StaticCheckInput{
+			data, err := staticCheck(StaticCheckInput{
 				Module: mod,
 				Node:   mod.MainChunk.Node,
 				Chunk:  mod.MainChunk,
 			})
 
 			duplicateDef := parse.FindNode(mod.MainChunk.Node, (*parse.StructDefinition)(nil), nil)
+			importStmt := parse.FindNode(mod.MainChunk.Node, (*parse.InclusionImportStatement)(nil), nil)
+			includedChunk := mod.InclusionStatementMap[importStmt]
+			firstDef := parse.FindNode(includedChunk.Node, (*parse.StructDefinition)(nil), nil)
 
-			expectedErr := utils.CombineErrors(
-				makeError(duplicateDef.Name, mod.MainChunk, fmtInvalidStructDefAlreadyDeclared("MyStruct")),
-			)
-			assert.Equal(t, expectedErr, err)
+			duplicateErr := NewStaticCheckError(fmtInvalidStructDefAlreadyDeclared("MyStruct"), parse.SourcePositionStack{
+				mod.MainChunk.GetSourcePosition(duplicateDef.Name.Base().Span),
+			})
+			duplicateErr.RelatedLocations = []parse.SourcePositionStack{
+				{
+					mod.MainChunk.GetSourcePosition(importStmt.Span),
+					includedChunk.GetSourcePosition(firstDef.Name.Base().Span),
+				},
+			}
+			assert.Equal(t, utils.CombineErrors(duplicateErr), err)
+
+			//the error should only be located in the duplicate definition (main chunk).
+			assert.Equal(t, []*StaticCheckError{duplicateErr}, data.NodeErrors(duplicateDef, mod.MainChunk.Name()))
+			assert.Empty(t, data.NodeErrors(firstDef, includedChunk.Name()))
 		})
 
 		t.Run("duplicate definition, first definition in included chunk, import after definition", func(t *testing.T) {
@@ -4256,11 +5818,59 @@ func TestCheck(t *testing.T) {
 			})
 
 			duplicateDef := parse.FindNode(mod.MainChunk.Node, (*parse.StructDefinition)(nil), nil)
+			importStmt := parse.FindNode(mod.MainChunk.Node, (*parse.InclusionImportStatement)(nil), nil)
+			includedChunk := mod.InclusionStatementMap[importStmt]
+			firstDef := parse.FindNode(includedChunk.Node, (*parse.StructDefinition)(nil), nil)
 
-			expectedErr := utils.CombineErrors(
-				makeError(duplicateDef.Name, mod.MainChunk, fmtInvalidStructDefAlreadyDeclared("MyStruct")),
-			)
-			assert.Equal(t, expectedErr, err)
+			duplicateErr := NewStaticCheckError(fmtInvalidStructDefAlreadyDeclared("MyStruct"), parse.SourcePositionStack{
+				mod.MainChunk.GetSourcePosition(duplicateDef.Name.Base().Span),
+			})
+			duplicateErr.RelatedLocations = []parse.SourcePositionStack{
+				{
+					mod.MainChunk.GetSourcePosition(importStmt.Span),
+					includedChunk.GetSourcePosition(firstDef.Name.Base().Span),
+				},
+			}
+			assert.Equal(t, utils.CombineErrors(duplicateErr), err)
+		})
+
+		t.Run("duplicate definition in an included chunk, first definition in another included chunk", func(t *testing.T) {
+			moduleName := "mymod.ix"
+			modpath := writeModuleAndIncludedFiles(t, moduleName, `
+				manifest {}
+				import ./dep1.ix
+				import ./dep2.ix
+			`, map[string]string{
+				"./dep1.ix": "includable-chunk\n struct MyStruct {}",
+				"./dep2.ix": "includable-chunk\n struct MyStruct {}",
+			})
+
+			mod, err := ParseLocalModule(modpath, ModuleParsingConfig{Context: createParsingContext(modpath)})
+			assert.NoError(t, err)
+
+			err = staticCheckNoData(StaticCheckInput{
+				Module: mod,
+				Node:   mod.MainChunk.Node,
+				Chunk:  mod.MainChunk,
+			})
+
+			importStmts := parse.FindNodes(mod.MainChunk.Node, (*parse.InclusionImportStatement)(nil), nil)
+			includedChunk1 := mod.InclusionStatementMap[importStmts[0]]
+			includedChunk2 := mod.InclusionStatementMap[importStmts[1]]
+			firstDef := parse.FindNode(includedChunk1.Node, (*parse.StructDefinition)(nil), nil)
+			duplicateDef := parse.FindNode(includedChunk2.Node, (*parse.StructDefinition)(nil), nil)
+
+			duplicateErr := NewStaticCheckError(fmtInvalidStructDefAlreadyDeclared("MyStruct"), parse.SourcePositionStack{
+				mod.MainChunk.GetSourcePosition(importStmts[1].Span),
+				includedChunk2.GetSourcePosition(duplicateDef.Name.Base().Span),
+			})
+			duplicateErr.RelatedLocations = []parse.SourcePositionStack{
+				{
+					mod.MainChunk.GetSourcePosition(importStmts[0].Span),
+					includedChunk1.GetSourcePosition(firstDef.Name.Base().Span),
+				},
+			}
+			assert.Equal(t, utils.CombineErrors(duplicateErr), err)
 		})
 
 		t.Run("same definition in embedded module", func(t *testing.T) {
@@ -4392,29 +6002,87 @@ func TestCheck(t *testing.T) {
 
 		t.Run("initialization", func(t *testing.T) {
 			n, src := mustParseCode(`
-				struct Lexer {}
+				struct Lexer { index int }
 				lexer = new Lexer {index: 0}
 			`)
 
 			globals := GlobalVariablesFromMap(map[string]Value{}, nil)
-			assert.NoError(t, staticCheckNoData(StaticCheckInput{Node: n, Chunk: src, Globals: globals}))
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{
+				Node:     n,
+				Chunk:    src,
+				Globals:  globals,
+				Patterns: map[string]Pattern{"int": INT_PATTERN},
+			}))
 		})
 
 		t.Run("duplicate field in initialization", func(t *testing.T) {
 			n, src := mustParseCode(`
-				struct Lexer {}
+				struct Lexer { index int }
 				lexer = new Lexer {index: 0, index: 1}
 			`)
 
 			inits := parse.FindNodes(n, (*parse.StructFieldInitialization)(nil), nil)
 
-			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			err := staticCheckNoData(StaticCheckInput{
+				Node:     n,
+				Chunk:    src,
+				Patterns: map[string]Pattern{"int": INT_PATTERN},
+			})
 			expectedErr := utils.CombineErrors(
 				makeError(inits[1].Name, src, fmtDuplicateFieldName("index")),
 			)
 			assert.Equal(t, expectedErr, err)
 		})
 
+		t.Run("unknown field in initialization", func(t *testing.T) {
+			n, src := mustParseCode(`
+				struct Lexer { index int }
+				lexer = new Lexer {index: 0, position: 1}
+			`)
+
+			inits := parse.FindNodes(n, (*parse.StructFieldInitialization)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{
+				Node:     n,
+				Chunk:    src,
+				Patterns: map[string]Pattern{"int": INT_PATTERN},
+			})
+			expectedErr := utils.CombineErrors(
+				makeError(inits[1].Name, src, fmtStructHasNoFieldNamed("Lexer", "position")),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("initialization of a method", func(t *testing.T) {
+			n, src := mustParseCode(`
+				struct Lexer {
+					fn next(){}
+				}
+				lexer = new Lexer {next: 0}
+			`)
+
+			init := parse.FindNode(n, (*parse.StructFieldInitialization)(nil), nil)
+
+			err := staticCheckNoData(StaticCheckInput{Node: n, Chunk: src})
+			expectedErr := utils.CombineErrors(
+				makeError(init.Name, src, fmtStructHasNoFieldNamed("Lexer", "next")),
+			)
+			assert.Equal(t, expectedErr, err)
+		})
+
+		t.Run("initialization of a struct type defined after the new expression", func(t *testing.T) {
+			n, src := mustParseCode(`
+				lexer = new Lexer {index: 0}
+				struct Lexer { index int }
+			`)
+
+			assert.NoError(t, staticCheckNoData(StaticCheckInput{
+				Node:     n,
+				Chunk:    src,
+				Patterns: map[string]Pattern{"int": INT_PATTERN},
+			}))
+		})
+
 		t.Run("undefined struct type", func(t *testing.T) {
 			n, src := mustParseCode(`
 				lexer = new Lexer
@@ -4919,6 +6587,422 @@ func TestCheckDatabasesObject(t *testing.T) {
 	})
 }
 
+func TestCheckInvocationObject(t *testing.T) {
+
+	parseManifestObject := func(s string) (manifestObj, invocationObj *parse.ObjectLiteral) {
+		manifestObj = parse.MustParseChunk(s).Statements[0].(*parse.ObjectLiteral)
+		invocationDesc, _ := manifestObj.PropValue(MANIFEST_INVOCATION_SECTION_NAME)
+		return manifestObj, invocationDesc.(*parse.ObjectLiteral)
+	}
+
+	registerLdb := func() {
+		RegisterStaticallyCheckDbResolutionDataFn("ldb", func(node parse.Node, p Project) (errorMsg string) {
+			return ""
+		})
+	}
+
+	t.Run("async with a valid on-added-element property", func(t *testing.T) {
+		resetStaticallyCheckDbResolutionDataFnRegistry()
+		defer resetStaticallyCheckDbResolutionDataFnRegistry()
+		registerLdb()
+
+		manifestObj, invocationObj := parseManifestObject(`
+			{
+				databases: /main.ix
+				invocation: {
+					on-added-element: ldb://main/users
+					async: true
+				}
+			}
+		`)
+
+		checkInvocationObject(invocationObj, manifestObj, func(n parse.Node, msg string) {
+			assert.Fail(t, msg)
+		}, func(n parse.Node, msg string) {
+			assert.Fail(t, msg)
+		}, nil)
+	})
+
+	t.Run("async without on-added-element property", func(t *testing.T) {
+		manifestObj, invocationObj := parseManifestObject(`
+			{
+				invocation: {
+					async: true
+				}
+			}
+		`)
+		boolLit := parse.FindNode(invocationObj, (*parse.BooleanLiteral)(nil), nil)
+
+		warned := false
+
+		checkInvocationObject(invocationObj, manifestObj, func(n parse.Node, msg string) {
+			assert.Fail(t, msg)
+		}, func(n parse.Node, msg string) {
+			warned = true
+			assert.Same(t, boolLit, n)
+			assert.Equal(t, ASYNC_INVOCATION_HAS_NO_EFFECT_WITHOUT_VALID_TRIGGER, msg)
+		}, nil)
+
+		assert.True(t, warned)
+	})
+
+	t.Run("async with an on-added-element property with an unsupported scheme", func(t *testing.T) {
+		resetStaticallyCheckDbResolutionDataFnRegistry()
+		defer resetStaticallyCheckDbResolutionDataFnRegistry()
+
+		manifestObj, invocationObj := parseManifestObject(`
+			{
+				invocation: {
+					on-added-element: ldb://main/users
+					async: true
+				}
+			}
+		`)
+
+		var warnings []string
+
+		checkInvocationObject(invocationObj, manifestObj, func(n parse.Node, msg string) {}, func(n parse.Node, msg string) {
+			warnings = append(warnings, msg)
+		}, nil)
+
+		assert.Equal(t, []string{ASYNC_INVOCATION_HAS_NO_EFFECT_WITHOUT_VALID_TRIGGER}, warnings)
+	})
+
+	t.Run("async: false without on-added-element property", func(t *testing.T) {
+		manifestObj, invocationObj := parseManifestObject(`
+			{
+				invocation: {
+					async: false
+				}
+			}
+		`)
+
+		checkInvocationObject(invocationObj, manifestObj, func(n parse.Node, msg string) {
+			assert.Fail(t, msg)
+		}, func(n parse.Node, msg string) {
+			assert.Fail(t, msg)
+		}, nil)
+	})
+}
+
+func TestStaticCheckWarnings(t *testing.T) {
+
+	t.Run("warnings should not cause StaticCheck to return an error", func(t *testing.T) {
+		src := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "test",
+			CodeString: `1..10`,
+		}))
+
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		data, err := StaticCheck(StaticCheckInput{
+			State: NewGlobalState(ctx),
+			Node:  src.Node,
+			Chunk: src,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Empty(t, data.Errors())
+		assert.NotEmpty(t, data.Warnings())
+	})
+}
+
+func TestStaticCheckErrorCategories(t *testing.T) {
+	check := func(code string) []*StaticCheckError {
+		src := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "test",
+			CodeString: code,
+		}))
+
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		data, _ := StaticCheck(StaticCheckInput{
+			State: NewGlobalState(ctx),
+			Node:  src.Node,
+			Chunk: src,
+		})
+		return data.Errors()
+	}
+
+	categories := []error{ErrMisplacement, ErrDuplicateDeclaration, ErrUndeclaredVariable}
+
+	testCases := []struct {
+		name     string
+		code     string
+		category error
+	}{
+		{"undeclared variable", "a", ErrUndeclaredVariable},
+		{"undeclared local variable", "$a", ErrUndeclaredVariable},
+		{"undeclared global variable", "$$a", ErrUndeclaredVariable},
+		{"duplicate function declaration", "fn f(){}\nfn f(){}", ErrDuplicateDeclaration},
+		{"duplicate pattern definition", "pattern p = 1\npattern p = 2", ErrDuplicateDeclaration},
+		{"misplaced pattern definition", "fn f(){ pattern p = 1 }", ErrMisplacement},
+		{"misplaced struct type name", "struct S {}\npattern p = {a: %S}", ErrMisplacement},
+		{"no category", "a = {a: 1, a: 2}", nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			errs := check(testCase.code)
+			if !assert.NotEmpty(t, errs) {
+				return
+			}
+
+			err := errs[0]
+
+			for _, category := range categories {
+				if category == testCase.category {
+					assert.ErrorIs(t, err, category)
+				} else {
+					assert.NotErrorIs(t, err, category)
+				}
+			}
+		})
+	}
+}
+
+func TestStaticCheckSourceNameOverride(t *testing.T) {
+	src := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+		NameString: "test",
+		CodeString: `$$a`,
+	}))
+
+	ctx := NewContext(ContextConfig{})
+	defer ctx.CancelGracefully()
+
+	data, err := StaticCheck(StaticCheckInput{
+		State:              NewGlobalState(ctx),
+		Node:               src.Node,
+		Chunk:              src,
+		SourceNameOverride: "virtual-document",
+	})
+
+	if !assert.Error(t, err) || !assert.Len(t, data.Errors(), 1) {
+		return
+	}
+
+	checkErr := data.Errors()[0]
+
+	globalVar := parse.FindNode(src.Node, (*parse.GlobalVariable)(nil), nil)
+	expectedPosition := src.GetSourcePosition(globalVar.Span)
+	expectedPosition.SourceName = "virtual-document"
+
+	assert.Equal(t, parse.SourcePositionStack{expectedPosition}, checkErr.LocationStack())
+}
+
+func TestStaticCheckDataNodeErrors(t *testing.T) {
+	src := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+		NameString: "test",
+		CodeString: "fn f(){\n\treturn $$a\n}\nfn g(){\n\treturn $$b\n}",
+	}))
+
+	ctx := NewContext(ContextConfig{})
+	defer ctx.CancelGracefully()
+
+	data, err := StaticCheck(StaticCheckInput{
+		State: NewGlobalState(ctx),
+		Node:  src.Node,
+		Chunk: src,
+	})
+
+	if !assert.Error(t, err) || !assert.Len(t, data.Errors(), 2) {
+		return
+	}
+
+	firstErr := data.Errors()[0]
+	secondErr := data.Errors()[1]
+
+	firstFn := src.Node.Statements[0]
+	secondFn := src.Node.Statements[1]
+
+	assert.Equal(t, []*StaticCheckError{firstErr}, data.NodeErrors(firstFn, "test"))
+	assert.Equal(t, []*StaticCheckError{secondErr}, data.NodeErrors(secondFn, "test"))
+	assert.Equal(t, []*StaticCheckError{firstErr, secondErr}, data.NodeErrors(src.Node, "test"))
+
+	//the global variable is the deepest node with an error.
+	globalVar := parse.FindNode(firstFn, (*parse.GlobalVariable)(nil), nil)
+	assert.Equal(t, []*StaticCheckError{firstErr}, data.NodeErrors(globalVar, "test"))
+
+	//the identifier of the function has no error.
+	assert.Empty(t, data.NodeErrors(firstFn.(*parse.FunctionDeclaration).Name, "test"))
+
+	//other source
+	assert.Empty(t, data.NodeErrors(src.Node, "other"))
+}
+
+func TestStaticCheckOnError(t *testing.T) {
+	src := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+		NameString: "test",
+		CodeString: "struct S {}\nstruct S {}\nfn f(){\n\treturn $$a\n}\nreturn $$b",
+	}))
+
+	ctx := NewContext(ContextConfig{})
+	defer ctx.CancelGracefully()
+
+	var reportedErrors []*StaticCheckError
+
+	data, err := StaticCheck(StaticCheckInput{
+		State: NewGlobalState(ctx),
+		Node:  src.Node,
+		Chunk: src,
+		OnError: func(err *StaticCheckError) {
+			reportedErrors = append(reportedErrors, err)
+		},
+	})
+
+	if !assert.Error(t, err) || !assert.Len(t, data.Errors(), 3) {
+		return
+	}
+
+	//each error should be reported exactly once and in the order of discovery.
+	assert.Equal(t, data.Errors(), reportedErrors)
+
+	assert.Contains(t, reportedErrors[0].Message, fmtInvalidStructDefAlreadyDeclared("S"))
+	assert.Contains(t, reportedErrors[1].Message, fmtGlobalVarIsNotDeclared("a"))
+	assert.Contains(t, reportedErrors[2].Message, fmtGlobalVarIsNotDeclared("b"))
+}
+
+func TestStaticCheckMaxErrors(t *testing.T) {
+
+	check := func(t *testing.T, code string, maxErrors int, onError func(err *StaticCheckError)) (*StaticCheckData, error) {
+		src := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "test",
+			CodeString: code,
+		}))
+
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		return StaticCheck(StaticCheckInput{
+			State:     NewGlobalState(ctx),
+			Node:      src.Node,
+			Chunk:     src,
+			MaxErrors: maxErrors,
+			OnError:   onError,
+		})
+	}
+
+	//each line contains an undeclared global variable.
+	code := strings.Repeat("$$a\n", 20)
+
+	t.Run("no limit", func(t *testing.T) {
+		data, err := check(t, code, 0, nil)
+
+		if assert.Error(t, err) {
+			assert.Len(t, data.Errors(), 20)
+		}
+	})
+
+	t.Run("limit lower than the number of errors", func(t *testing.T) {
+		var reportedErrors []*StaticCheckError
+
+		data, err := check(t, code, 5, func(err *StaticCheckError) {
+			reportedErrors = append(reportedErrors, err)
+		})
+
+		if !assert.Error(t, err) || !assert.Len(t, data.Errors(), 6) {
+			return
+		}
+
+		for _, err := range data.Errors()[:5] {
+			assert.Contains(t, err.Message, fmtGlobalVarIsNotDeclared("a"))
+		}
+
+		marker := data.Errors()[5]
+		assert.Equal(t, CHECK_ERR_PREFIX+TOO_MANY_ERRORS, marker.Message)
+
+		//the marker should be located at the first dropped error.
+		assert.EqualValues(t, 6, marker.Location[0].StartLine)
+
+		assert.Equal(t, data.Errors(), reportedErrors)
+	})
+
+	t.Run("limit equal to the number of errors", func(t *testing.T) {
+		data, err := check(t, code, 20, nil)
+
+		if assert.Error(t, err) && assert.Len(t, data.Errors(), 20) {
+			assert.NotContains(t, data.Errors()[19].Message, TOO_MANY_ERRORS)
+		}
+	})
+
+	t.Run("included chunk", func(t *testing.T) {
+		checkModule := func(t *testing.T, mainCode, includedChunkCode string, maxErrors int) (*StaticCheckData, []*StaticCheckError) {
+			modpath := writeModuleAndIncludedFiles(t, "mymod.ix", mainCode, map[string]string{
+				"./dep.ix": "includable-chunk\n" + includedChunkCode,
+			})
+
+			mod, err := ParseLocalModule(modpath, ModuleParsingConfig{Context: createParsingContext(modpath)})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			ctx := NewContext(ContextConfig{})
+			defer ctx.CancelGracefully()
+
+			var reportedErrors []*StaticCheckError
+
+			data, err := StaticCheck(StaticCheckInput{
+				State:     NewGlobalState(ctx),
+				Module:    mod,
+				Node:      mod.MainChunk.Node,
+				Chunk:     mod.MainChunk,
+				MaxErrors: maxErrors,
+				OnError: func(err *StaticCheckError) {
+					reportedErrors = append(reportedErrors, err)
+				},
+			})
+			assert.Error(t, err)
+			return data, reportedErrors
+		}
+
+		countMarkers := func(errors []*StaticCheckError) int {
+			count := 0
+			for _, err := range errors {
+				if err.Message == CHECK_ERR_PREFIX+TOO_MANY_ERRORS {
+					count++
+				}
+			}
+			return count
+		}
+
+		t.Run("limit reached in the included chunk", func(t *testing.T) {
+			data, reportedErrors := checkModule(t, "manifest {}\n$$a\n$$a\nimport ./dep.ix\n$$a\n$$a", strings.Repeat("fn f(){ $$a }\n", 10), 5)
+
+			if !assert.Len(t, data.Errors(), 6) {
+				return
+			}
+			assert.Equal(t, 1, countMarkers(data.Errors()))
+			assert.Equal(t, CHECK_ERR_PREFIX+TOO_MANY_ERRORS, data.Errors()[5].Message)
+			assert.Equal(t, data.Errors(), reportedErrors)
+		})
+
+		t.Run("limit reached before the included chunk", func(t *testing.T) {
+			data, reportedErrors := checkModule(t, "manifest {}\n"+strings.Repeat("$$a\n", 6)+"import ./dep.ix", strings.Repeat("fn f(){ $$a }\n", 3), 5)
+
+			if !assert.Len(t, data.Errors(), 6) {
+				return
+			}
+			assert.Equal(t, 1, countMarkers(data.Errors()))
+			assert.Equal(t, data.Errors(), reportedErrors)
+		})
+	})
+
+	t.Run("the check should be completed", func(t *testing.T) {
+		//the range literal at the end causes a warning.
+		data, err := check(t, code+"1..2", 5, nil)
+
+		if assert.Error(t, err) {
+			assert.Len(t, data.Errors(), 6)
+			if assert.Len(t, data.Warnings(), 1) {
+				assert.Contains(t, data.Warnings()[0].Message, RANGE_LITERAL_HAS_NO_EFFECT)
+			}
+		}
+	})
+}
+
 // testMutableGoValue implements the GoValue interface
 type testMutableGoValue struct {
 	Name   string
@@ -5041,3 +7125,267 @@ func (*testProject) CanProvideS3Credentials(s3Provider string) (bool, error) {
 func (*testProject) GetS3CredentialsForBucket(ctx *Context, bucketName string, provider string) (accessKey string, secretKey string, s3Endpoint Host, _ error) {
 	panic("unimplemented")
 }
+
+func TestStaticCheckDataHasErrorsAndWarnings(t *testing.T) {
+
+	t.Run("no errors and no warnings", func(t *testing.T) {
+		data := &StaticCheckData{}
+
+		assert.False(t, data.HasErrors())
+		assert.False(t, data.HasWarnings())
+	})
+
+	t.Run("only warnings", func(t *testing.T) {
+		src := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "test",
+			CodeString: `1..10`,
+		}))
+
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		data, err := StaticCheck(StaticCheckInput{
+			State: NewGlobalState(ctx),
+			Node:  src.Node,
+			Chunk: src,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.False(t, data.HasErrors())
+		assert.True(t, data.HasWarnings())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		src := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "test",
+			CodeString: `a`,
+		}))
+
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		data, err := StaticCheck(StaticCheckInput{
+			State: NewGlobalState(ctx),
+			Node:  src.Node,
+			Chunk: src,
+		})
+		if !assert.Error(t, err) {
+			return
+		}
+
+		assert.True(t, data.HasErrors())
+		assert.False(t, data.HasWarnings())
+	})
+}
+
+func TestStaticCheckDataMerge(t *testing.T) {
+
+	check := func(code string) (*parse.Chunk, *StaticCheckData) {
+		src := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "test",
+			CodeString: code,
+		}))
+
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		data, _ := StaticCheck(StaticCheckInput{
+			State: NewGlobalState(ctx),
+			Node:  src.Node,
+			Chunk: src,
+		})
+		return src.Node, data
+	}
+
+	chunk1, data1 := check("globalvar a = 1\nfn f(){ return a }\n$$x")
+	chunk2, data2 := check("globalvar b = 1\nfn g(){ $$b = 2 }\n$$y")
+
+	fnExpr1 := parse.FindNode(chunk1, (*parse.FunctionExpression)(nil), nil)
+	fnExpr2 := parse.FindNode(chunk2, (*parse.FunctionExpression)(nil), nil)
+
+	if !assert.Len(t, data1.Errors(), 1) || !assert.Len(t, data2.Errors(), 1) {
+		return
+	}
+
+	errors1 := slices.Clone(data1.Errors())
+	errors2 := slices.Clone(data2.Errors())
+
+	merged := data1.Merge(data2)
+
+	assert.Equal(t, append(errors1, errors2...), merged.Errors())
+
+	if assert.NotNil(t, merged.GetFnData(fnExpr1)) {
+		assert.Equal(t, []string{"a"}, merged.GetFnData(fnExpr1).capturedGlobals)
+
+		//the entries should be copied.
+		assert.NotSame(t, data1.GetFnData(fnExpr1), merged.GetFnData(fnExpr1))
+	}
+
+	if assert.NotNil(t, merged.GetFnData(fnExpr2)) {
+		assert.True(t, merged.GetFnData(fnExpr2).assignGlobal)
+		assert.NotSame(t, data2.GetFnData(fnExpr2), merged.GetFnData(fnExpr2))
+	}
+
+	//the merged objects should not be modified.
+	assert.Equal(t, errors1, data1.Errors())
+	assert.Nil(t, data1.GetFnData(fnExpr2))
+
+	assert.Equal(t, errors2, data2.Errors())
+	assert.Nil(t, data2.GetFnData(fnExpr1))
+}
+
+func TestStaticCheckDataSourcePositions(t *testing.T) {
+
+	check := func(code string, indexPositions bool, sourceNameOverride string) (*parse.ParsedChunkSource, *StaticCheckData) {
+		src := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "test",
+			CodeString: code,
+		}))
+
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		data, _ := StaticCheck(StaticCheckInput{
+			State:                NewGlobalState(ctx),
+			Node:                 src.Node,
+			Chunk:                src,
+			IndexSourcePositions: indexPositions,
+			SourceNameOverride:   sourceNameOverride,
+		})
+		return src, data
+	}
+
+	t.Run("base case", func(t *testing.T) {
+		chunk, data := check("a = 1\nfn f(){\n  return 2\n}", true, "")
+
+		intLiterals := parse.FindNodes(chunk.Node, (*parse.IntLiteral)(nil), nil)
+		fnExpr := parse.FindNode(chunk.Node, (*parse.FunctionExpression)(nil), nil)
+
+		positions := data.SourcePositions()
+
+		position, ok := positions[intLiterals[0]]
+		if assert.True(t, ok) {
+			assert.Equal(t, parse.SourcePositionRange{
+				SourceName:  "test",
+				StartLine:   1,
+				StartColumn: 5,
+				EndLine:     1,
+				EndColumn:   6,
+				Span:        parse.NodeSpan{Start: 4, End: 5},
+			}, position)
+		}
+
+		position, ok = positions[intLiterals[1]]
+		if assert.True(t, ok) {
+			assert.Equal(t, chunk.GetSourcePosition(intLiterals[1].Span), position)
+			assert.EqualValues(t, 3, position.StartLine)
+		}
+
+		position, ok = positions[fnExpr]
+		if assert.True(t, ok) {
+			assert.Equal(t, chunk.GetSourcePosition(fnExpr.Span), position)
+		}
+
+		position, ok = positions[chunk.Node]
+		if assert.True(t, ok) {
+			assert.Equal(t, chunk.GetSourcePosition(chunk.Node.Span), position)
+		}
+	})
+
+	t.Run("source name override", func(t *testing.T) {
+		chunk, data := check("a = 1", true, "/main.ix")
+
+		intLiteral := parse.FindNode(chunk.Node, (*parse.IntLiteral)(nil), nil)
+
+		position, ok := data.SourcePositions()[intLiteral]
+		if assert.True(t, ok) {
+			assert.Equal(t, "/main.ix", position.SourceName)
+		}
+	})
+
+	t.Run("node not in the checked chunk", func(t *testing.T) {
+		_, data := check("a = 1", true, "")
+		otherChunk, _ := check("a = 1", false, "")
+
+		intLiteral := parse.FindNode(otherChunk.Node, (*parse.IntLiteral)(nil), nil)
+
+		_, ok := data.SourcePositions()[intLiteral]
+		assert.False(t, ok)
+	})
+
+	t.Run("positions not indexed", func(t *testing.T) {
+		_, data := check("a = 1", false, "")
+
+		assert.Nil(t, data.SourcePositions())
+	})
+}
+
+func TestCheckChunkSyntaxOnly(t *testing.T) {
+	check := func(code string) (*parse.ParsedChunkSource, []*StaticCheckError) {
+		chunk := utils.Must(parse.ParseChunkSource(parse.InMemorySource{
+			NameString: "test",
+			CodeString: code,
+		}))
+		return chunk, CheckChunkSyntaxOnly(chunk)
+	}
+
+	t.Run("valid code", func(t *testing.T) {
+		_, errs := check("a = {a: 1s, b: 1kB}")
+		assert.Empty(t, errs)
+	})
+
+	t.Run("declarations and variables are not checked", func(t *testing.T) {
+		_, errs := check("a = b\nfn f(){}\nfn f(){}\nimport lib https://example.com/lib.ix {}")
+		assert.Empty(t, errs)
+	})
+
+	t.Run("invalid quantity", func(t *testing.T) {
+		chunk, errs := check("a = 1s1h")
+		if !assert.Len(t, errs, 1) {
+			return
+		}
+
+		quantityLit := parse.FindNode(chunk.Node, (*parse.QuantityLiteral)(nil), nil)
+		location := parse.SourcePositionStack{chunk.GetSourcePosition(quantityLit.Span)}
+		assert.Equal(t, NewStaticCheckError(INVALID_QUANTITY, location), errs[0])
+	})
+
+	t.Run("invalid rate", func(t *testing.T) {
+		_, errs := check("a = 1s/s")
+		if assert.Len(t, errs, 1) {
+			assert.Equal(t, CHECK_ERR_PREFIX+INVALID_RATE, errs[0].Message)
+		}
+	})
+
+	t.Run("quantity range with bounds in the wrong order", func(t *testing.T) {
+		_, errs := check("a = (2kB..1kB)")
+		if assert.Len(t, errs, 1) {
+			assert.Equal(t, CHECK_ERR_PREFIX+LOWER_BOUND_OF_QUANTITY_RANGE_LIT_SHOULD_BE_SMALLER_THAN_UPPER_BOUND, errs[0].Message)
+		}
+	})
+
+	t.Run("duplicate key in object literal", func(t *testing.T) {
+		chunk, errs := check("a = {a: 1, a: 2}")
+		if !assert.Len(t, errs, 1) {
+			return
+		}
+
+		prop := parse.FindNodes(chunk.Node, (*parse.ObjectProperty)(nil), nil)[1]
+		location := parse.SourcePositionStack{chunk.GetSourcePosition(prop.Span)}
+		assert.Equal(t, NewStaticCheckError(fmtDuplicateKey("a"), location), errs[0])
+	})
+
+	t.Run("duplicate key in dictionary literal", func(t *testing.T) {
+		_, errs := check(`a = :{"a": 1, "a": 2}`)
+		if assert.Len(t, errs, 1) {
+			assert.Equal(t, CHECK_ERR_PREFIX+fmtDuplicateDictKey("a"), errs[0].Message)
+		}
+	})
+
+	t.Run("nested literals", func(t *testing.T) {
+		_, errs := check("fn f(){ return {a: #[1s1h], a: 1} }")
+		assert.Len(t, errs, 2)
+	})
+}
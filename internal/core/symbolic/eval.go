@@ -5108,6 +5108,8 @@ func evalLifetimejobExpression(n *parse.LifetimejobExpression, state *State, opt
 		state.addError(makeSymbolicEvalError(n.Meta, state, META_VAL_OF_LIFETIMEJOB_SHOULD_BE_IMMUTABLE))
 	}
 
+	state.symbolicData.SetContextData(n, state.ctx.currentData())
+
 	var subject Value = ANY
 	var subjectPattern Pattern = ANY_PATTERN
 
@@ -5126,6 +5128,10 @@ func evalLifetimejobExpression(n *parse.LifetimejobExpression, state *State, opt
 		}
 	}
 
+	if n.Module == nil { //parsing error
+		return NewLifetimeJob(subjectPattern), nil
+	}
+
 	v, err := symbolicEval(n.Module, state)
 	if err != nil {
 		return nil, err
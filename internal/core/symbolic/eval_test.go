@@ -11518,6 +11518,18 @@ func TestSymbolicEval(t *testing.T) {
 	})
 
 	t.Run("lifetimejob expression", func(t *testing.T) {
+		t.Run("missing embedded module", func(t *testing.T) {
+			n, state, _ := _makeStateAndChunk(`
+				pattern p = %{}
+				lifetimejob #job for %p
+			`)
+
+			res, err := symbolicEval(n, state)
+			assert.NoError(t, err)
+			assert.Nil(t, res)
+			assert.Empty(t, state.errors())
+		})
+
 		t.Run("should have access to implicit subject properties defined before and after the jobs", func(t *testing.T) {
 			n, state := MakeTestStateAndChunk(`{ 
 				a: int
@@ -9,6 +9,13 @@ import (
 
 const (
 	VISIBILITY_KEY = "_visibility_"
+
+	//keys of the object in the initialization block of the visibility metaproperty.
+	VISIBILITY_DESC_PUBLIC_KEY     = "public"
+	VISIBILITY_DESC_VISIBLE_BY_KEY = "visible_by"
+
+	//key of the 'visible_by' dictionary entry listing the properties visible by the object itself (#self).
+	VISIBILITY_DESC_SELF_KEY = "self"
 )
 
 var (
@@ -51,20 +58,20 @@ func initializeVisibilityMetaproperty(v *Object, block *parse.InitializationBloc
 		}
 
 		switch prop.Name() {
-		case "public":
+		case VISIBILITY_DESC_PUBLIC_KEY:
 			keyList := prop.Value.(*parse.KeyListExpression)
 			visibility.publicKeys = make([]string, len(keyList.Keys))
 			for i, n := range keyList.Names() {
 				visibility.publicKeys[i] = n.Name
 			}
-		case "visible_by":
+		case VISIBILITY_DESC_VISIBLE_BY_KEY:
 			dict := prop.Value.(*parse.DictionaryLiteral)
 
 			for _, entry := range dict.Entries {
 				switch keyNode := entry.Key.(type) {
 				case *parse.UnambiguousIdentifierLiteral:
 					switch keyNode.Name {
-					case "self":
+					case VISIBILITY_DESC_SELF_KEY:
 						keyList := entry.Value.(*parse.KeyListExpression)
 						visibility.selfVisibleKeys = make([]string, len(keyList.Keys))
 						for i, n := range keyList.Names() {
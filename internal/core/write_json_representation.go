@@ -13,10 +13,11 @@ import (
 )
 
 const (
-	JSON_UNTYPED_VALUE_SUFFIX   = "__value"
-	MAX_JSON_REPR_WRITING_DEPTH = 20
-	JS_MIN_SAFE_INTEGER         = -9007199254740991
-	JS_MAX_SAFE_INTEGER         = 9007199254740991
+	JSON_UNTYPED_VALUE_SUFFIX           = "__value"
+	MAX_JSON_REPR_WRITING_DEPTH         = 20
+	DEFAULT_JSON_ELEMENT_FLUSH_INTERVAL = 100
+	JS_MIN_SAFE_INTEGER                 = -9007199254740991
+	JS_MAX_SAFE_INTEGER                 = 9007199254740991
 
 	//int range serialization
 
@@ -136,6 +137,10 @@ type JSONSerializationConfig struct {
 	*ReprConfig
 	Pattern  Pattern //nillable
 	Location string  //location of the current value being serialized
+
+	//If greater than zero the stream is flushed each time ElementFlushInterval elements of a list or tuple have been written,
+	//this prevents large collections from being fully buffered. The setting is ignored by streams without an underlying writer.
+	ElementFlushInterval int
 }
 
 func GetJSONRepresentation(v Serializable, ctx *Context, pattern Pattern) string {
@@ -148,6 +153,31 @@ func GetJSONRepresentation(v Serializable, ctx *Context, pattern Pattern) string
 	return string(stream.Buffer())
 }
 
+// WriteChunkedJSONRepresentation writes the JSON representation of v to out, the stream is periodically flushed
+// (see JSONSerializationConfig.ElementFlushInterval) so that the representation of large lists and tuples is written in chunks.
+func WriteChunkedJSONRepresentation(ctx *Context, v Serializable, out io.Writer, config JSONSerializationConfig) error {
+	if config.ElementFlushInterval <= 0 {
+		config.ElementFlushInterval = DEFAULT_JSON_ELEMENT_FLUSH_INTERVAL
+	}
+
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, out, 0)
+
+	err := v.WriteJSONRepresentation(ctx, stream, config, 0)
+	if err != nil {
+		return err
+	}
+	return stream.Flush()
+}
+
+// flushAfterElementIfNecessary flushes the stream if the element at elementIndex
+// completes a group of config.ElementFlushInterval elements.
+func flushAfterElementIfNecessary(w *jsoniter.Stream, config JSONSerializationConfig, elementIndex int) error {
+	if config.ElementFlushInterval > 0 && (elementIndex+1)%config.ElementFlushInterval == 0 {
+		return w.Flush()
+	}
+	return nil
+}
+
 func MustGetJSONRepresentationWithConfig(v Serializable, ctx *Context, config JSONSerializationConfig) string {
 	repr, err := GetJSONRepresentationWithConfig(v, ctx, config)
 	if err != nil {
@@ -411,7 +441,8 @@ func (list *ValueList) WriteJSONRepresentation(ctx *Context, w *jsoniter.Stream,
 			first = false
 
 			elementConfig := JSONSerializationConfig{
-				ReprConfig: config.ReprConfig,
+				ReprConfig:           config.ReprConfig,
+				ElementFlushInterval: config.ElementFlushInterval,
 			}
 
 			if listPattern != nil {
@@ -426,6 +457,10 @@ func (list *ValueList) WriteJSONRepresentation(ctx *Context, w *jsoniter.Stream,
 			if err != nil {
 				return err
 			}
+
+			if err := flushAfterElementIfNecessary(w, config, i); err != nil {
+				return err
+			}
 		}
 
 		w.WriteArrayEnd()
@@ -453,7 +488,8 @@ func (list *NumberList[T]) WriteJSONRepresentation(ctx *Context, w *jsoniter.Str
 			first = false
 
 			elementConfig := JSONSerializationConfig{
-				ReprConfig: config.ReprConfig,
+				ReprConfig:           config.ReprConfig,
+				ElementFlushInterval: config.ElementFlushInterval,
 			}
 
 			if listPattern != nil {
@@ -468,6 +504,10 @@ func (list *NumberList[T]) WriteJSONRepresentation(ctx *Context, w *jsoniter.Str
 			if err != nil {
 				return err
 			}
+
+			if err := flushAfterElementIfNecessary(w, config, i); err != nil {
+				return err
+			}
 		}
 
 		w.WriteArrayEnd()
@@ -499,6 +539,10 @@ func (list *BoolList) WriteJSONRepresentation(ctx *Context, w *jsoniter.Stream,
 			} else {
 				w.WriteFalse()
 			}
+
+			if err := flushAfterElementIfNecessary(w, config, int(i)); err != nil {
+				return err
+			}
 		}
 		w.WriteArrayEnd()
 		return nil
@@ -516,7 +560,7 @@ func (list *StringList) WriteJSONRepresentation(ctx *Context, w *jsoniter.Stream
 	write := func(w *jsoniter.Stream) error {
 		w.WriteArrayStart()
 		first := true
-		for _, v := range list.elements {
+		for i, v := range list.elements {
 			if !first {
 				w.WriteMore()
 			}
@@ -527,6 +571,10 @@ func (list *StringList) WriteJSONRepresentation(ctx *Context, w *jsoniter.Stream
 			if err != nil {
 				return err
 			}
+
+			if err := flushAfterElementIfNecessary(w, config, i); err != nil {
+				return err
+			}
 		}
 
 		w.WriteArrayEnd()
@@ -567,7 +615,8 @@ func (tuple *Tuple) WriteJSONRepresentation(ctx *Context, w *jsoniter.Stream, co
 			first = false
 
 			elementConfig := JSONSerializationConfig{
-				ReprConfig: config.ReprConfig,
+				ReprConfig:           config.ReprConfig,
+				ElementFlushInterval: config.ElementFlushInterval,
 			}
 
 			if tuplePattern != nil {
@@ -582,6 +631,10 @@ func (tuple *Tuple) WriteJSONRepresentation(ctx *Context, w *jsoniter.Stream, co
 			if err != nil {
 				return err
 			}
+
+			if err := flushAfterElementIfNecessary(w, config, i); err != nil {
+				return err
+			}
 		}
 
 		w.WriteArrayEnd()
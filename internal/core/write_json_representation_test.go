@@ -512,6 +512,69 @@ func TestListJSONRepresentation(t *testing.T) {
 
 }
 
+func TestChunkedJSONRepresentation(t *testing.T) {
+	ctx := NewContexWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+
+	const ELEMENT_COUNT = 100_000
+
+	elements := make([]Serializable, ELEMENT_COUNT)
+	for i := range elements {
+		elements[i] = Int(i)
+	}
+
+	t.Run("large list", func(t *testing.T) {
+		list := NewWrappedValueList(elements...)
+		writer := &maxWriteSizeRecordingWriter{}
+
+		err := WriteChunkedJSONRepresentation(ctx, list, writer, JSONSerializationConfig{ElementFlushInterval: 100})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, GetJSONRepresentation(list, ctx, nil), writer.buf.String())
+		assert.Less(t, writer.maxWriteSize, 5_000)
+	})
+
+	t.Run("large tuple", func(t *testing.T) {
+		tuple := NewTuple(elements)
+		writer := &maxWriteSizeRecordingWriter{}
+
+		err := WriteChunkedJSONRepresentation(ctx, tuple, writer, JSONSerializationConfig{ElementFlushInterval: 100})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, GetJSONRepresentation(tuple, ctx, nil), writer.buf.String())
+		assert.Less(t, writer.maxWriteSize, 5_000)
+	})
+
+	t.Run("default flush interval", func(t *testing.T) {
+		list := NewWrappedValueList(elements...)
+		writer := &maxWriteSizeRecordingWriter{}
+
+		err := WriteChunkedJSONRepresentation(ctx, list, writer, JSONSerializationConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, GetJSONRepresentation(list, ctx, nil), writer.buf.String())
+		assert.Less(t, writer.maxWriteSize, 5_000)
+	})
+}
+
+// maxWriteSizeRecordingWriter records the size of the largest write, the size of a write
+// corresponds to the size of the stream buffer when it is flushed.
+type maxWriteSizeRecordingWriter struct {
+	buf          bytes.Buffer
+	maxWriteSize int
+}
+
+func (w *maxWriteSizeRecordingWriter) Write(p []byte) (int, error) {
+	w.maxWriteSize = max(w.maxWriteSize, len(p))
+	return w.buf.Write(p)
+}
+
 func TestByteSliceJSONRepresentation(t *testing.T) {
 	ctx := NewContexWithEmptyState(ContextConfig{}, nil)
 	defer ctx.CancelGracefully()
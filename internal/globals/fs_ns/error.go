@@ -3,6 +3,9 @@ package fs_ns
 import (
 	"errors"
 	"fmt"
+
+	"github.com/inoxlang/inox/internal/commonfmt"
+	"github.com/inoxlang/inox/internal/core"
 )
 
 var (
@@ -13,8 +16,20 @@ var (
 	ErrNoRemainingSpaceUsableByFS    = errors.New("no remaining space usable by filesystem")
 	ErrNoRemainingSpaceToApplyChange = errors.New("no remaining space to apply change")
 	ErrMaxUsableSpaceTooSmall        = errors.New("the given usable space value is too small")
+	ErrUnderlyingStorageFull         = errors.New("the underlying storage is full")
+	ErrTooManyOpenFiles              = errors.New("too many open files")
+	ErrNotDirectory                  = errors.New("not a directory")
+	ErrFilesystemDirAlreadyInUse     = errors.New("the directory is already used by another filesystem")
 )
 
 func fmtDirContainFiles(path string) string {
 	return fmt.Sprintf("dir: %s contains files", path)
 }
+
+func makeSnapshotMaxTotalSizeExceededError(maxTotalSize core.ByteCount) error {
+	max, err := commonfmt.FmtByteCount(int64(maxTotalSize), -1)
+	if err != nil {
+		panic(err)
+	}
+	return fmt.Errorf("%w (%s)", core.ErrSnapshotMaxTotalSizeExceeded, max)
+}
@@ -30,6 +30,11 @@ func isWriteOnly(flag int) bool {
 	return flag&os.O_WRONLY != 0
 }
 
+// isWriting returns true if flag allows modifying the content of the file.
+func isWriting(flag int) bool {
+	return flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_TRUNC) != 0
+}
+
 func isSymlink(m os.FileMode) bool {
 	return m&os.ModeSymlink != 0
 }
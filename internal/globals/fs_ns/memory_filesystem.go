@@ -4,6 +4,7 @@ package fs_ns
 
 import (
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -165,8 +166,14 @@ func (fs *MemFilesystem) ReadDir(path string) ([]os.FileInfo, error) {
 	return entries, nil
 }
 
+// MkdirAll creates a directory and its missing parents, nothing is done if the directory already exists (its mode
+// is not changed). An error wrapping ErrNotDirectory and os.ErrExist is returned if a non-dir file exists at path.
 func (fs *MemFilesystem) MkdirAll(path string, perm os.FileMode) error {
 	_, err := fs.s.New(path, perm|os.ModeDir, 0)
+	if errors.Is(err, os.ErrExist) {
+		//only returned if there is a non-dir file at path.
+		return fmt.Errorf("%w: %w", ErrNotDirectory, err)
+	}
 	return err
 }
 
@@ -260,12 +267,23 @@ func (fs *MemFilesystem) TakeFilesystemSnapshot(config core.FilesystemSnapshotCo
 		}
 	}
 
+	//total size of the file contents added to the snapshot.
+	var totalSize core.ByteCount
+
 	//add includable files & directories to the snapshot
 	for normalizedPath, f := range storage.files {
 		if _, ok := includableFiles[normalizedPath]; !ok {
 			continue
 		}
 
+		if config.Context != nil {
+			select {
+			case <-config.Context.Done():
+				return nil, config.Context.Err()
+			default:
+			}
+		}
+
 		f.content.lock.RLock()
 		defer f.content.lock.RUnlock()
 
@@ -302,6 +320,11 @@ func (fs *MemFilesystem) TakeFilesystemSnapshot(config core.FilesystemSnapshotCo
 		snapshot.MetadataMap[normalizedPath] = metadata
 
 		if !info.IsDir() {
+			totalSize += core.ByteCount(len(f.content.bytes))
+			if config.MaxTotalSize > 0 && totalSize > config.MaxTotalSize {
+				return nil, makeSnapshotMaxTotalSizeExceededError(config.MaxTotalSize)
+			}
+
 			metadata.ChecksumSHA256 = sha256.Sum256(f.content.bytes)
 
 			content := config.GetContent(metadata.ChecksumSHA256)
@@ -1,6 +1,10 @@
 package fs_ns
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"os"
 	"testing"
 
 	billy "github.com/go-git/go-billy/v5"
@@ -84,6 +88,45 @@ func TestMemoryFilesystemCapabilities(t *testing.T) {
 	}
 }
 
+func TestMemoryFilesystemMkdirAll(t *testing.T) {
+	t.Run("existing directory", func(t *testing.T) {
+		fs := NewMemFilesystem(10_000_000)
+
+		if !assert.NoError(t, fs.MkdirAll("/dir", 0o700)) {
+			return
+		}
+
+		infoBefore, err := fs.Stat("/dir")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		if !assert.NoError(t, fs.MkdirAll("/dir", 0o755)) {
+			return
+		}
+
+		info, err := fs.Stat("/dir")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.True(t, info.IsDir())
+		assert.Equal(t, infoBefore.Mode(), info.Mode())
+	})
+
+	t.Run("existing file", func(t *testing.T) {
+		fs := NewMemFilesystem(10_000_000)
+
+		if !assert.NoError(t, util.WriteFile(fs, "/file.txt", nil, DEFAULT_FILE_FMODE)) {
+			return
+		}
+
+		err := fs.MkdirAll("/file.txt", DEFAULT_DIR_FMODE)
+		assert.ErrorIs(t, err, ErrNotDirectory)
+		assert.ErrorIs(t, err, os.ErrExist)
+	})
+}
+
 func TestMemoryFilesystemTakeFilesystemSnapshot(t *testing.T) {
 	const MAX_STORAGE_SIZE = 10_000
 
@@ -92,6 +135,70 @@ func TestMemoryFilesystemTakeFilesystemSnapshot(t *testing.T) {
 
 		return ctx, NewMemFilesystem(MAX_STORAGE_SIZE)
 	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		fls := NewMemFilesystem(MAX_STORAGE_SIZE)
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("a"), DEFAULT_FILE_FMODE))
+
+		snapshotCtx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		snapshotCtx.CancelGracefully()
+
+		snapshot, err := fls.TakeFilesystemSnapshot(core.FilesystemSnapshotConfig{
+			GetContent: func(ChecksumSHA256 [32]byte) core.AddressableContent {
+				return nil
+			},
+			InclusionFilters: []core.PathPattern{"/..."},
+			Context:          snapshotCtx,
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, snapshot)
+	})
+
+	t.Run("max total size", func(t *testing.T) {
+		createFS := func() *MemFilesystem {
+			fls := NewMemFilesystem(MAX_STORAGE_SIZE)
+
+			utils.PanicIfErrAmong(
+				util.WriteFile(fls, "/a.ix", bytes.Repeat([]byte{'a'}, 100), DEFAULT_FILE_FMODE),
+				fls.MkdirAll("/dir", DEFAULT_DIR_FMODE),
+				util.WriteFile(fls, "/dir/b.ix", bytes.Repeat([]byte{'b'}, 50), DEFAULT_FILE_FMODE),
+				//excluded file
+				util.WriteFile(fls, "/c.txt", bytes.Repeat([]byte{'c'}, 1000), DEFAULT_FILE_FMODE),
+			)
+			return fls
+		}
+
+		t.Run("just under the maximum", func(t *testing.T) {
+			fls := createFS()
+
+			snapshot, err := fls.TakeFilesystemSnapshot(core.FilesystemSnapshotConfig{
+				GetContent:       func(ChecksumSHA256 [32]byte) core.AddressableContent { return nil },
+				InclusionFilters: []core.PathPattern{"/**/*.ix"},
+				MaxTotalSize:     150,
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			content, err := snapshot.Content("/dir/b.ix")
+			if assert.NoError(t, err) {
+				assert.Equal(t, bytes.Repeat([]byte{'b'}, 50), utils.Must(io.ReadAll(content.Reader())))
+			}
+		})
+
+		t.Run("just over the maximum", func(t *testing.T) {
+			fls := createFS()
+
+			snapshot, err := fls.TakeFilesystemSnapshot(core.FilesystemSnapshotConfig{
+				GetContent:       func(ChecksumSHA256 [32]byte) core.AddressableContent { return nil },
+				InclusionFilters: []core.PathPattern{"/**/*.ix"},
+				MaxTotalSize:     149,
+			})
+			assert.ErrorIs(t, err, core.ErrSnapshotMaxTotalSizeExceeded)
+			assert.Nil(t, snapshot)
+		})
+	})
 }
 
 func TestNewMemFilesystemFromSnapshot(t *testing.T) {
@@ -425,9 +425,14 @@ func (s *inMemStorage) Remove(path string) error {
 	return nil
 }
 
+// NormalizeAsAbsolute cleans path and makes it absolute, the empty path and "." are normalized to the root directory.
 func NormalizeAsAbsolute(path string) string {
 	path = filepath.Clean(path)
 
+	if path == "." {
+		return "/"
+	}
+
 	if path != "/" && path[0] != '/' {
 		return "/" + path
 	}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"maps"
 	"os"
@@ -16,7 +17,6 @@ import (
 	"time"
 
 	"github.com/go-git/go-billy/v5"
-	"github.com/go-git/go-billy/v5/util"
 	"github.com/inoxlang/inox/internal/afs"
 	"github.com/inoxlang/inox/internal/buntdb"
 	"github.com/inoxlang/inox/internal/commonfmt"
@@ -34,18 +34,24 @@ const (
 	METAFS_MODIF_TIME_PROPNAME      = "modification-time"
 	METAFS_SYMLINK_TARGET_PROPNAME  = "symlink-target"
 	METAFS_CHILDREN_PROPNAME        = "children"
+	METAFS_COMPRESSED_PROPNAME      = "compressed"
 
 	METAFS_UNDERLYING_UNDERLYING_FILE_PERM = 0600
 	METAFS_AUTO_CREATED_DIR_PERM           = fs.FileMode(0700)
 
-	METAFS_FILES_KEY   = "/files"
-	METAFS_KV_FILENAME = "metadata.kv"
+	METAFS_FILES_KEY                        = "/files"
+	METAFS_CONCRETE_FILE_REFCOUNTS_KEY      = "/concrete-file-refcounts"
+	METAFS_CONCRETE_FILE_ORIGINAL_SIZES_KEY = "/concrete-file-original-sizes"
+	METAFS_KV_FILENAME                      = "metadata.kv"
 
 	METAFS_MIN_USABLE_SPACE                             = 10_000_000
 	METAFS_USED_SPACE_CHECK_INTERVAL                    = time.Second / 2
 	METAFS_ALWAYS_CHECK_USED_SPACE_BYTE_COUNT_THRESHOLD = 100_000
+	METAFS_AVAILABLE_SPACE_CHECK_INTERVAL               = time.Second / 2
 	METAFS_DEFAULT_MAX_FILE_COUNT                       = 1000
 	METAFS_DEFAULT_MAX_PARALLEL_FILE_CREATION_COUNT     = 10
+	METAFS_DEFAULT_MAX_EVENT_QUEUE_LENGTH               = 10_000
+	METAFS_REMOVE_ALL_BATCH_SIZE                        = 100
 
 	METAFS_MAX_SNAPSHOTABLE_SIZE                 = core.ByteCount(100_000_000)
 	METAFS_DEFAULT_MAX_UNTRACK_CLOSED_FILE_COUNT = 10
@@ -55,25 +61,41 @@ var (
 	REQUIRED_METAFS_FILE_METADATA_PROPNAMES = []string{METAFS_FILE_MODE_PROPNAME, METAFS_CREATION_TIME_PROPNAME, METAFS_MODIF_TIME_PROPNAME}
 
 	_ = core.SnapshotableFilesystem((*MetaFilesystem)(nil))
+
+	//directories used by the meta filesystems open in the current process, a directory cannot be used by two meta filesystems
+	//at the same time.
+	metaFsDirsInUse     = map[metaFsDirKey]struct{}{}
+	metaFsDirsInUseLock sync.Mutex
 )
 
+type metaFsDirKey struct {
+	underlying billy.Basic
+	dir        string
+}
+
 // MetaFilesystem is a filesystem that works on top of another filesystem, it stores its metadata in a file and file contents
 // in regular files.
 type MetaFilesystem struct {
 	maxUsableSpace           core.ByteCount //maximum space usable in the underyling filesystem
 	maxFileCount             int32          //maximum number of files stored by MetaFilesystem in the underyling filesystem
 	maxParallelCreationCount int32
+	maxOpenFiles             int //no limit if <= 0
 
 	//underlying afs.Filesystem
 	underlying billy.Basic
 	dir        *string //optional, if set underlying is an afs.Filesytem
+	dirKey     metaFsDirKey
 	openFiles  map[ /*normalized path*/ string]map[*metaFsFile]struct{}
 
+	//locks shared by the files opened in append mode, an append is performed while holding the lock of its file
+	//in order for concurrent appends to not overwrite each other. The map is protected by fls.lock.
+	appendLocks map[ /*normalized path*/ string]*sync.Mutex
+
 	// last modification times of non-dir files
 	lastModificationTimes     map[ /*normalized path*/ string]core.DateTime
 	lastModificationTimesLock sync.RWMutex
 
-	eventQueue     *memds.TSArrayQueue[Event] //periodically emptied
+	eventQueue     *memds.TSArrayQueue[Event] //periodically emptied, the oldest events are dropped if the queue is full.
 	fsWatchers     []*VirtualFilesystemWatcher
 	fsWatchersLock sync.Mutex
 
@@ -89,8 +111,36 @@ type MetaFilesystem struct {
 
 	usedSpaceCache     core.ByteCount
 	usedSpaceCacheLock sync.RWMutex
-	lastSpaceCheckTime atomic.Int64 //unix milli (the millisecond precision is required)
+	reservedSpace      core.ByteCount //total remaining space of the reservations (see ReserveSpace), guarded by usedSpaceCacheLock
+	lastSpaceCheckTime atomic.Int64   //unix milli (the millisecond precision is required)
+
+	//space available in the underlying storage, only used if the underlying filesystem implements afs.AvailableSpaceCapable.
+	availableSpaceCache         core.ByteCount
+	availableSpaceCacheLock     sync.Mutex
+	lastAvailableSpaceCheckTime time.Time
+
+	//metadata writes performed without an explicit transaction are batched if the window is not zero.
+	metadataWriteBatchingWindow time.Duration
+	pendingMetadataWrites       map[ /*KV key*/ string]pendingMetadataWrite
+	pendingMetadataWritesLock   sync.Mutex
+	metadataFlushTimer          *time.Timer //nil if no flush is scheduled
+
+	concreteNameFunc func(path core.Path) string
+
+	evictFilesWhenFull bool
+	compress           bool
+	enforcePermissions bool
+
+	mirror *metaFsMirror //nil if writes and removals are not mirrored
+
+	//decompressed contents of the compressed files that are open, see compressedFileContent.
+	compressedContents     map[ /*concrete file*/ core.Path]*compressedFileContent
+	compressedContentsLock sync.Mutex
+
+	metrics MetaFilesystemMetrics //can be nil
 
+	contentCache *metaFsContentCache //nil if the content cache is disabled
+	statCache    *metaFsStatCache    //nil if the stat cache is disabled
 }
 
 type MetaFilesystemParams struct {
@@ -106,8 +156,70 @@ type MetaFilesystemParams struct {
 
 	//The value defaults to METAFS_DEFAULT_MAX_PARALLEL_FILE_CREATION_COUNT, ignored if dir is false.
 	MaxParallelCreationCount int16
+
+	//Maximum number of events in the event queue, the oldest events are dropped when the queue is full.
+	//The value defaults to METAFS_DEFAULT_MAX_EVENT_QUEUE_LENGTH.
+	MaxEventQueueLength int
+
+	//If greater than zero the metadata writes that are not part of a larger operation (e.g. a rename) are coalesced:
+	//they are committed in a single transaction at most MetadataWriteBatchingWindow after the first one,
+	//or when (*MetaFilesystem).Sync or SyncAll is called. Batching is disabled by default.
+	MetadataWriteBatchingWindow time.Duration
+
+	//ConcreteNameFunc returns the name of the concrete (underlying) file of a file being created,
+	//the name should be unique and should not contain path separators. ULID-based names are used by default.
+	//A custom function is mostly useful for tests and debugging. Atomic writes (see WriteFileAtomic) add a
+	//ULID suffix to the name if a concrete file with the same name already exists.
+	ConcreteNameFunc func(path core.Path) string
+
+	//If true, creating a file while the maximum number of files is reached evicts (removes) the least recently modified
+	//files instead of failing, this is mostly useful for cache-like filesystems. Directories and open files are never evicted.
+	EvictLeastRecentlyModifiedFiles bool
+
+	//If true, the contents of the files created by the filesystem are gzip-compressed in their concrete files and
+	//transparently decompressed when read. The content of a compressed file is held in memory while the file is open,
+	//and it is compressed and written when the file is synced or closed. The used space is the compressed size.
+	Compress bool
+
+	//Maximum number of files that can be open at the same time, opening a file beyond the limit fails with ErrTooManyOpenFiles.
+	//Only the files that are not closed are counted. There is no limit by default.
+	MaxOpenFiles int
+
+	//If set, the writes and removals of files are asynchronously mirrored to this storage (e.g. for live backups):
+	//the content of a modified file is copied to the mirror when the file is synced or closed, and removed files are
+	//removed from the mirror. A rename is mirrored as the removal of the previous path followed by the write of the
+	//moved files, a content link is mirrored as a write. The creation of empty directories is not mirrored. The pending
+	//operations are applied before the filesystem is closed.
+	Mirror billy.Basic
+
+	//Called when a mirrored operation fails, mirroring errors never make the operations on the filesystem fail.
+	//The errors are logged if the function is not set.
+	OnMirrorError func(path core.Path, err error)
+
+	//If set, the creation, opening, reading, writing and deletion of files as well as the snapshots are recorded,
+	//see MetaFilesystemMetrics.
+	Metrics MetaFilesystemMetrics
+
+	//Maximum total size of the file contents cached in memory, the cache speeds up repeated reads of small files
+	//(e.g. configuration files): files opened in read-only mode are served from memory. A cached content is invalidated
+	//when the file is modified or removed. The cache is disabled by default (zero).
+	ContentCacheSize core.ByteCount
+
+	//Maximum number of file infos cached by Stat, the cache avoids reading the metadata of a file each time it is
+	//statted. A cached info is ignored once the modification time of the file changes. The cache is disabled by
+	//default (zero).
+	StatCacheSize int
+
+	//If true opening an existing file for writing fails with os.ErrPermission if the mode of the file lacks the
+	//owner-write bit, as on POSIX systems. A file created by the opening is always writable through the returned file.
+	//The modes are not enforced by default.
+	EnforcePermissions bool
 }
 
+// OpenMetaFilesystem opens or creates a meta filesystem storing its files in opts.Dir. A directory cannot be used by two
+// open meta filesystems at the same time: ErrFilesystemDirAlreadyInUse is returned if the directory is used by another meta
+// filesystem, the directory is released when the filesystem is closed. Only the meta filesystems open in the current
+// process are detected: nothing prevents another process from using the same directory.
 func OpenMetaFilesystem(ctx *core.Context, underlying billy.Basic, opts MetaFilesystemParams) (*MetaFilesystem, error) {
 	if opts.MaxUsableSpace > 0 && opts.MaxUsableSpace < METAFS_MIN_USABLE_SPACE {
 		return nil, ErrMaxUsableSpaceTooSmall
@@ -125,6 +237,11 @@ func OpenMetaFilesystem(ctx *core.Context, underlying billy.Basic, opts MetaFile
 		maxParallelCreationCount = METAFS_DEFAULT_MAX_PARALLEL_FILE_CREATION_COUNT
 	}
 
+	maxEventQueueLength := opts.MaxEventQueueLength
+	if maxEventQueueLength <= 0 {
+		maxEventQueueLength = METAFS_DEFAULT_MAX_EVENT_QUEUE_LENGTH
+	}
+
 	var buntDBPath string
 
 	if opts.Dir != "" {
@@ -142,25 +259,63 @@ func OpenMetaFilesystem(ctx *core.Context, underlying billy.Basic, opts MetaFile
 		buntDBPath = "/" + METAFS_KV_FILENAME
 	}
 
+	//make sure the directory is not used by another meta filesystem.
+	dirKey := metaFsDirKey{underlying: underlying, dir: filepath.Dir(buntDBPath)}
+
+	metaFsDirsInUseLock.Lock()
+	if _, ok := metaFsDirsInUse[dirKey]; ok {
+		metaFsDirsInUseLock.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrFilesystemDirAlreadyInUse, dirKey.dir)
+	}
+	metaFsDirsInUse[dirKey] = struct{}{}
+	metaFsDirsInUseLock.Unlock()
+
 	kv, err := buntdb.OpenBuntDBNoPermCheck(buntDBPath, underlying)
 
 	if err != nil {
+		releaseMetaFsDir(dirKey)
 		return nil, fmt.Errorf("failed to open/create single-file KV store for storing metadata of meta filesystem: %w", err)
 	}
 
+	opened := false
+	defer func() {
+		if !opened {
+			kv.Close()
+			releaseMetaFsDir(dirKey)
+		}
+	}()
+
 	fls := &MetaFilesystem{
 		ctx:                   ctx,
 		underlying:            underlying,
+		dirKey:                dirKey,
 		openFiles:             map[string]map[*metaFsFile]struct{}{},
+		appendLocks:           map[string]*sync.Mutex{},
+		compressedContents:    map[core.Path]*compressedFileContent{},
 		lastModificationTimes: map[string]core.DateTime{},
 		eventQueue: memds.NewTSArrayQueueWithConfig(memds.TSArrayQueueConfig[Event]{
 			AutoRemoveCondition: isOldEvent,
+			MaxSize:             maxEventQueueLength,
 		}),
 
 		metadata:                 kv,
 		maxUsableSpace:           maxUsableSpace,
 		maxFileCount:             maxFileCount,
 		maxParallelCreationCount: int32(maxParallelCreationCount),
+		maxOpenFiles:             opts.MaxOpenFiles,
+
+		metadataWriteBatchingWindow: max(opts.MetadataWriteBatchingWindow, 0),
+		pendingMetadataWrites:       map[string]pendingMetadataWrite{},
+
+		concreteNameFunc:   opts.ConcreteNameFunc,
+		evictFilesWhenFull: opts.EvictLeastRecentlyModifiedFiles,
+		compress:           opts.Compress,
+		enforcePermissions: opts.EnforcePermissions,
+		metrics:            opts.Metrics,
+	}
+
+	if fls.concreteNameFunc == nil {
+		fls.concreteNameFunc = makeULIDConcreteName
 	}
 
 	dir := opts.Dir
@@ -199,12 +354,9 @@ func OpenMetaFilesystem(ctx *core.Context, underlying billy.Basic, opts MetaFile
 		return nil, fmt.Errorf("failed to check used space: %w", err)
 	}
 
-	ctx.OnGracefulTearDown(func(ctx *core.Context) error {
-		return fls.Close(ctx)
-	})
-
-	// update modification time of files
-	err = fls.Walk(func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error {
+	// update modification time of files, the context is not checked because OpenMetaFilesystem may be called
+	// by ContextConfig.CreateFilesystem with a context that is not initialized yet.
+	err = fls.Walk(nil, func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error {
 		if metadata.mode.IsDir() {
 			return nil
 		}
@@ -225,9 +377,32 @@ func OpenMetaFilesystem(ctx *core.Context, underlying billy.Basic, opts MetaFile
 		return nil, fmt.Errorf("failed to update modification times during opening of meta filesystem: %w", err)
 	}
 
+	if opts.Mirror != nil {
+		fls.mirror = newMetaFsMirror(fls, opts.Mirror, opts.OnMirrorError)
+	}
+
+	if opts.ContentCacheSize > 0 {
+		fls.contentCache = newMetaFsContentCache(opts.ContentCacheSize)
+	}
+
+	if opts.StatCacheSize > 0 {
+		fls.statCache = newMetaFsStatCache(opts.StatCacheSize)
+	}
+
+	ctx.OnGracefulTearDown(func(ctx *core.Context) error {
+		return fls.Close(ctx)
+	})
+
+	opened = true
 	return fls, nil
 }
 
+func releaseMetaFsDir(key metaFsDirKey) {
+	metaFsDirsInUseLock.Lock()
+	defer metaFsDirsInUseLock.Unlock()
+	delete(metaFsDirsInUse, key)
+}
+
 func (fls *MetaFilesystem) Close(ctx *core.Context) error {
 	if !fls.closed.CompareAndSwap(false, true) {
 		return nil
@@ -268,8 +443,146 @@ func (fls *MetaFilesystem) Close(ctx *core.Context) error {
 		}
 	}
 
+	//commit the pending metadata writes
+	fls.lock.Lock()
+	flushErr := fls.flushPendingMetadataWrites()
+	fls.lock.Unlock()
+
+	//apply the pending mirrored operations, this includes the writes of the files closed above.
+	if fls.mirror != nil {
+		fls.mirror.close()
+	}
+
 	//close the key-value store
-	return fls.metadata.Close()
+	closeErr := fls.metadata.Close()
+
+	//allow the directory to be used by another meta filesystem.
+	releaseMetaFsDir(fls.dirKey)
+
+	return errors.Join(flushErr, closeErr)
+}
+
+// DroppedEventCount returns the number of events that have been dropped because the event queue was full,
+// watchers can use this count to detect gaps in the events they received.
+func (fls *MetaFilesystem) DroppedEventCount() int64 {
+	return fls.eventQueue.DroppedCount()
+}
+
+// DirectorySizes returns a map from the normalized path of each directory to the total size of the files it directly contains.
+// If recursive is true the sizes of the files in all descendant directories are also included. The content of files is not read:
+// the sizes are the ones reported by Stat, so the original (decompressed) size is used for compressed files.
+func (fls *MetaFilesystem) DirectorySizes(recursive bool) (map[string]core.ByteCount, error) {
+	if fls.closed.Load() {
+		return nil, ErrClosedFilesystem
+	}
+
+	fls.lock.RLock()
+	defer fls.lock.RUnlock()
+
+	sizes := map[string]core.ByteCount{}
+
+	err := fls.Walk(nil, func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error {
+		if metadata.mode.IsDir() {
+			if _, ok := sizes[normalizedPath]; !ok {
+				sizes[normalizedPath] = 0
+			}
+			return nil
+		}
+
+		if metadata.concreteFile == nil {
+			return nil
+		}
+
+		var size core.ByteCount
+
+		if metadata.compressed {
+			size = metadata.originalSize
+		} else {
+			stat, err := fls.underlying.Stat(metadata.concreteFile.UnderlyingString())
+			if err != nil {
+				return fmt.Errorf("failed to get stat of %s", normalizedPath)
+			}
+			size = core.ByteCount(stat.Size())
+		}
+
+		dir := filepath.Dir(normalizedPath)
+		sizes[dir] += size
+
+		if recursive {
+			for dir != "/" {
+				dir = filepath.Dir(dir)
+				sizes[dir] += size
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return sizes, nil
+}
+
+// Chtimes sets the modification time of a file or directory, the access time is ignored because it is not tracked.
+func (fls *MetaFilesystem) Chtimes(path string, atime time.Time, mtime time.Time) error {
+	return fls.chtimes(path, mtime, nil)
+}
+
+// ChtimesWithCreationTime is like Chtimes but it also sets the creation time.
+func (fls *MetaFilesystem) ChtimesWithCreationTime(path string, atime time.Time, mtime time.Time, ctime time.Time) error {
+	return fls.chtimes(path, mtime, &ctime)
+}
+
+func (fls *MetaFilesystem) chtimes(path string, mtime time.Time, ctime *time.Time) error {
+	if fls.closed.Load() {
+		return ErrClosedFilesystem
+	}
+
+	fls.lock.Lock()
+	defer fls.lock.Unlock()
+
+	normalizedPath := NormalizeAsAbsolute(path)
+	pth := core.PathFrom(normalizedPath)
+
+	tx, err := fls.beginMetadataTx(true)
+	if err != nil {
+		return err
+	}
+
+	metadata, exists, err := fls.getFileMetadata(pth, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if !exists {
+		tx.Rollback()
+		return fmt.Errorf("%w: %s", os.ErrNotExist, normalizedPath)
+	}
+
+	metadata.modificationTime = core.DateTime(mtime)
+	if ctime != nil {
+		metadata.creationTime = core.DateTime(*ctime)
+	}
+
+	err = fls.setFileMetadata(metadata, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	fls.lastModificationTimesLock.Lock()
+	defer fls.lastModificationTimesLock.Unlock()
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	//the last modification time of a file has precedence over the time stored in the metadata.
+	fls.lastModificationTimes[normalizedPath] = metadata.modificationTime
+	return nil
 }
 
 func (fls *MetaFilesystem) Chroot(path string) (billy.Filesystem, error) {
@@ -322,6 +635,24 @@ func (fls *MetaFilesystem) getFileMetadata(pth core.Path, usedTx *buntdb.Tx) (*m
 	metadata := metaFsFileMetadata{path: pth}
 
 	if usedTx == nil {
+		if write, ok := fls.getPendingMetadataWrite(key.UnderlyingString()); ok {
+			//the write has not been committed yet.
+			if write.deletion {
+				return nil, false, nil
+			}
+			err = metadata.initFromJSON(write.serializedMetadata, hasLastModifTime, lastModificationTime)
+			if err != nil {
+				return nil, false, err
+			}
+			if metadata.compressed {
+				metadata.originalSize, err = fls.getConcreteFileOriginalSize(*metadata.concreteFile, nil)
+				if err != nil {
+					return nil, false, err
+				}
+			}
+			return &metadata, true, nil
+		}
+
 		//create a temporary transaction
 		usedTx, err = fls.metadata.Begin(false)
 		if err != nil {
@@ -347,6 +678,13 @@ func (fls *MetaFilesystem) getFileMetadata(pth core.Path, usedTx *buntdb.Tx) (*m
 		return nil, false, err
 	}
 
+	if metadata.compressed {
+		metadata.originalSize, err = fls.getConcreteFileOriginalSize(*metadata.concreteFile, usedTx)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
 	return &metadata, true, nil
 }
 
@@ -355,9 +693,16 @@ func (fls *MetaFilesystem) setFileMetadata(metadata *metaFsFileMetadata, tx *bun
 		return errors.New("file's path should be absolute")
 	}
 
+	fls.invalidateCachedStat(metadata.path)
+
 	json := metadata.marshalJSON()
 	key := getKvKeyFromPath(metadata.path)
 
+	if tx == nil && fls.isMetadataWriteBatchingEnabled() {
+		fls.addPendingMetadataWrite(string(key), pendingMetadataWrite{serializedMetadata: json})
+		return nil
+	}
+
 	var noIssue bool
 	if tx == nil {
 		//create a temporary transaction
@@ -380,8 +725,15 @@ func (fls *MetaFilesystem) setFileMetadata(metadata *metaFsFileMetadata, tx *bun
 }
 
 func (fls *MetaFilesystem) deleteFileMetadata(pth core.Path, tx *buntdb.Tx) error {
+	fls.invalidateCachedStat(pth)
+
 	key := getKvKeyFromPath(pth)
 
+	if tx == nil && fls.isMetadataWriteBatchingEnabled() {
+		fls.addPendingMetadataWrite(string(key), pendingMetadataWrite{deletion: true})
+		return nil
+	}
+
 	var noIssue bool
 	if tx == nil {
 		//create a temporary transaction
@@ -404,11 +756,21 @@ func (fls *MetaFilesystem) deleteFileMetadata(pth core.Path, tx *buntdb.Tx) erro
 	return nil
 }
 
-func (fls *MetaFilesystem) Walk(visit func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error) error {
-	return fls.walk("/", visit)
+// Walk walks the file tree in lexical order and calls visit for each file and directory, walking stops at the first
+// error returned by visit or when ctx is done. If ctx is nil the walk is not cancellable.
+func (fls *MetaFilesystem) Walk(ctx *core.Context, visit func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error) error {
+	return fls.walk(ctx, "/", visit)
 }
 
-func (fls *MetaFilesystem) walk(path core.Path, visit func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error) error {
+func (fls *MetaFilesystem) walk(ctx *core.Context, path core.Path, visit func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error) error {
+	if ctx != nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+	}
+
 	meta, _, err := fls.getFileMetadata(path, nil)
 	if err != nil {
 		return err
@@ -429,7 +791,7 @@ func (fls *MetaFilesystem) walk(path core.Path, visit func(normalizedPath string
 
 		for _, childName := range childrenNames {
 			childPath := path.JoinEntry(string(childName))
-			if err := fls.walk(childPath, visit); err != nil {
+			if err := fls.walk(ctx, childPath, visit); err != nil {
 				return fmt.Errorf("%q: %w", childPath, err)
 			}
 		}
@@ -438,7 +800,36 @@ func (fls *MetaFilesystem) walk(path core.Path, visit func(normalizedPath string
 	return nil
 }
 
-func (fls *MetaFilesystem) TakeFilesystemSnapshot(config core.FilesystemSnapshotConfig) (core.FilesystemSnapshot, error) {
+// EachFile walks the file tree in lexical order and calls fn for each file and directory, unlike TakeFilesystemSnapshot
+// the contents are not read: open opens the content of the current file on demand and returns an error for directories.
+// The filesystem is not locked during the walk, so the files created or removed concurrently may or may not be visited.
+// Walking stops at the first error returned by fn or when ctx is done.
+func (fls *MetaFilesystem) EachFile(ctx *core.Context, fn func(path core.Path, metadata *metaFsFileMetadata, open func() (io.ReadCloser, error)) error) error {
+	if fls.closed.Load() {
+		return ErrClosedFilesystem
+	}
+
+	return fls.walk(ctx, "/", func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error {
+		open := func() (io.ReadCloser, error) {
+			if metadata.mode.IsDir() {
+				return nil, fmt.Errorf("%w: %s", ErrCannotOpenDir, normalizedPath)
+			}
+			if metadata.concreteFile == nil {
+				return nil, fmt.Errorf("%s has no content", normalizedPath)
+			}
+			if fls.closed.Load() {
+				return nil, ErrClosedFilesystem
+			}
+			return fls.openConcreteFileContent(metadata)
+		}
+
+		return fn(path, metadata, open)
+	})
+}
+
+func (fls *MetaFilesystem) TakeFilesystemSnapshot(config core.FilesystemSnapshotConfig) (_ core.FilesystemSnapshot, finalErr error) {
+	defer fls.startOp(METAFS_SNAPSHOT_OP)(&finalErr)
+
 	if !fls.snapshoting.CompareAndSwap(false, true) {
 		return nil, core.ErrAlreadyBeingSnapshoted
 	}
@@ -465,6 +856,11 @@ func (fls *MetaFilesystem) TakeFilesystemSnapshot(config core.FilesystemSnapshot
 			errors.New("for now snapshoting is only supported when the underlying filesystem is the OS filesystem or a memory filesystem")
 	}
 
+	ctx := config.Context
+	if ctx == nil {
+		ctx = fls.ctx
+	}
+
 	snapshot := &InMemorySnapshot{
 		MetadataMap:  make(map[string]*core.EntrySnapshotMetadata),
 		FileContents: make(map[string]core.AddressableContent),
@@ -474,6 +870,17 @@ func (fls *MetaFilesystem) TakeFilesystemSnapshot(config core.FilesystemSnapshot
 	defer fls.lock.Unlock()
 	fls.untrackSomeClosedFiles(100)
 
+	//total size of the file contents added to the snapshot.
+	var totalSize core.ByteCount
+
+	addToTotalSize := func(size core.ByteCount) error {
+		totalSize += size
+		if config.MaxTotalSize > 0 && totalSize > config.MaxTotalSize {
+			return makeSnapshotMaxTotalSizeExceededError(config.MaxTotalSize)
+		}
+		return nil
+	}
+
 	//files being written to.
 	var writableFiles []*metaFsFile
 	writableFilePaths := map[string]struct{}{}
@@ -504,14 +911,17 @@ top:
 	//add writable files to the snapshot
 	for _, file := range writableFiles {
 		normalizedPath := NormalizeAsAbsolute(file.metadata.path.UnderlyingString())
-		concreteFilePath := file.metadata.concreteFile.UnderlyingString()
 
-		file.underlying.Sync()
+		file.syncUnderlyingWhileFsLocked()
 
-		content, err := util.ReadFile(fls.underlying, concreteFilePath)
+		content, err := fls.readConcreteFileContent(file.metadata)
 		if err != nil {
 			return nil, err
 		}
+
+		if err := addToTotalSize(core.ByteCount(len(content))); err != nil {
+			return nil, err
+		}
 		checkSum := sha256.Sum256(content)
 
 		//add the file's content and metadata to the snapshot
@@ -535,7 +945,7 @@ top:
 	maps.Copy(includableFiles, writableFilePaths)
 
 	// determine what remaining files are includable
-	fls.Walk(func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error {
+	err = fls.Walk(ctx, func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error {
 		if !config.IsFileIncluded(path) {
 			return nil
 		}
@@ -544,6 +954,10 @@ top:
 		return nil
 	})
 
+	if err != nil {
+		return nil, err
+	}
+
 	// add directory hierarchy of includable files
 	for includable := range includableFiles {
 		for i := 1; i < len(includable); i++ {
@@ -554,7 +968,7 @@ top:
 	}
 
 	//add other files to the snapshot
-	err = fls.Walk(func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error {
+	err = fls.Walk(ctx, func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error {
 		if _, ok := writableFilePaths[normalizedPath]; ok {
 			//already in the snapshot
 			return nil
@@ -567,11 +981,14 @@ top:
 		var checksum [32]byte
 
 		if !metadata.mode.IsDir() {
-			concreteFilePath := metadata.concreteFile.UnderlyingString()
-			content, err = util.ReadFile(fls.underlying, concreteFilePath)
+			content, err = fls.readConcreteFileContent(metadata)
 			if err != nil {
 				return err
 			}
+
+			if err := addToTotalSize(core.ByteCount(len(content))); err != nil {
+				return err
+			}
 			checksum = sha256.Sum256(content)
 		}
 
@@ -638,6 +1055,30 @@ func (fls *MetaFilesystem) untrackSomeClosedFiles(maxRemovalCount int) {
 	}
 }
 
+// checkOpenFileCount returns ErrTooManyOpenFiles if the maximum number of open files is reached,
+// closed files are untracked before the open files are counted. fls.lock should be held.
+func (fls *MetaFilesystem) checkOpenFileCount() error {
+	if fls.maxOpenFiles <= 0 {
+		return nil
+	}
+
+	fls.untrackSomeClosedFiles(-1)
+
+	openFileCount := 0
+	for _, files := range fls.openFiles {
+		for sameFile := range files {
+			if !sameFile.closed.Load() {
+				openFileCount++
+			}
+		}
+	}
+
+	if openFileCount >= fls.maxOpenFiles {
+		return ErrTooManyOpenFiles
+	}
+	return nil
+}
+
 func (fls *MetaFilesystem) getUnderlyingFileCount() (int32, error) {
 	if fls.dir == nil {
 		//TODO: iterate over files and call Stat()
@@ -726,6 +1167,10 @@ func (fls *MetaFilesystem) computeFreeSpace(useCache bool, add ...core.ByteCount
 		return 0, err
 	}
 
+	fls.usedSpaceCacheLock.RLock()
+	usedSpace += fls.reservedSpace
+	fls.usedSpaceCacheLock.RUnlock()
+
 	if usedSpace > fls.maxUsableSpace {
 		return 0, nil
 	}
@@ -733,7 +1178,18 @@ func (fls *MetaFilesystem) computeFreeSpace(useCache bool, add ...core.ByteCount
 	return fls.maxUsableSpace - usedSpace, nil
 }
 
-func (fls *MetaFilesystem) checkAddedByteCount(size core.ByteCount) (bool, error) {
+// releaseAddedByteCount updates the used space cache after a write that added less bytes than the count passed to
+// checkAddedByteCount.
+func (fls *MetaFilesystem) releaseAddedByteCount(size core.ByteCount) {
+	fls.usedSpaceCacheLock.Lock()
+	defer fls.usedSpaceCacheLock.Unlock()
+
+	fls.usedSpaceCache = max(0, fls.usedSpaceCache-size)
+}
+
+// checkAddedByteCount checks that size bytes can be added, reservation is the reservation the write is performed
+// through (can be nil): the part of size available in the reservation is consumed instead of the unreserved space.
+func (fls *MetaFilesystem) checkAddedByteCount(size core.ByteCount, reservation *SpaceReservation) (bool, error) {
 	// WIP
 
 	freeSpace, err := fls.computeFreeSpace(size < METAFS_ALWAYS_CHECK_USED_SPACE_BYTE_COUNT_THRESHOLD, size)
@@ -750,29 +1206,99 @@ func (fls *MetaFilesystem) checkAddedByteCount(size core.ByteCount) (bool, error
 		return false, nil
 	}
 
-	return freeSpace >= size, nil
+	//the part of size taken from the reservation has already been counted by ReserveSpace.
+	if freeSpace < size-min(size, reservation.available()) {
+		return false, nil
+	}
+
+	reservation.consume(size)
+	return true, nil
+}
+
+// checkUnderlyingAvailableSpace returns ErrUnderlyingStorageFull if the storage of the underlying filesystem has not enough
+// space to write size bytes. The check is only performed if the underlying filesystem implements afs.AvailableSpaceCapable,
+// the available space is cached for METAFS_AVAILABLE_SPACE_CHECK_INTERVAL unless size is large.
+func (fls *MetaFilesystem) checkUnderlyingAvailableSpace(size core.ByteCount) error {
+	underlying, ok := fls.underlying.(afs.AvailableSpaceCapable)
+	if !ok {
+		return nil
+	}
+
+	fls.availableSpaceCacheLock.Lock()
+	defer fls.availableSpaceCacheLock.Unlock()
+
+	useCache := size < METAFS_ALWAYS_CHECK_USED_SPACE_BYTE_COUNT_THRESHOLD &&
+		time.Since(fls.lastAvailableSpaceCheckTime) < METAFS_AVAILABLE_SPACE_CHECK_INTERVAL
+
+	if !useCache {
+		dir := "/"
+		if fls.dir != nil {
+			dir = *fls.dir
+		}
+
+		availableSpace, err := underlying.AvailableSpace(dir)
+		if err != nil {
+			return fmt.Errorf("failed to get the space available in the underlying storage: %w", err)
+		}
+		fls.availableSpaceCache = core.ByteCount(availableSpace)
+		fls.lastAvailableSpaceCheckTime = time.Now()
+	}
+
+	if fls.availableSpaceCache < size {
+		return ErrUnderlyingStorageFull
+	}
+
+	//the cached value is updated in order for successive writes to be taken into account.
+	fls.availableSpaceCache -= size
+	return nil
 }
 
 func (fls *MetaFilesystem) Create(filename string) (billy.File, error) {
+	return fls.create(filename, nil)
+}
+
+func (fls *MetaFilesystem) create(filename string, reservation *SpaceReservation) (_ billy.File, finalErr error) {
+	defer fls.startOp(METAFS_CREATE_OP)(&finalErr)
 	defer fls.pendingFileCreations.Add(-1)
 
 	if fls.pendingFileCreations.Add(1) > fls.maxParallelCreationCount {
 		return nil, ErrTooManyParallelFileCreation
 	}
 
+	if err := fls.checkFileCountBeforeCreation(); err != nil {
+		return nil, err
+	}
+
+	return fls.openFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, afs.DEFAULT_CREATE_FPERM, reservation)
+}
+
+// checkFileCountBeforeCreation returns ErrMaxFileNumberAlreadyReached if creating a concrete file would exceed the
+// maximum number of files, files are evicted instead if EvictLeastRecentlyModifiedFiles is enabled. The caller should
+// have incremented fls.pendingFileCreations.
+func (fls *MetaFilesystem) checkFileCountBeforeCreation() error {
 	//properly taking into account files being deleted is not trivial,
 	//especially since we know nothing about the underyling file system.
 
 	count, err := fls.getUnderlyingFileCount()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if count+fls.pendingFileCreations.Load() > int32(fls.maxFileCount) {
-		return nil, ErrMaxFileNumberAlreadyReached
+	if excess := count + fls.pendingFileCreations.Load() - int32(fls.maxFileCount); excess > 0 {
+		if !fls.evictFilesWhenFull {
+			return ErrMaxFileNumberAlreadyReached
+		}
+
+		removedCount, err := fls.evictLeastRecentlyModifiedFiles(int(excess))
+		if err != nil {
+			return err
+		}
+		if removedCount < int(excess) {
+			return ErrMaxFileNumberAlreadyReached
+		}
 	}
 
-	return fls.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, afs.DEFAULT_CREATE_FPERM)
+	return nil
 }
 
 func (fls *MetaFilesystem) Open(filename string) (billy.File, error) {
@@ -780,6 +1306,21 @@ func (fls *MetaFilesystem) Open(filename string) (billy.File, error) {
 }
 
 func (fls *MetaFilesystem) OpenFile(filename string, flag int, perm os.FileMode) (billy.File, error) {
+	return fls.openFileWithReservation(filename, flag, perm, nil)
+}
+
+func (fls *MetaFilesystem) openFileWithReservation(filename string, flag int, perm os.FileMode, reservation *SpaceReservation) (_ billy.File, finalErr error) {
+	if IsCreate(flag) {
+		defer fls.startOp(METAFS_CREATE_OP)(&finalErr)
+	} else {
+		defer fls.startOp(METAFS_OPEN_OP)(&finalErr)
+	}
+
+	return fls.openFile(filename, flag, perm, reservation)
+}
+
+// openFile opens a file, the writes to the file consume the space of reservation if it is not nil.
+func (fls *MetaFilesystem) openFile(filename string, flag int, perm os.FileMode, reservation *SpaceReservation) (billy.File, error) {
 	if fls.closed.Load() {
 		return nil, ErrClosedFilesystem
 	}
@@ -792,11 +1333,35 @@ func (fls *MetaFilesystem) OpenFile(filename string, flag int, perm os.FileMode)
 		}
 	}()
 
+	if err := fls.checkOpenFileCount(); err != nil {
+		return nil, err
+	}
+
 	originalPath := filename
 	filename = NormalizeAsAbsolute(filename)
 
 	pth := core.PathFrom(filename)
-	metadata, exists, err := fls.getFileMetadata(pth, nil)
+
+	var tx *buntdb.Tx
+	txClosed := false
+
+	//create a read-write transaction in order to check the existence of the file
+	//and create it atomically. If batching is enabled the metadata writes are batched instead,
+	//holding fls.lock is enough to make the check and the creation atomic.
+	if IsCreate(flag) && !fls.isMetadataWriteBatchingEnabled() {
+		var err error
+		tx, err = fls.beginMetadataTx(true)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if !txClosed {
+				tx.Rollback()
+			}
+		}()
+	}
+
+	metadata, exists, err := fls.getFileMetadata(pth, tx)
 	if err != nil {
 		return nil, err
 	}
@@ -810,24 +1375,6 @@ func (fls *MetaFilesystem) OpenFile(filename string, flag int, perm os.FileMode)
 
 		//create file
 
-		//create a read-write transaction
-		tx, err := fls.metadata.Begin(true)
-		if err != nil {
-			return nil, err
-		}
-		txCommited := false
-		defer func() {
-			if !txCommited {
-				tx.Rollback()
-			}
-		}()
-
-		//return an error if the file has been created in the meantime
-		_, exists, _ := fls.getFileMetadata(pth, tx)
-		if exists {
-			return nil, errors.New("file was created in the meantime")
-		}
-
 		dir := filepath.Dir(filename)
 		if dir != "/" {
 			//make sure parent exists
@@ -854,12 +1401,9 @@ func (fls *MetaFilesystem) OpenFile(filename string, flag int, perm os.FileMode)
 		}
 
 		//create & store metadata for new file
-		var underlyingFilePath core.Path
-
-		if fls.dir != nil {
-			underlyingFilePath = core.Path(fls.underlying.Join(*fls.dir, ulid.Make().String()))
-		} else {
-			underlyingFilePath = core.Path(NormalizeAsAbsolute(ulid.Make().String()))
+		underlyingFilePath, err := fls.makeConcreteFilePath(pth)
+		if err != nil {
+			return nil, err
 		}
 
 		creationTime := core.DateTime(time.Now())
@@ -872,6 +1416,7 @@ func (fls *MetaFilesystem) OpenFile(filename string, flag int, perm os.FileMode)
 			mode:             mode,
 			creationTime:     creationTime,
 			modificationTime: creationTime,
+			compressed:       fls.compress,
 		}
 
 		if err := fls.setFileMetadata(newFileMetadata, tx); err != nil {
@@ -881,22 +1426,34 @@ func (fls *MetaFilesystem) OpenFile(filename string, flag int, perm os.FileMode)
 		metadata = newFileMetadata
 
 		//commit metada changes
-		txCommited = true
-		err = tx.Commit()
+		if tx != nil {
+			txClosed = true
+			err = tx.Commit()
 
-		if err != nil {
-			return nil, err
+			if err != nil {
+				return nil, err
+			}
 		}
 	} else {
 		//file exists
 
+		if tx != nil {
+			//nothing to create
+			txClosed = true
+			tx.Rollback()
+		}
+
 		if isSymlink(metadata.mode) {
 			//
 			return nil, errors.New("symlinks not supported")
 		}
 
 		if IsExclusive(flag) {
-			return nil, os.ErrExist
+			return nil, fmt.Errorf("%w: %s", os.ErrExist, filename)
+		}
+
+		if fls.enforcePermissions && isWriting(flag) && metadata.mode&0o200 == 0 {
+			return nil, fmt.Errorf("%w: %s", os.ErrPermission, filename)
 		}
 	}
 
@@ -904,7 +1461,18 @@ func (fls *MetaFilesystem) OpenFile(filename string, flag int, perm os.FileMode)
 		return nil, fmt.Errorf("%w: %s", ErrCannotOpenDir, filename)
 	}
 
-	underlyingFile, err := fls.underlying.OpenFile(metadata.concreteFile.UnderlyingString(), flag, METAFS_UNDERLYING_UNDERLYING_FILE_PERM)
+	var underlyingFile billy.File
+
+	if cachedContentFile, ok := fls.openCachedContent(metadata, flag); ok {
+		underlyingFile = cachedContentFile
+	} else if metadata.compressed {
+		underlyingFile, err = fls.openCompressedFile(metadata, flag, reservation)
+	} else {
+		underlyingFile, err = fls.underlying.OpenFile(metadata.concreteFile.UnderlyingString(), flag, METAFS_UNDERLYING_UNDERLYING_FILE_PERM)
+		if err == nil && IsTruncate(flag) {
+			fls.invalidateCachedContent(*metadata.concreteFile)
+		}
+	}
 
 	if err != nil {
 		//TODO: give more info about the error without leaking information about the underlying filesystem.
@@ -931,6 +1499,16 @@ func (fls *MetaFilesystem) OpenFile(filename string, flag int, perm os.FileMode)
 		flag:           flag,
 		metadata:       metadata,
 		underlying:     underlyingFile.(afs.SyncCapable),
+		reservation:    reservation,
+	}
+
+	if IsAppend(flag) {
+		appendLock, ok := fls.appendLocks[filename]
+		if !ok {
+			appendLock = &sync.Mutex{}
+			fls.appendLocks[filename] = appendLock
+		}
+		file.appendLock = appendLock
 	}
 
 	files[file] = struct{}{}
@@ -939,6 +1517,10 @@ func (fls *MetaFilesystem) OpenFile(filename string, flag int, perm os.FileMode)
 	fls.lock.Unlock()
 	locked = false
 
+	if created || IsTruncate(flag) {
+		file.modified.Store(true)
+	}
+
 	if created {
 		//add event and remove old events.
 		fls.eventQueue.EnqueueAutoRemove(Event{
@@ -951,6 +1533,19 @@ func (fls *MetaFilesystem) OpenFile(filename string, flag int, perm os.FileMode)
 	return file, nil
 }
 
+// makeConcreteFilePath returns the path in the underlying filesystem of a new concrete file for the file at pth.
+func (fls *MetaFilesystem) makeConcreteFilePath(pth core.Path) (core.Path, error) {
+	concreteName := fls.concreteNameFunc(pth)
+	if concreteName == "" || concreteName == METAFS_KV_FILENAME || strings.ContainsAny(concreteName, "/\\") {
+		return "", fmt.Errorf("failed to create %s: invalid concrete file name %q", pth, concreteName)
+	}
+
+	if fls.dir != nil {
+		return core.Path(fls.underlying.Join(*fls.dir, concreteName)), nil
+	}
+	return core.Path(NormalizeAsAbsolute(concreteName)), nil
+}
+
 func (fls *MetaFilesystem) Stat(filename string) (os.FileInfo, error) {
 	if fls.closed.Load() {
 		return nil, ErrClosedFilesystem
@@ -969,23 +1564,34 @@ func (fls *MetaFilesystem) statNoLock(filename string) (os.FileInfo, error) {
 
 	filename = NormalizeAsAbsolute(filename)
 
+	if fls.statCache != nil {
+		return fls.statWithCache(filename)
+	}
+
+	info, _, err := fls.computeFileInfo(filename)
+	return info, err
+}
+
+func (fls *MetaFilesystem) computeFileInfo(filename string) (os.FileInfo, *metaFsFileMetadata, error) {
 	metadata, exists, err := fls.getFileMetadata(core.PathFrom(filename), nil)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if !exists {
-		return nil, os.ErrNotExist
+		return nil, nil, os.ErrNotExist
 	}
 
 	var size core.ByteCount
 
-	if metadata.concreteFile != nil {
+	if metadata.compressed {
+		size = metadata.originalSize
+	} else if metadata.concreteFile != nil {
 		underlyingFilePath := *metadata.concreteFile
 		stat, err := fls.underlying.Stat(string(underlyingFilePath))
 		if err != nil {
-			return nil, fmt.Errorf("failed to get stat of %s", filename)
+			return nil, nil, fmt.Errorf("failed to get stat of %s", filename)
 		}
 		size = core.ByteCount(stat.Size())
 	}
@@ -998,7 +1604,7 @@ func (fls *MetaFilesystem) statNoLock(filename string) (os.FileInfo, error) {
 		ModTime_:        metadata.modificationTime,
 		HasCreationTime: true,
 		Size_:           size,
-	}, nil
+	}, metadata, nil
 }
 
 func (fls *MetaFilesystem) Lstat(filename string) (os.FileInfo, error) {
@@ -1064,6 +1670,65 @@ func (fls *MetaFilesystem) ReadDir(path string) ([]os.FileInfo, error) {
 	return entries, nil
 }
 
+// ReadDirPage returns at most limit entries of a directory, starting at offset, the entries are sorted the same way as ReadDir.
+// Only the entries of the page are retrieved, the returned boolean is true if there are more entries after the page.
+func (fls *MetaFilesystem) ReadDirPage(path string, offset, limit int) ([]os.FileInfo, bool, error) {
+	if fls.closed.Load() {
+		return nil, false, ErrClosedFilesystem
+	}
+
+	if offset < 0 {
+		return nil, false, errors.New("offset should be positive or zero")
+	}
+
+	if limit <= 0 {
+		return nil, false, errors.New("limit should be positive")
+	}
+
+	fls.lock.RLock()
+	defer fls.lock.RUnlock()
+
+	path = NormalizeAsAbsolute(path)
+
+	metadata, exists, err := fls.getFileMetadata(core.PathFrom(path), nil)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !exists {
+		return nil, false, os.ErrNotExist
+	}
+
+	if !metadata.mode.IsDir() {
+		return nil, false, errors.New("not a dir")
+	}
+
+	if offset >= len(metadata.children) {
+		return nil, false, nil
+	}
+
+	childNames := slices.Clone(metadata.children)
+	slices.Sort(childNames)
+
+	end := min(offset+limit, len(childNames))
+	hasMore := end < len(childNames)
+
+	entries := make([]os.FileInfo, 0, end-offset)
+	for _, childName := range childNames[offset:end] {
+		stat, err := fls.statNoLock(filepath.Join(path, string(childName)))
+		if err != nil {
+			return nil, false, err
+		}
+		entries = append(entries, stat)
+	}
+
+	return entries, hasMore, nil
+}
+
+// MkdirAll creates a directory and its missing parents, nothing is done if the directory already exists (its mode
+// is not changed). An error wrapping ErrNotDirectory and os.ErrExist is returned if a non-dir file exists at path
+// or at the path of one of the parents.
 func (fls *MetaFilesystem) MkdirAll(path string, perm os.FileMode) error {
 	if fls.closed.Load() {
 		return ErrClosedFilesystem
@@ -1124,15 +1789,16 @@ func (fls *MetaFilesystem) MkdirAllNoLock_(path string, perm os.FileMode, tx *bu
 			panic(core.ErrUnreachable)
 		}
 
+		//the parent and the new directory (including auto-created intermediate directories) get the same time.
+		creationTime := core.DateTime(time.Now())
+
 		dirMetadata.children = append(dirMetadata.children, pth.Basename())
-		dirMetadata.modificationTime = core.DateTime(time.Now())
+		dirMetadata.modificationTime = creationTime
 		if err := fls.setFileMetadata(dirMetadata, tx); err != nil {
 			return err
 		}
 
 		//create metadata for new directory & store it
-		creationTime := core.DateTime(time.Now())
-
 		newDirMetadata := &metaFsFileMetadata{
 			path:             pth,
 			mode:             perm,
@@ -1151,12 +1817,11 @@ func (fls *MetaFilesystem) MkdirAllNoLock_(path string, perm os.FileMode, tx *bu
 			dateTime: newDirMetadata.creationTime,
 		})
 	} else if !metadata.mode.IsDir() {
-		//if there is a non-dir file we return an error
-		return fmt.Errorf("%w at %q", os.ErrExist, path)
+		//if there is a non-dir file we return an error, the error also wraps os.ErrExist.
+		return fmt.Errorf("%w: %w at %q", ErrNotDirectory, os.ErrExist, path)
 	}
 
-	//TODO: support creating intermediary directories
-
+	//the mode of an existing directory is not changed.
 	return nil
 }
 
@@ -1200,7 +1865,7 @@ func (fls *MetaFilesystem) Rename(from, to string) error {
 	//iterate the metadata database to find all files & directories to move.
 
 	noIssue := false
-	tx, err := fls.metadata.Begin(true)
+	tx, err := fls.beginMetadataTx(true)
 	if err != nil {
 		return err
 	}
@@ -1296,6 +1961,8 @@ func (fls *MetaFilesystem) Rename(from, to string) error {
 
 	//update metadata of moved files & directories
 
+	var movedFiles []*metaFsFileMetadata
+
 	for opIndex, ops := range move {
 
 		if noCheckFuel <= 0 { //check context
@@ -1336,6 +2003,10 @@ func (fls *MetaFilesystem) Rename(from, to string) error {
 			return err
 		}
 
+		if !metadata.mode.IsDir() {
+			movedFiles = append(movedFiles, metadata)
+		}
+
 		//add event
 		if opIndex == 0 {
 			event := Event{
@@ -1353,11 +2024,32 @@ func (fls *MetaFilesystem) Rename(from, to string) error {
 			fls.eventQueue.EnqueueAutoRemove(event)
 		}
 	}
+
+	//move the append locks in order for the appends to the new paths to be serialized with the appends
+	//performed by the files still open on the previous paths.
+	for _, ops := range move {
+		from := ops[0].UnderlyingString()
+		if appendLock, ok := fls.appendLocks[from]; ok {
+			delete(fls.appendLocks, from)
+			fls.appendLocks[ops[1].UnderlyingString()] = appendLock
+		}
+	}
+
+	//the pending writes of the previous paths are applied before the removal.
+	if fls.mirror != nil {
+		fls.mirror.addRemovals([]core.Path{fromPath})
+		for _, metadata := range movedFiles {
+			fls.mirror.addWrite(metadata)
+		}
+	}
+
 	noIssue = true
 	return nil
 }
 
-func (fls *MetaFilesystem) Remove(filename string) error {
+func (fls *MetaFilesystem) Remove(filename string) (finalErr error) {
+	defer fls.startOp(METAFS_DELETE_OP)(&finalErr)
+
 	if fls.closed.Load() {
 		return ErrClosedFilesystem
 	}
@@ -1402,11 +2094,15 @@ func (fls *MetaFilesystem) Remove(filename string) error {
 
 		//add event and remove old events.
 		fls.eventQueue.EnqueueAllAutoRemove(events...)
+
+		if fls.mirror != nil {
+			fls.mirror.addRemovals(removed)
+		}
 	}()
 
 	noIssue := false
 	//create a temporary transaction
-	tx, err := fls.metadata.Begin(true)
+	tx, err := fls.beginMetadataTx(true)
 	if err != nil {
 		return err
 	}
@@ -1447,9 +2143,15 @@ func (fls *MetaFilesystem) Remove(filename string) error {
 		return err
 	}
 
-	//remove concrete file (error is ignored for now)
+	//remove concrete file if it is not referenced by other paths.
 	if metadata.concreteFile != nil {
-		fls.underlying.Remove((*metadata.concreteFile).UnderlyingString())
+		removed, size, err := fls.releaseConcreteFile(*metadata.concreteFile, tx)
+		if err != nil {
+			return err
+		}
+		if removed {
+			fls.releaseAddedByteCount(size)
+		}
 	}
 
 	//delete metadata
@@ -1459,6 +2161,7 @@ func (fls *MetaFilesystem) Remove(filename string) error {
 
 	removed = append(removed, metadata.path)
 	removalTimes = append(removalTimes, time.Time(parentMetadata.modificationTime))
+	delete(fls.appendLocks, filename)
 
 	if !metadata.mode.IsDir() {
 		noIssue = true
@@ -1503,16 +2206,22 @@ func (fls *MetaFilesystem) Remove(filename string) error {
 			queue = append(queue, currentMetadata.ChildrenPaths()...)
 		}
 
-		//remove concrete file (error is ignored for now)
-		if metadata.concreteFile != nil {
-			fls.underlying.Remove((*metadata.concreteFile).UnderlyingString())
+		//remove concrete file if it is not referenced by other paths.
+		if currentMetadata.concreteFile != nil {
+			removedConcreteFile, size, err := fls.releaseConcreteFile(*currentMetadata.concreteFile, tx)
+			if err != nil {
+				return err
+			}
+			if removedConcreteFile {
+				fls.releaseAddedByteCount(size)
+			}
 		}
 
 		if err := fls.deleteFileMetadata(current, tx); err != nil {
 			return err
 		}
 
-		removed = append(removed, metadata.path)
+		removed = append(removed, currentMetadata.path)
 		removalTimes = append(removalTimes, time.Now())
 	}
 	noIssue = err == nil
@@ -1545,6 +2254,13 @@ type metaFsFileMetadata struct {
 
 	//name of children if directory
 	children []core.String
+
+	//true if the content of the concrete file is gzip-compressed.
+	compressed bool
+
+	//size of the decompressed content, only set if compressed. The size is not part of the serialized metadata,
+	//it is stored per concrete file because the paths sharing a concrete file (see LinkContent) share their content.
+	originalSize core.ByteCount
 }
 
 func (m *metaFsFileMetadata) ChildrenPaths() []core.Path {
@@ -1601,6 +2317,8 @@ func (m *metaFsFileMetadata) initFromJSON(serialized string, updateLastModiftime
 				m.children = append(m.children, core.String(it.ReadString()))
 				return true
 			})
+		case METAFS_COMPRESSED_PROPNAME:
+			m.compressed = it.ReadBool()
 		default:
 			it.ReportError("read metadata", "unexpected property: "+keyString)
 		}
@@ -1670,6 +2388,12 @@ func (m *metaFsFileMetadata) marshalJSON() string {
 	} else {
 		stream.WriteObjectField(METAFS_UNDERLYING_FILE_PROPNAME)
 		stream.WriteString(m.concreteFile.UnderlyingString())
+
+		if m.compressed {
+			stream.WriteMore()
+			stream.WriteObjectField(METAFS_COMPRESSED_PROPNAME)
+			stream.WriteBool(true)
+		}
 	}
 
 	stream.WriteObjectEnd()
@@ -1689,3 +2413,7 @@ func getKvKeyFromPath(pth core.Path) core.Path {
 func fmtFailedToGetFileMetadataError(pth core.Path, err error) error {
 	return fmt.Errorf("failed to get metadata for file %s: %w", pth, err)
 }
+
+func makeULIDConcreteName(path core.Path) string {
+	return ulid.Make().String()
+}
@@ -0,0 +1,244 @@
+package fs_ns
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/inoxlang/inox/internal/core"
+)
+
+// WriteFileAtomic writes data to the file at path: the data is written to a new concrete file that is synced, and then
+// the metadata of the file is updated in a single transaction to point to the new concrete file. The previous concrete
+// file is removed afterwards if it is not referenced by other paths. Readers either see the previous content or data,
+// and nothing is visible if the write fails. The file is created with perm if it does not exist, the mode of an
+// existing file is not changed.
+func (fls *MetaFilesystem) WriteFileAtomic(path core.Path, data []byte, perm fs.FileMode) error {
+	return fls.writeFileAtomic(path, data, perm, nil)
+}
+
+func (fls *MetaFilesystem) writeFileAtomic(path core.Path, data []byte, perm fs.FileMode, reservation *SpaceReservation) (finalErr error) {
+	defer fls.startOp(METAFS_WRITE_OP)(&finalErr)
+
+	if fls.closed.Load() {
+		return ErrClosedFilesystem
+	}
+
+	filename := NormalizeAsAbsolute(path.UnderlyingString())
+	pth := core.PathFrom(filename)
+
+	defer fls.pendingFileCreations.Add(-1)
+
+	if fls.pendingFileCreations.Add(1) > fls.maxParallelCreationCount {
+		return ErrTooManyParallelFileCreation
+	}
+
+	if err := fls.checkFileCountBeforeCreation(); err != nil {
+		return err
+	}
+
+	concreteContent := data
+	if fls.compress {
+		compressed, err := compressFileContent(data)
+		if err != nil {
+			return err
+		}
+		concreteContent = compressed
+	}
+
+	//check the usable space.
+
+	addedBytes := core.ByteCount(len(concreteContent))
+	if err := fls.checkUnderlyingAvailableSpace(addedBytes); err != nil {
+		return err
+	}
+
+	if yes, err := fls.checkAddedByteCount(addedBytes, reservation); err != nil {
+		return err
+	} else if !yes {
+		return ErrNoRemainingSpaceToApplyChange
+	}
+
+	//write the new concrete file.
+
+	concreteFile, err := fls.makeAtomicWriteConcreteFilePath(pth)
+	if err != nil {
+		fls.releaseAddedByteCount(addedBytes)
+		return err
+	}
+
+	removeNewConcreteFile := func() {
+		fls.underlying.Remove(concreteFile.UnderlyingString())
+		fls.releaseAddedByteCount(addedBytes)
+	}
+
+	if err := fls.writeNewConcreteFile(concreteFile, concreteContent); err != nil {
+		removeNewConcreteFile()
+		return fmt.Errorf("failed to write %s: %w", pth, err)
+	}
+
+	//update the metadata.
+
+	fls.lock.Lock()
+	defer fls.lock.Unlock()
+
+	if fls.closed.Load() {
+		removeNewConcreteFile()
+		return ErrClosedFilesystem
+	}
+
+	tx, err := fls.beginMetadataTx(true)
+	if err != nil {
+		removeNewConcreteFile()
+		return err
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			tx.Rollback()
+			removeNewConcreteFile()
+		}
+	}()
+
+	metadata, exists, err := fls.getFileMetadata(pth, tx)
+	if err != nil {
+		return err
+	}
+
+	now := core.DateTime(time.Now())
+	var previousConcreteFile *core.Path
+
+	if exists {
+		if metadata.mode.IsDir() {
+			return fmt.Errorf("%w: %s", ErrCannotOpenDir, filename)
+		}
+		if isSymlink(metadata.mode) {
+			return errors.New("symlinks not supported")
+		}
+		if fls.enforcePermissions && metadata.mode&0o200 == 0 {
+			return fmt.Errorf("%w: %s", os.ErrPermission, filename)
+		}
+
+		previousConcreteFile = metadata.concreteFile
+
+		//the metadata is copied because it may be shared with open files.
+		newMetadata := *metadata
+		metadata = &newMetadata
+	} else {
+		dir := filepath.Dir(filename)
+		if dir != "/" {
+			//make sure parent exists
+			if err := fls.MkdirAllNoLock_(dir, METAFS_AUTO_CREATED_DIR_PERM, tx); err != nil {
+				return fmt.Errorf("failed to create %s", dir)
+			}
+		}
+
+		//update the metadata of the parent directory
+		dirMetadata, found, err := fls.getFileMetadata(core.DirPathFrom(dir), tx)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("failed to create %s: parent directory %s does not exist", pth, dir)
+		}
+
+		dirMetadata.children = append(dirMetadata.children, pth.Basename())
+		dirMetadata.modificationTime = now
+		if err := fls.setFileMetadata(dirMetadata, tx); err != nil {
+			return err
+		}
+
+		metadata = &metaFsFileMetadata{
+			path:         pth,
+			mode:         perm,
+			creationTime: now,
+		}
+	}
+
+	metadata.concreteFile = &concreteFile
+	metadata.modificationTime = now
+	metadata.compressed = fls.compress
+	metadata.originalSize = 0 //only set if compressed
+
+	if fls.compress {
+		metadata.originalSize = core.ByteCount(len(data))
+	}
+
+	if err := fls.setFileMetadata(metadata, tx); err != nil {
+		return err
+	}
+
+	if fls.compress {
+		if err := fls.setConcreteFileOriginalSize(concreteFile, metadata.originalSize, tx); err != nil {
+			return err
+		}
+	}
+
+	previousConcreteFileUnreferenced := false
+	if previousConcreteFile != nil {
+		previousConcreteFileUnreferenced, err = fls.decrementConcreteFileRefCount(*previousConcreteFile, tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	committed = true
+
+	//remove the previous concrete file, the files that are open keep reading the previous content.
+	if previousConcreteFileUnreferenced {
+		size, err := fls.removeConcreteFile(*previousConcreteFile)
+		if err != nil {
+			fls.ctx.Logger().Err(err).Msg("failed to remove previous concrete file of " + filename)
+		} else {
+			fls.releaseAddedByteCount(size)
+		}
+	}
+
+	fls.lastModificationTimesLock.Lock()
+	fls.lastModificationTimes[filename] = now
+	fls.lastModificationTimesLock.Unlock()
+
+	fls.eventQueue.EnqueueAutoRemove(Event{
+		path:     pth,
+		createOp: !exists,
+		writeOp:  exists,
+		dateTime: now,
+	})
+
+	if fls.mirror != nil {
+		fls.mirror.addWrite(metadata)
+	}
+
+	return nil
+}
+
+// makeAtomicWriteConcreteFilePath returns the path of the new concrete file of an atomic write. The name returned by
+// the ConcreteNameFunc may be the name of the current concrete file (e.g. a name derived from the path), in this case
+// a ULID suffix is added to make the name distinct.
+func (fls *MetaFilesystem) makeAtomicWriteConcreteFilePath(pth core.Path) (core.Path, error) {
+	concreteFile, err := fls.makeConcreteFilePath(pth)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := fls.underlying.Stat(concreteFile.UnderlyingString()); err == nil {
+		concreteFile = core.Path(concreteFile.UnderlyingString() + "-" + makeULIDConcreteName(pth))
+	}
+	return concreteFile, nil
+}
+
+// writeNewConcreteFile creates a concrete file, writes content to it and syncs it.
+func (fls *MetaFilesystem) writeNewConcreteFile(concreteFile core.Path, content []byte) error {
+	file, err := fls.underlying.OpenFile(concreteFile.UnderlyingString(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, METAFS_UNDERLYING_UNDERLYING_FILE_PERM)
+	if err != nil {
+		return err
+	}
+	return writeAndSyncConcreteFile(file, content)
+}
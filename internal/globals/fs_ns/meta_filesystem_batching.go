@@ -0,0 +1,115 @@
+package fs_ns
+
+import (
+	"errors"
+	"time"
+
+	"github.com/inoxlang/inox/internal/buntdb"
+)
+
+// A pendingMetadataWrite is a metadata write that has not been committed to the KV store yet,
+// see MetaFilesystemParams.MetadataWriteBatchingWindow.
+type pendingMetadataWrite struct {
+	serializedMetadata string
+	deletion           bool
+}
+
+func (fls *MetaFilesystem) isMetadataWriteBatchingEnabled() bool {
+	return fls.metadataWriteBatchingWindow > 0
+}
+
+// beginMetadataTx flushes the pending metadata writes and then begins a transaction, this should be used instead
+// of fls.metadata.Begin in order for the transaction to see the writes that have been batched.
+func (fls *MetaFilesystem) beginMetadataTx(writable bool) (*buntdb.Tx, error) {
+	if err := fls.flushPendingMetadataWrites(); err != nil {
+		return nil, err
+	}
+	return fls.metadata.Begin(writable)
+}
+
+// getPendingMetadataWrite returns the last pending write for a KV key, if any.
+func (fls *MetaFilesystem) getPendingMetadataWrite(key string) (pendingMetadataWrite, bool) {
+	if !fls.isMetadataWriteBatchingEnabled() {
+		return pendingMetadataWrite{}, false
+	}
+
+	fls.pendingMetadataWritesLock.Lock()
+	defer fls.pendingMetadataWritesLock.Unlock()
+
+	write, ok := fls.pendingMetadataWrites[key]
+	return write, ok
+}
+
+// addPendingMetadataWrite records a write that will be committed by the next flush, a flush is scheduled
+// if none is pending. The caller should hold fls.lock (write).
+func (fls *MetaFilesystem) addPendingMetadataWrite(key string, write pendingMetadataWrite) {
+	fls.pendingMetadataWritesLock.Lock()
+	defer fls.pendingMetadataWritesLock.Unlock()
+
+	fls.pendingMetadataWrites[key] = write
+
+	if fls.metadataFlushTimer == nil {
+		fls.metadataFlushTimer = time.AfterFunc(fls.metadataWriteBatchingWindow, fls.flushPendingMetadataWritesAfterDelay)
+	}
+}
+
+func (fls *MetaFilesystem) flushPendingMetadataWritesAfterDelay() {
+	if fls.closed.Load() {
+		return
+	}
+
+	fls.lock.Lock()
+	defer fls.lock.Unlock()
+
+	if err := fls.flushPendingMetadataWrites(); err != nil {
+		fls.ctx.Logger().Err(err).Msg("failed to flush pending metadata writes of meta filesystem")
+	}
+}
+
+// flushPendingMetadataWrites commits all pending metadata writes in a single transaction. If the commit fails
+// the writes are kept and will be retried by the next flush. The caller should hold fls.lock (read or write).
+func (fls *MetaFilesystem) flushPendingMetadataWrites() error {
+	if !fls.isMetadataWriteBatchingEnabled() {
+		return nil
+	}
+
+	fls.pendingMetadataWritesLock.Lock()
+	defer fls.pendingMetadataWritesLock.Unlock()
+
+	if fls.metadataFlushTimer != nil {
+		fls.metadataFlushTimer.Stop()
+		fls.metadataFlushTimer = nil
+	}
+
+	if len(fls.pendingMetadataWrites) == 0 {
+		return nil
+	}
+
+	tx, err := fls.metadata.Begin(true)
+	if err != nil {
+		return err
+	}
+
+	for key, write := range fls.pendingMetadataWrites {
+		if write.deletion {
+			_, err = tx.Delete(key)
+			if errors.Is(err, buntdb.ErrNotFound) {
+				err = nil
+			}
+		} else {
+			_, _, err = tx.Set(key, write.serializedMetadata, nil)
+		}
+
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	clear(fls.pendingMetadataWrites)
+	return nil
+}
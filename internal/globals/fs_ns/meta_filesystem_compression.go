@@ -0,0 +1,473 @@
+package fs_ns
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/inoxlang/inox/internal/afs"
+	"github.com/inoxlang/inox/internal/core"
+)
+
+var (
+	ErrFileNotOpenedForReading = errors.New("file is not opened for reading")
+	ErrFileNotOpenedForWriting = errors.New("file is not opened for writing")
+
+	_ afs.SyncCapable = (*compressedFile)(nil)
+)
+
+// compressedFile is an in-memory view of the decompressed content of a compressed concrete file. The content is
+// decompressed when the first handle of the concrete file is opened and it is shared by all the handles of the concrete
+// file, see compressedFileContent. The content is compressed and written to the concrete file by Sync and Close
+// if it has been modified. The space used by the concrete file is checked when the content is written.
+type compressedFile struct {
+	fls          *MetaFilesystem
+	concreteFile core.Path
+	metadata     *metaFsFileMetadata
+	flag         int
+	reservation  *SpaceReservation //can be nil
+
+	shared   *compressedFileContent
+	position int64 //guarded by shared.lock
+	closed   bool  //guarded by shared.lock
+}
+
+// compressedFileContent is the decompressed content of a compressed concrete file, it is shared by the handles of
+// the concrete file in order for a flush to never overwrite the writes performed through another handle.
+type compressedFileContent struct {
+	lock     sync.Mutex
+	content  []byte
+	modified bool
+
+	handleCount int //guarded by MetaFilesystem.compressedContentsLock
+}
+
+// openCompressedFile returns a handle of a compressed file, the content of the concrete file is decompressed if no other
+// handle of the concrete file is open. The content is not read if the file is truncated by flag.
+func (fls *MetaFilesystem) openCompressedFile(metadata *metaFsFileMetadata, flag int, reservation *SpaceReservation) (*compressedFile, error) {
+	concreteFile := *metadata.concreteFile
+
+	fls.compressedContentsLock.Lock()
+	defer fls.compressedContentsLock.Unlock()
+
+	shared, ok := fls.compressedContents[concreteFile]
+	if !ok {
+		shared = &compressedFileContent{}
+
+		if !IsTruncate(flag) {
+			content, err := fls.readConcreteFileContent(metadata)
+			if err != nil {
+				return nil, err
+			}
+			shared.content = content
+		}
+		fls.compressedContents[concreteFile] = shared
+	}
+
+	if IsTruncate(flag) {
+		//the empty content will be compressed and written to the concrete file.
+		shared.lock.Lock()
+		shared.content = nil
+		shared.modified = true
+		shared.lock.Unlock()
+	}
+
+	shared.handleCount++
+
+	return &compressedFile{
+		fls:          fls,
+		concreteFile: concreteFile,
+		metadata:     metadata,
+		flag:         flag,
+		reservation:  reservation,
+		shared:       shared,
+	}, nil
+}
+
+// releaseCompressedContent is called when a handle of a compressed file is closed, the shared content is dropped
+// when the last handle is closed. Contents that are not tracked (see openCachedContent) are ignored.
+func (fls *MetaFilesystem) releaseCompressedContent(concreteFile core.Path, shared *compressedFileContent) {
+	fls.compressedContentsLock.Lock()
+	defer fls.compressedContentsLock.Unlock()
+
+	if fls.compressedContents[concreteFile] != shared {
+		return
+	}
+
+	shared.handleCount--
+	if shared.handleCount <= 0 {
+		delete(fls.compressedContents, concreteFile)
+	}
+}
+
+// readConcreteFileContent reads the content of the concrete file of a non-dir file and decompresses it if necessary.
+func (fls *MetaFilesystem) readConcreteFileContent(metadata *metaFsFileMetadata) ([]byte, error) {
+	content, err := util.ReadFile(fls.underlying, metadata.concreteFile.UnderlyingString())
+	if err != nil {
+		return nil, err
+	}
+
+	if !metadata.compressed {
+		return content, nil
+	}
+	return decompressFileContent(content)
+}
+
+// openConcreteFileContent opens the concrete file of a non-dir file, the returned reader decompresses the content
+// if necessary.
+func (fls *MetaFilesystem) openConcreteFileContent(metadata *metaFsFileMetadata) (io.ReadCloser, error) {
+	file, err := fls.underlying.Open(metadata.concreteFile.UnderlyingString())
+	if err != nil {
+		return nil, err
+	}
+
+	if !metadata.compressed {
+		return file, nil
+	}
+
+	content, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	decompressed, err := decompressFileContent(content)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(decompressed)), nil
+}
+
+func decompressFileContent(compressed []byte) ([]byte, error) {
+	if len(compressed) == 0 {
+		//the concrete file of a new file is empty.
+		return nil, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress file content: %w", err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress file content: %w", err)
+	}
+	return content, nil
+}
+
+func compressFileContent(content []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	writer := gzip.NewWriter(buf)
+
+	if _, err := writer.Write(content); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (f *compressedFile) Name() string {
+	return f.concreteFile.UnderlyingString()
+}
+
+func (f *compressedFile) isReadable() bool {
+	return f.flag&(os.O_WRONLY|os.O_RDWR) != os.O_WRONLY
+}
+
+func (f *compressedFile) isWritable() bool {
+	return f.flag&(os.O_WRONLY|os.O_RDWR) != 0
+}
+
+func (f *compressedFile) Write(p []byte) (n int, err error) {
+	f.shared.lock.Lock()
+	defer f.shared.lock.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	if !f.isWritable() {
+		return 0, ErrFileNotOpenedForWriting
+	}
+
+	end := f.position + int64(len(p))
+	if end > int64(len(f.shared.content)) {
+		f.shared.content = append(f.shared.content, make([]byte, end-int64(len(f.shared.content)))...)
+	}
+
+	copy(f.shared.content[f.position:end], p)
+	f.position = end
+	f.shared.modified = true
+	return len(p), nil
+}
+
+func (f *compressedFile) Read(p []byte) (n int, err error) {
+	f.shared.lock.Lock()
+	defer f.shared.lock.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	if !f.isReadable() {
+		return 0, ErrFileNotOpenedForReading
+	}
+
+	if f.position >= int64(len(f.shared.content)) {
+		return 0, io.EOF
+	}
+
+	n = copy(p, f.shared.content[f.position:])
+	f.position += int64(n)
+	return n, nil
+}
+
+func (f *compressedFile) ReadAt(p []byte, off int64) (n int, err error) {
+	f.shared.lock.Lock()
+	defer f.shared.lock.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	if !f.isReadable() {
+		return 0, ErrFileNotOpenedForReading
+	}
+
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	if off >= int64(len(f.shared.content)) {
+		return 0, io.EOF
+	}
+
+	n = copy(p, f.shared.content[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *compressedFile) Seek(offset int64, whence int) (int64, error) {
+	f.shared.lock.Lock()
+	defer f.shared.lock.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	var position int64
+
+	switch whence {
+	case io.SeekStart:
+		position = offset
+	case io.SeekCurrent:
+		position = f.position + offset
+	case io.SeekEnd:
+		position = int64(len(f.shared.content)) + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if position < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	f.position = position
+	return position, nil
+}
+
+func (f *compressedFile) Truncate(size int64) error {
+	f.shared.lock.Lock()
+	defer f.shared.lock.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+
+	if !f.isWritable() {
+		return ErrFileNotOpenedForWriting
+	}
+
+	if size < 0 {
+		return errors.New("negative size")
+	}
+
+	if size <= int64(len(f.shared.content)) {
+		f.shared.content = f.shared.content[:size]
+	} else {
+		f.shared.content = append(f.shared.content, make([]byte, size-int64(len(f.shared.content)))...)
+	}
+	f.shared.modified = true
+	return nil
+}
+
+func (f *compressedFile) Stat() (os.FileInfo, error) {
+	f.shared.lock.Lock()
+	defer f.shared.lock.Unlock()
+
+	return core.FileInfo{
+		BaseName_:       string(f.metadata.path.Basename()),
+		AbsPath_:        f.metadata.path,
+		Mode_:           core.FileMode(f.metadata.mode),
+		CreationTime_:   f.metadata.creationTime,
+		ModTime_:        f.metadata.modificationTime,
+		HasCreationTime: true,
+		Size_:           core.ByteCount(len(f.shared.content)),
+	}, nil
+}
+
+func (f *compressedFile) Lock() error {
+	return core.ErrNotImplemented
+}
+
+func (f *compressedFile) Unlock() error {
+	return core.ErrNotImplemented
+}
+
+func (f *compressedFile) Sync() error {
+	return f.sync(false)
+}
+
+// syncWhileFsLocked is the equivalent of Sync for when the lock of the filesystem is held by the caller.
+func (f *compressedFile) syncWhileFsLocked() error {
+	return f.sync(true)
+}
+
+func (f *compressedFile) sync(fsLocked bool) error {
+	if !fsLocked && f.isWritable() {
+		//the lock of the filesystem is always acquired before the lock of the content (see SyncAll).
+		f.fls.lock.Lock()
+		defer f.fls.lock.Unlock()
+	}
+
+	f.shared.lock.Lock()
+	defer f.shared.lock.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.flush()
+}
+
+func (f *compressedFile) Close() error {
+	if f.isWritable() {
+		f.fls.lock.Lock()
+		defer f.fls.lock.Unlock()
+	}
+
+	f.shared.lock.Lock()
+
+	if f.closed {
+		f.shared.lock.Unlock()
+		return os.ErrClosed
+	}
+
+	err := f.flush()
+	f.closed = true
+	f.shared.lock.Unlock()
+
+	f.fls.releaseCompressedContent(f.concreteFile, f.shared)
+	return err
+}
+
+// flush compresses the shared content and writes it to the concrete file if the content has been modified,
+// the original size of the concrete file is updated. The lock of the filesystem and f.shared.lock should be held.
+func (f *compressedFile) flush() error {
+	shared := f.shared
+
+	if !shared.modified || !f.isWritable() {
+		return nil
+	}
+
+	fls := f.fls
+
+	compressed, err := compressFileContent(shared.content)
+	if err != nil {
+		return err
+	}
+
+	//only the difference between the new and the current on-disk sizes is checked.
+	var currentSize int64
+	if stat, err := fls.underlying.Stat(f.concreteFile.UnderlyingString()); err == nil {
+		currentSize = stat.Size()
+	}
+
+	if addedBytes := core.ByteCount(int64(len(compressed)) - currentSize); addedBytes > 0 {
+		if err := fls.checkUnderlyingAvailableSpace(addedBytes); err != nil {
+			return err
+		}
+
+		if yes, err := fls.checkAddedByteCount(addedBytes, f.reservation); err != nil {
+			return err
+		} else if !yes {
+			return ErrNoRemainingSpaceToApplyChange
+		}
+	} else {
+		fls.releaseAddedByteCount(-addedBytes)
+	}
+
+	concreteFile, err := fls.underlying.OpenFile(f.concreteFile.UnderlyingString(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, METAFS_UNDERLYING_UNDERLYING_FILE_PERM)
+	if err != nil {
+		return fmt.Errorf("failed to write %s", f.metadata.path)
+	}
+
+	err = writeAndSyncConcreteFile(concreteFile, compressed)
+	fls.invalidateCachedContent(f.concreteFile)
+
+	if err != nil {
+		return fmt.Errorf("failed to write %s", f.metadata.path)
+	}
+
+	shared.modified = false
+	f.metadata.originalSize = core.ByteCount(len(shared.content))
+
+	return fls.storeOriginalSize(f.concreteFile, f.metadata.originalSize)
+}
+
+func writeAndSyncConcreteFile(file billy.File, content []byte) error {
+	defer file.Close()
+
+	if _, err := file.Write(content); err != nil {
+		return err
+	}
+
+	if syncCapable, ok := file.(afs.SyncCapable); ok {
+		return syncCapable.Sync()
+	}
+	return nil
+}
+
+// storeOriginalSize stores the original (decompressed) size of a compressed concrete file, the size is shared by all
+// the paths referencing the concrete file (see LinkContent). fls.lock should be held.
+func (fls *MetaFilesystem) storeOriginalSize(concreteFile core.Path, size core.ByteCount) error {
+	tx, err := fls.metadata.Begin(true)
+	if err != nil {
+		return err
+	}
+
+	if err := fls.setConcreteFileOriginalSize(concreteFile, size, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if fls.statCache != nil {
+		fls.statCache.invalidateConcreteFile(concreteFile)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package fs_ns
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/inoxlang/inox/internal/core"
+)
+
+// A metaFsContentCache is an in-memory LRU cache of the (decompressed) contents of concrete files, see
+// MetaFilesystemParams.ContentCacheSize. Entries are keyed by concrete file, so the paths sharing a concrete file
+// share an entry and an atomic write (new concrete file) never hits a stale entry.
+type metaFsContentCache struct {
+	lock    sync.Mutex
+	maxSize core.ByteCount
+	size    core.ByteCount
+	entries map[core.Path]*list.Element
+	lru     *list.List //front: most recently used
+
+	//incremented by each invalidation, this prevents a content read before an invalidation from being added.
+	generation uint64
+}
+
+type contentCacheEntry struct {
+	concreteFile core.Path
+	content      []byte
+}
+
+func newMetaFsContentCache(maxSize core.ByteCount) *metaFsContentCache {
+	return &metaFsContentCache{
+		maxSize: maxSize,
+		entries: map[core.Path]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// get returns the cached content of a concrete file, the returned slice should not be modified.
+func (c *metaFsContentCache) get(concreteFile core.Path) ([]byte, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.entries[concreteFile]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*contentCacheEntry).content, true
+}
+
+// currentGeneration should be called before reading a content that will be added.
+func (c *metaFsContentCache) currentGeneration() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.generation
+}
+
+// add adds the content of a concrete file and evicts the least recently used entries if necessary. Nothing is added if
+// an invalidation occurred since generation was retrieved or if the content is larger than the cache.
+func (c *metaFsContentCache) add(concreteFile core.Path, content []byte, generation uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	size := core.ByteCount(len(content))
+
+	if c.generation != generation || size > c.maxSize {
+		return
+	}
+
+	if elem, ok := c.entries[concreteFile]; ok {
+		c.removeElement(elem)
+	}
+
+	for c.size+size > c.maxSize {
+		c.removeElement(c.lru.Back())
+	}
+
+	c.entries[concreteFile] = c.lru.PushFront(&contentCacheEntry{concreteFile: concreteFile, content: content})
+	c.size += size
+}
+
+// invalidate removes the content of a concrete file, it should be called after the concrete file is modified or removed.
+func (c *metaFsContentCache) invalidate(concreteFile core.Path) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.generation++
+
+	if elem, ok := c.entries[concreteFile]; ok {
+		c.removeElement(elem)
+	}
+}
+
+func (c *metaFsContentCache) removeElement(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*contentCacheEntry)
+	delete(c.entries, entry.concreteFile)
+	c.size -= core.ByteCount(len(entry.content))
+}
+
+// invalidateCachedContent removes the cached content of a concrete file, nothing is done if the cache is disabled.
+func (fls *MetaFilesystem) invalidateCachedContent(concreteFile core.Path) {
+	if fls.contentCache != nil {
+		fls.contentCache.invalidate(concreteFile)
+	}
+}
+
+// openCachedContent returns an in-memory file serving the content of a file opened in read-only mode. The content is read
+// and added to the cache if it is not already cached, false is returned if the cache is disabled, if flag allows writing
+// or if the content cannot be cached.
+func (fls *MetaFilesystem) openCachedContent(metadata *metaFsFileMetadata, flag int) (*compressedFile, bool) {
+	if fls.contentCache == nil || isWriting(flag) {
+		return nil, false
+	}
+
+	concreteFile := *metadata.concreteFile
+
+	content, ok := fls.contentCache.get(concreteFile)
+	if !ok {
+		info, err := fls.underlying.Stat(concreteFile.UnderlyingString())
+		if err != nil || core.ByteCount(info.Size()) > fls.contentCache.maxSize {
+			return nil, false
+		}
+
+		generation := fls.contentCache.currentGeneration()
+
+		content, err = fls.readConcreteFileContent(metadata)
+		if err != nil {
+			return nil, false
+		}
+
+		fls.contentCache.add(concreteFile, content, generation)
+	}
+
+	//a read-only compressedFile is an in-memory file that never modifies its content.
+	return &compressedFile{
+		fls:          fls,
+		concreteFile: concreteFile,
+		metadata:     metadata,
+		flag:         flag,
+		shared:       &compressedFileContent{content: content},
+	}, true
+}
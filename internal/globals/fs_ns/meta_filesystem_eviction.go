@@ -0,0 +1,169 @@
+package fs_ns
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/inoxlang/inox/internal/core"
+	"github.com/inoxlang/inox/internal/utils"
+)
+
+// evictLeastRecentlyModifiedFiles removes the least recently modified non-dir files until count concrete files have been
+// removed, the number of removed concrete files is returned. Several paths may need to be removed in order to remove a
+// concrete file shared by linked paths. Directories and open files are never evicted. The metadata changes (including the
+// update of the children of the parent directories) are committed in a single transaction before the concrete files are removed.
+func (fls *MetaFilesystem) evictLeastRecentlyModifiedFiles(count int) (removedConcreteFileCount int, _ error) {
+	if fls.closed.Load() {
+		return 0, ErrClosedFilesystem
+	}
+
+	fls.lock.Lock()
+	defer fls.lock.Unlock()
+
+	fls.untrackSomeClosedFiles(-1)
+
+	entries, err := fls.getAllFileMetadata()
+	if err != nil {
+		return 0, err
+	}
+
+	//find the files that can be evicted, the modification times tracked in memory are more recent than the stored ones.
+	var candidates []*metaFsFileMetadata
+
+	func() {
+		fls.lastModificationTimesLock.RLock()
+		defer fls.lastModificationTimesLock.RUnlock()
+
+		for normalizedPath, metadata := range entries {
+			if metadata.mode.IsDir() || metadata.symlinkTarget != nil || metadata.concreteFile == nil || fls.isFileOpen(normalizedPath) {
+				continue
+			}
+			if modifTime, ok := fls.lastModificationTimes[normalizedPath]; ok {
+				metadata.modificationTime = modifTime
+			}
+			candidates = append(candidates, metadata)
+		}
+	}()
+
+	slices.SortFunc(candidates, func(a, b *metaFsFileMetadata) int {
+		if cmp := time.Time(a.modificationTime).Compare(time.Time(b.modificationTime)); cmp != 0 {
+			return cmp
+		}
+		return strings.Compare(a.path.UnderlyingString(), b.path.UnderlyingString())
+	})
+
+	noIssue := false
+	tx, err := fls.beginMetadataTx(true)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if !noIssue {
+			tx.Rollback()
+		}
+	}()
+
+	var (
+		evicted                   []*metaFsFileMetadata
+		unreferencedConcreteFiles []core.Path
+	)
+
+	for _, metadata := range candidates {
+		if len(unreferencedConcreteFiles) >= count {
+			break
+		}
+
+		normalizedPath := NormalizeAsAbsolute(metadata.path.UnderlyingString())
+
+		//detach the file from its parent.
+		parentMetadata, exists, err := fls.getFileMetadata(core.DirPathFrom(filepath.Dir(normalizedPath)), tx)
+		if err != nil {
+			return 0, err
+		}
+
+		if exists {
+			for index, childName := range parentMetadata.children {
+				if childName == metadata.path.Basename() {
+					parentMetadata.children = utils.RemoveIndexOfSlice(parentMetadata.children, index)
+					break
+				}
+			}
+
+			parentMetadata.modificationTime = core.DateTime(time.Now())
+			if err := fls.setFileMetadata(parentMetadata, tx); err != nil {
+				return 0, err
+			}
+		}
+
+		if err := fls.deleteFileMetadata(metadata.path, tx); err != nil {
+			return 0, err
+		}
+
+		unreferenced, err := fls.decrementConcreteFileRefCount(*metadata.concreteFile, tx)
+		if err != nil {
+			return 0, err
+		}
+		if unreferenced {
+			unreferencedConcreteFiles = append(unreferencedConcreteFiles, *metadata.concreteFile)
+		}
+
+		evicted = append(evicted, metadata)
+	}
+
+	noIssue = true
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	//update the in-memory state and remove the concrete files.
+	now := core.DateTime(time.Now())
+	events := make([]Event, len(evicted))
+
+	fls.lastModificationTimesLock.Lock()
+	for i, metadata := range evicted {
+		normalizedPath := NormalizeAsAbsolute(metadata.path.UnderlyingString())
+		delete(fls.lastModificationTimes, normalizedPath)
+		delete(fls.appendLocks, normalizedPath)
+		delete(fls.openFiles, normalizedPath)
+
+		events[i] = Event{
+			path:     metadata.path,
+			removeOp: true,
+			dateTime: now,
+		}
+	}
+	fls.lastModificationTimesLock.Unlock()
+
+	for _, concreteFile := range unreferencedConcreteFiles {
+		size, err := fls.removeConcreteFile(concreteFile)
+		if err != nil {
+			return removedConcreteFileCount, err
+		}
+		fls.releaseAddedByteCount(size)
+		removedConcreteFileCount++
+	}
+
+	fls.eventQueue.EnqueueAllAutoRemove(events...)
+
+	if fls.mirror != nil {
+		evictedPaths := make([]core.Path, len(evicted))
+		for i, metadata := range evicted {
+			evictedPaths[i] = metadata.path
+		}
+		fls.mirror.addRemovals(evictedPaths)
+	}
+
+	return removedConcreteFileCount, nil
+}
+
+// isFileOpen returns true if the file has at least one handle that is not closed, fls.lock should be held.
+func (fls *MetaFilesystem) isFileOpen(normalizedPath string) bool {
+	for file := range fls.openFiles[normalizedPath] {
+		if !file.closed.Load() {
+			return true
+		}
+	}
+	return false
+}
@@ -3,7 +3,9 @@ package fs_ns
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -23,6 +25,9 @@ var (
 	_ billy.File = (*metaFsFile)(nil)
 )
 
+// metaFsFile is a file of a MetaFilesystem. Reads and writes go directly through the concrete file in the underlying
+// filesystem, so the content of large files is never fully loaded in memory. The only exception is compressed files,
+// see compressedFile.
 type metaFsFile struct {
 	fs             *MetaFilesystem
 	originalPath   string
@@ -31,9 +36,12 @@ type metaFsFile struct {
 	flag           int
 	underlying     afs.SyncCapable
 	metadata       *metaFsFileMetadata
+	appendLock     *sync.Mutex       //set if the file is opened in append mode
+	reservation    *SpaceReservation //set if the file is opened through a space reservation
 
 	snapshoting atomic.Bool
 	closed      atomic.Bool
+	modified    atomic.Bool //modified since the last mirrored write, only used if the filesystem has a mirror
 }
 
 func (f *metaFsFile) Name() string {
@@ -41,8 +49,17 @@ func (f *metaFsFile) Name() string {
 }
 
 func (f *metaFsFile) checkUsableSpace(addedBytes int) error {
+	if f.metadata.compressed {
+		//the space used by the compressed content is checked when it is written to the concrete file.
+		return nil
+	}
+
 	// TODO: take position into account
-	if yes, err := f.fs.checkAddedByteCount(core.ByteCount(addedBytes)); err != nil {
+	if err := f.fs.checkUnderlyingAvailableSpace(core.ByteCount(addedBytes)); err != nil {
+		return err
+	}
+
+	if yes, err := f.fs.checkAddedByteCount(core.ByteCount(addedBytes), f.reservation); err != nil {
 		return err
 	} else if !yes {
 		return ErrNoRemainingSpaceToApplyChange
@@ -52,6 +69,8 @@ func (f *metaFsFile) checkUsableSpace(addedBytes int) error {
 }
 
 func (f *metaFsFile) Write(p []byte) (n int, err error) {
+	defer f.fs.startOp(METAFS_WRITE_OP)(&err)
+
 	if f.closed.Load() {
 		return 0, os.ErrClosed
 	}
@@ -60,38 +79,83 @@ func (f *metaFsFile) Write(p []byte) (n int, err error) {
 		return 0, ErrFileBeingSnapshoted
 	}
 
+	if f.appendLock != nil {
+		return f.append(p)
+	}
+
 	if err := f.checkUsableSpace(len(p)); err != nil {
 		return 0, err
 	}
 
-	var modifTime core.DateTime
+	defer func() {
+		if n > 0 || err == nil {
+			f.updateModificationTime()
+		}
+	}()
 
-	func() {
-		f.fs.lastModificationTimesLock.Lock()
-		defer f.fs.lastModificationTimesLock.Unlock()
+	//TODO: prevent leaks about underlying file
+	return f.underlying.Write(p)
+}
 
-		modifTime = core.DateTime(time.Now())
-		f.fs.lastModificationTimes[f.normalizedPath] = modifTime
-	}()
+// append writes p at the end of the file. The lock shared by all the files opened in append mode with the same path
+// is held during the operation, this way the end of the file cannot change between the seek and the write.
+func (f *metaFsFile) append(p []byte) (n int, err error) {
+	f.appendLock.Lock()
+	defer f.appendLock.Unlock()
+
+	//all appended bytes are new bytes.
+	if err := f.checkUsableSpace(len(p)); err != nil {
+		return 0, err
+	}
 
 	defer func() {
-		if err != nil {
-			return
+		if n < len(p) {
+			f.fs.releaseAddedByteCount(core.ByteCount(len(p) - n))
+		}
+		if n > 0 || err == nil {
+			f.updateModificationTime()
 		}
-
-		//add event
-		f.fs.eventQueue.Enqueue(Event{
-			path:     f.path,
-			writeOp:  true,
-			dateTime: modifTime,
-		})
 	}()
 
+	if _, err := f.underlying.Seek(0, io.SeekEnd); err != nil {
+		return 0, err
+	}
+
 	//TODO: prevent leaks about underlying file
 	return f.underlying.Write(p)
 }
 
+// updateModificationTime updates the last modification time of the file and adds a write event, both operations
+// are performed while holding the lock of lastModificationTimes. This way a watcher that calls Stat upon receiving
+// the event sees (at least) the event's modification time, and write events are added in chronological order.
+func (f *metaFsFile) updateModificationTime() {
+	f.fs.lastModificationTimesLock.Lock()
+	defer f.fs.lastModificationTimesLock.Unlock()
+
+	modifTime := core.DateTime(time.Now())
+	f.fs.lastModificationTimes[f.normalizedPath] = modifTime
+	f.modified.Store(true)
+
+	if f.metadata.concreteFile != nil {
+		f.fs.invalidateCachedContent(*f.metadata.concreteFile)
+
+		//the size of the files sharing the concrete file may have changed.
+		if f.fs.statCache != nil {
+			f.fs.statCache.invalidateConcreteFile(*f.metadata.concreteFile)
+		}
+	}
+
+	//add event
+	f.fs.eventQueue.Enqueue(Event{
+		path:     f.path,
+		writeOp:  true,
+		dateTime: modifTime,
+	})
+}
+
 func (f *metaFsFile) Read(p []byte) (n int, err error) {
+	defer f.fs.startOp(METAFS_READ_OP)(&err)
+
 	if f.closed.Load() {
 		return 0, os.ErrClosed
 	}
@@ -101,6 +165,8 @@ func (f *metaFsFile) Read(p []byte) (n int, err error) {
 }
 
 func (f *metaFsFile) ReadAt(p []byte, off int64) (n int, err error) {
+	defer f.fs.startOp(METAFS_READ_OP)(&err)
+
 	if f.closed.Load() {
 		return 0, os.ErrClosed
 	}
@@ -130,6 +196,8 @@ func (f *metaFsFile) Close() error {
 	} else {
 		f.closed.Store(true)
 	}
+
+	f.mirrorWriteIfModified()
 	return nil
 }
 
@@ -165,29 +233,13 @@ func (f *metaFsFile) Truncate(size int64) error {
 		}
 	}
 
-	var modifTime core.DateTime
-
-	func() {
-		f.fs.lastModificationTimesLock.Lock()
-		defer f.fs.lastModificationTimesLock.Unlock()
-
-		modifTime = core.DateTime(time.Now())
-		f.fs.lastModificationTimes[f.normalizedPath] = modifTime
-	}()
-
 	err := f.underlying.Truncate(size)
 	if err != nil {
 		f.fs.ctx.Logger().Err(err).Msg("failed to close metafs file " + string(f.metadata.path))
 		return fmt.Errorf("failed to truncate %s", f.metadata.path)
 	}
 
-	//add event
-	f.fs.eventQueue.Enqueue(Event{
-		path:     f.path,
-		writeOp:  true,
-		dateTime: modifTime,
-	})
-
+	f.updateModificationTime()
 	return nil
 }
 
@@ -195,5 +247,42 @@ func (f *metaFsFile) Sync() error {
 	if f.closed.Load() {
 		return os.ErrClosed
 	}
+
+	if err := f.underlying.Sync(); err != nil {
+		return err
+	}
+
+	f.mirrorWriteIfModified()
+	return nil
+}
+
+// syncWhileFsLocked is the equivalent of Sync for when the lock of the filesystem is held by the caller.
+func (f *metaFsFile) syncWhileFsLocked() error {
+	if f.closed.Load() {
+		return os.ErrClosed
+	}
+
+	if err := f.syncUnderlyingWhileFsLocked(); err != nil {
+		return err
+	}
+
+	f.mirrorWriteIfModified()
+	return nil
+}
+
+// syncUnderlyingWhileFsLocked syncs the underlying file while the lock of the filesystem is held, compressed files
+// acquire this lock in their Sync method (see compressedFile.flush).
+func (f *metaFsFile) syncUnderlyingWhileFsLocked() error {
+	if compressed, ok := f.underlying.(*compressedFile); ok {
+		return compressed.syncWhileFsLocked()
+	}
 	return f.underlying.Sync()
 }
+
+// mirrorWriteIfModified copies the content of the file to the mirror of the filesystem if the file has been modified
+// since the last copy, nothing is done if the filesystem has no mirror.
+func (f *metaFsFile) mirrorWriteIfModified() {
+	if f.fs.mirror != nil && f.modified.CompareAndSwap(true, false) {
+		f.fs.mirror.addWrite(f.metadata)
+	}
+}
@@ -0,0 +1,202 @@
+package fs_ns
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/inoxlang/inox/internal/core"
+)
+
+const (
+	MissingConcreteFile IntegrityIssueKind = iota + 1
+	UnreadableConcreteFileSize
+	DanglingChildReference
+	OrphanedMetadata
+)
+
+// An IntegrityIssue is an inconsistency found by (*MetaFilesystem).VerifyIntegrity.
+type IntegrityIssue struct {
+	Kind IntegrityIssueKind
+
+	//normalized path of the file or directory concerned by the issue,
+	//for a dangling child reference this is the path of the missing child.
+	Path string
+
+	Details string
+}
+
+func (i IntegrityIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Kind, i.Path, i.Details)
+}
+
+type IntegrityIssueKind int
+
+func (k IntegrityIssueKind) String() string {
+	switch k {
+	case MissingConcreteFile:
+		return "missing-concrete-file"
+	case UnreadableConcreteFileSize:
+		return "unreadable-concrete-file-size"
+	case DanglingChildReference:
+		return "dangling-child-reference"
+	case OrphanedMetadata:
+		return "orphaned-metadata"
+	default:
+		return "unknown"
+	}
+}
+
+// VerifyIntegrity checks the consistency of the metadata and the underlying files, all the issues found are returned.
+// The following checks are performed:
+// - the concrete file of each non-dir file exists and its size is readable.
+// - each child listed by a directory has metadata.
+// - each metadata entry has a parent directory that lists it (no orphaned entry).
+func (fls *MetaFilesystem) VerifyIntegrity(ctx *core.Context) ([]IntegrityIssue, error) {
+	if fls.closed.Load() {
+		return nil, ErrClosedFilesystem
+	}
+
+	fls.lock.RLock()
+	defer fls.lock.RUnlock()
+
+	entries, err := fls.getAllFileMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedPaths := make([]string, 0, len(entries))
+	for normalizedPath := range entries {
+		normalizedPaths = append(normalizedPaths, normalizedPath)
+	}
+	slices.Sort(normalizedPaths)
+
+	var issues []IntegrityIssue
+
+	for _, normalizedPath := range normalizedPaths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		metadata := entries[normalizedPath]
+
+		//check that the parent directory exists and lists the file.
+		if normalizedPath != "/" {
+			parentPath := filepath.Dir(normalizedPath)
+			parentMetadata, ok := entries[parentPath]
+
+			switch {
+			case !ok:
+				issues = append(issues, IntegrityIssue{
+					Kind:    OrphanedMetadata,
+					Path:    normalizedPath,
+					Details: fmt.Sprintf("parent directory %s has no metadata", parentPath),
+				})
+			case !slices.Contains(parentMetadata.children, core.String(filepath.Base(normalizedPath))):
+				issues = append(issues, IntegrityIssue{
+					Kind:    OrphanedMetadata,
+					Path:    normalizedPath,
+					Details: fmt.Sprintf("parent directory %s does not list the file", parentPath),
+				})
+			}
+		}
+
+		if metadata.mode.IsDir() {
+			//check that all children have metadata.
+			for _, childName := range metadata.children {
+				childPath := filepath.Join(normalizedPath, string(childName))
+				if _, ok := entries[childPath]; !ok {
+					issues = append(issues, IntegrityIssue{
+						Kind:    DanglingChildReference,
+						Path:    childPath,
+						Details: fmt.Sprintf("directory %s lists a child that has no metadata", normalizedPath),
+					})
+				}
+			}
+			continue
+		}
+
+		if metadata.symlinkTarget != nil {
+			continue
+		}
+
+		//check that the concrete file exists and that its size is readable.
+		if metadata.concreteFile == nil {
+			issues = append(issues, IntegrityIssue{
+				Kind:    MissingConcreteFile,
+				Path:    normalizedPath,
+				Details: "no concrete file is set",
+			})
+			continue
+		}
+
+		concreteFile := metadata.concreteFile.UnderlyingString()
+		_, err := fls.underlying.Stat(concreteFile)
+
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			issues = append(issues, IntegrityIssue{
+				Kind:    MissingConcreteFile,
+				Path:    normalizedPath,
+				Details: fmt.Sprintf("concrete file %s does not exist", concreteFile),
+			})
+		case err != nil:
+			issues = append(issues, IntegrityIssue{
+				Kind:    UnreadableConcreteFileSize,
+				Path:    normalizedPath,
+				Details: fmt.Sprintf("failed to get the size of the concrete file %s: %s", concreteFile, err.Error()),
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// getAllFileMetadata reads all the file metadata entries, including the ones that are not reachable from the root directory.
+// The modification times tracked in memory are ignored.
+func (fls *MetaFilesystem) getAllFileMetadata() (map[ /*normalized path*/ string]*metaFsFileMetadata, error) {
+	tx, err := fls.beginMetadataTx(false)
+	if err != nil {
+		return nil, err
+	}
+	// Read-only transactions can only be rolled back, not committed.
+	defer tx.Rollback()
+
+	entries := map[string]*metaFsFileMetadata{}
+	var parsingErr error
+
+	err = tx.Ascend("", func(key, value string) (_continue bool) {
+		if key != METAFS_FILES_KEY && !strings.HasPrefix(key, METAFS_FILES_KEY+"/") {
+			return true
+		}
+
+		normalizedPath := "/"
+		if key != METAFS_FILES_KEY {
+			normalizedPath = NormalizeAsAbsolute(strings.TrimPrefix(key, METAFS_FILES_KEY))
+		}
+		metadata := &metaFsFileMetadata{path: core.PathFrom(normalizedPath)}
+
+		if err := metadata.initFromJSON(value, false, core.DateTime{}); err != nil {
+			parsingErr = fmtFailedToGetFileMetadataError(metadata.path, err)
+			return false
+		}
+
+		if metadata.mode.IsDir() {
+			metadata.path = core.DirPathFrom(normalizedPath)
+		}
+
+		entries[normalizedPath] = metadata
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	if parsingErr != nil {
+		return nil, parsingErr
+	}
+	return entries, nil
+}
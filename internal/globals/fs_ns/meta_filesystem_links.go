@@ -0,0 +1,258 @@
+package fs_ns
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/inoxlang/inox/internal/buntdb"
+	"github.com/inoxlang/inox/internal/core"
+)
+
+var (
+	ErrCannotLinkContentOfNonRegularFile = errors.New("cannot link the content of a directory or symlink")
+)
+
+// LinkContent creates a file at newPath that shares the concrete file of the regular file at existing,
+// the parent directory of newPath is created if necessary. Writes to any of the paths are visible
+// from the other ones, as with hard links. The number of paths referencing a concrete file is stored
+// in the metadata: removing a path only removes the concrete file if no other path references it.
+func (fls *MetaFilesystem) LinkContent(existing, newPath core.Path) error {
+	if fls.closed.Load() {
+		return ErrClosedFilesystem
+	}
+
+	fls.lock.Lock()
+	defer fls.lock.Unlock()
+
+	existingPath := core.PathFrom(NormalizeAsAbsolute(existing.UnderlyingString()))
+	normalizedNewPath := NormalizeAsAbsolute(newPath.UnderlyingString())
+	linkPath := core.PathFrom(normalizedNewPath)
+
+	noIssue := false
+	tx, err := fls.beginMetadataTx(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if noIssue {
+			tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+	}()
+
+	metadata, exists, err := fls.getFileMetadata(existingPath, tx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", os.ErrNotExist, existingPath)
+	}
+	if metadata.mode.IsDir() || isSymlink(metadata.mode) || metadata.concreteFile == nil {
+		return fmt.Errorf("%w: %s", ErrCannotLinkContentOfNonRegularFile, existingPath)
+	}
+
+	_, exists, err = fls.getFileMetadata(linkPath, tx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: %s", os.ErrExist, linkPath)
+	}
+
+	//make sure the parent exists and add the new file to its children.
+	dir := filepath.Dir(normalizedNewPath)
+	if dir != "/" {
+		if err := fls.MkdirAllNoLock_(dir, METAFS_AUTO_CREATED_DIR_PERM, tx); err != nil {
+			return fmt.Errorf("failed to create %s", dir)
+		}
+	}
+
+	dirMetadata, found, err := fls.getFileMetadata(core.DirPathFrom(dir), tx)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("failed to create %s: parent directory %s does not exist", linkPath, dir)
+	}
+
+	creationTime := core.DateTime(time.Now())
+
+	dirMetadata.children = append(dirMetadata.children, linkPath.Basename())
+	dirMetadata.modificationTime = creationTime
+	if err := fls.setFileMetadata(dirMetadata, tx); err != nil {
+		return err
+	}
+
+	concreteFile := *metadata.concreteFile
+
+	linkMetadata := &metaFsFileMetadata{
+		path:             linkPath,
+		concreteFile:     &concreteFile,
+		mode:             metadata.mode,
+		creationTime:     creationTime,
+		modificationTime: creationTime,
+		compressed:       metadata.compressed,
+	}
+
+	if err := fls.setFileMetadata(linkMetadata, tx); err != nil {
+		return err
+	}
+
+	refCount, err := fls.getConcreteFileRefCount(concreteFile, tx)
+	if err != nil {
+		return err
+	}
+
+	if err := fls.setConcreteFileRefCount(concreteFile, refCount+1, tx); err != nil {
+		return err
+	}
+
+	noIssue = true
+
+	fls.eventQueue.EnqueueAutoRemove(Event{
+		path:     linkPath,
+		createOp: true,
+		dateTime: creationTime,
+	})
+
+	if fls.mirror != nil {
+		fls.mirror.addWrite(linkMetadata)
+	}
+
+	return nil
+}
+
+// getConcreteFileRefCount returns the number of paths referencing a concrete file, concrete files that have never been
+// linked are only referenced by the path they have been created for so the count defaults to 1.
+func (fls *MetaFilesystem) getConcreteFileRefCount(concreteFile core.Path, tx *buntdb.Tx) (int, error) {
+	serialized, err := tx.Get(getConcreteFileRefCountKey(concreteFile))
+	if err != nil {
+		if errors.Is(err, buntdb.ErrNotFound) {
+			return 1, nil
+		}
+		return 0, err
+	}
+
+	count, err := strconv.Atoi(serialized)
+	if err != nil {
+		return 0, fmt.Errorf("invalid reference count for concrete file %s: %w", concreteFile, err)
+	}
+	return count, nil
+}
+
+// setConcreteFileRefCount stores the number of paths referencing a concrete file, the entry is deleted
+// if count is less or equal to 1.
+func (fls *MetaFilesystem) setConcreteFileRefCount(concreteFile core.Path, count int, tx *buntdb.Tx) error {
+	key := getConcreteFileRefCountKey(concreteFile)
+
+	if count <= 1 {
+		_, err := tx.Delete(key)
+		if err != nil && !errors.Is(err, buntdb.ErrNotFound) {
+			return err
+		}
+		return nil
+	}
+
+	_, _, err := tx.Set(key, strconv.Itoa(count), nil)
+	return err
+}
+
+// releaseConcreteFile decrements the reference count of a concrete file and removes the file if it
+// is no longer referenced. The returned size is the size of the removed file.
+func (fls *MetaFilesystem) releaseConcreteFile(concreteFile core.Path, tx *buntdb.Tx) (removed bool, size core.ByteCount, _ error) {
+	unreferenced, err := fls.decrementConcreteFileRefCount(concreteFile, tx)
+	if err != nil || !unreferenced {
+		return false, 0, err
+	}
+
+	size, err = fls.removeConcreteFile(concreteFile)
+	if err != nil {
+		return false, 0, err
+	}
+	return true, size, nil
+}
+
+// decrementConcreteFileRefCount decrements the reference count of a concrete file, true is returned if the
+// file is no longer referenced, in this case its original size is also deleted.
+func (fls *MetaFilesystem) decrementConcreteFileRefCount(concreteFile core.Path, tx *buntdb.Tx) (unreferenced bool, _ error) {
+	refCount, err := fls.getConcreteFileRefCount(concreteFile, tx)
+	if err != nil {
+		return false, err
+	}
+
+	if refCount > 1 {
+		return false, fls.setConcreteFileRefCount(concreteFile, refCount-1, tx)
+	}
+
+	if err := fls.setConcreteFileRefCount(concreteFile, 0, tx); err != nil {
+		return false, err
+	}
+
+	_, err = tx.Delete(getConcreteFileOriginalSizeKey(concreteFile))
+	if err != nil && !errors.Is(err, buntdb.ErrNotFound) {
+		return false, err
+	}
+	return true, nil
+}
+
+// removeConcreteFile removes a concrete file and returns its size, no error is returned if the file does not exist.
+func (fls *MetaFilesystem) removeConcreteFile(concreteFile core.Path) (core.ByteCount, error) {
+	var size core.ByteCount
+	if info, err := fls.underlying.Stat(concreteFile.UnderlyingString()); err == nil {
+		size = core.ByteCount(info.Size())
+	}
+
+	err := fls.underlying.Remove(concreteFile.UnderlyingString())
+	fls.invalidateCachedContent(concreteFile)
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	return size, nil
+}
+
+func getConcreteFileRefCountKey(concreteFile core.Path) string {
+	return METAFS_CONCRETE_FILE_REFCOUNTS_KEY + NormalizeAsAbsolute(concreteFile.UnderlyingString())
+}
+
+// getConcreteFileOriginalSize returns the original (decompressed) size of a compressed concrete file, the size of
+// a concrete file that has never been written to is zero. If tx is nil a temporary transaction is used.
+func (fls *MetaFilesystem) getConcreteFileOriginalSize(concreteFile core.Path, tx *buntdb.Tx) (core.ByteCount, error) {
+	if tx == nil {
+		var err error
+		tx, err = fls.metadata.Begin(false)
+		if err != nil {
+			return 0, err
+		}
+		defer tx.Rollback()
+	}
+
+	serialized, err := tx.Get(getConcreteFileOriginalSizeKey(concreteFile))
+	if err != nil {
+		if errors.Is(err, buntdb.ErrNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	size, err := strconv.ParseInt(serialized, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid original size for concrete file %s: %w", concreteFile, err)
+	}
+	return core.ByteCount(size), nil
+}
+
+// setConcreteFileOriginalSize stores the original (decompressed) size of a compressed concrete file.
+func (fls *MetaFilesystem) setConcreteFileOriginalSize(concreteFile core.Path, size core.ByteCount, tx *buntdb.Tx) error {
+	_, _, err := tx.Set(getConcreteFileOriginalSizeKey(concreteFile), strconv.FormatInt(int64(size), 10), nil)
+	return err
+}
+
+func getConcreteFileOriginalSizeKey(concreteFile core.Path) string {
+	return METAFS_CONCRETE_FILE_ORIGINAL_SIZES_KEY + NormalizeAsAbsolute(concreteFile.UnderlyingString())
+}
@@ -0,0 +1,44 @@
+package fs_ns
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	//names of the operations passed to MetaFilesystemMetrics.RecordOp.
+
+	METAFS_CREATE_OP   = "create"
+	METAFS_OPEN_OP     = "open"
+	METAFS_READ_OP     = "read"
+	METAFS_WRITE_OP    = "write"
+	METAFS_DELETE_OP   = "delete"
+	METAFS_SNAPSHOT_OP = "snapshot"
+)
+
+// MetaFilesystemMetrics records the operations performed on a MetaFilesystem, it allows the operations to be monitored
+// (e.g. by exporting Prometheus metrics) without the filesystem depending on a metrics library. RecordOp is called after
+// each operation with the name of the operation (METAFS_CREATE_OP, METAFS_OPEN_OP, ...), its duration and the error it
+// returned. io.EOF is not considered to be an error. Implementations should be thread safe and fast.
+type MetaFilesystemMetrics interface {
+	RecordOp(name string, dur time.Duration, err error)
+}
+
+// startOp returns a function recording an operation that starts now, the function should be deferred and called with
+// a pointer to the error returned by the operation. Nothing is recorded if the filesystem has no metrics recorder.
+func (fls *MetaFilesystem) startOp(name string) func(err *error) {
+	if fls.metrics == nil {
+		return func(err *error) {}
+	}
+
+	start := time.Now()
+
+	return func(err *error) {
+		opErr := *err
+		if errors.Is(opErr, io.EOF) {
+			opErr = nil
+		}
+		fls.metrics.RecordOp(name, time.Since(start), opErr)
+	}
+}
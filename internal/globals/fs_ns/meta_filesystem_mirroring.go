@@ -0,0 +1,174 @@
+package fs_ns
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/inoxlang/inox/internal/core"
+	"github.com/inoxlang/inox/internal/utils"
+)
+
+// A metaFsMirror asynchronously applies the writes and removals of files of a MetaFilesystem to a secondary storage,
+// see MetaFilesystemParams.Mirror. The operations are applied in order by a single goroutine: renames and content links
+// are mirrored as removals and writes.
+type metaFsMirror struct {
+	fls     *MetaFilesystem
+	storage billy.Basic
+	onError func(path core.Path, err error) //can be nil
+
+	lock    sync.Mutex
+	pending []mirrorOperation
+	closed  bool
+
+	wakeUp  chan struct{} //buffered (1), signals that there are pending operations or that the mirror is closed.
+	stopped chan struct{} //closed when the goroutine applying the operations stops.
+}
+
+type mirrorOperation struct {
+	path    core.Path
+	removal bool
+
+	//copy of the metadata of the written file at the time of the write, nil for removals.
+	written *metaFsFileMetadata
+}
+
+func newMetaFsMirror(fls *MetaFilesystem, storage billy.Basic, onError func(path core.Path, err error)) *metaFsMirror {
+	mirror := &metaFsMirror{
+		fls:     fls,
+		storage: storage,
+		onError: onError,
+		wakeUp:  make(chan struct{}, 1),
+		stopped: make(chan struct{}),
+	}
+
+	go mirror.applyOperations()
+	return mirror
+}
+
+// addWrite adds an operation copying the current content of a file to the mirror.
+func (m *metaFsMirror) addWrite(metadata *metaFsFileMetadata) {
+	written := *metadata
+	m.add(mirrorOperation{path: metadata.path, written: &written})
+}
+
+// addRemovals adds operations removing files from the mirror.
+func (m *metaFsMirror) addRemovals(paths []core.Path) {
+	for _, path := range paths {
+		m.add(mirrorOperation{path: path, removal: true})
+	}
+}
+
+func (m *metaFsMirror) add(op mirrorOperation) {
+	m.lock.Lock()
+
+	if m.closed {
+		m.lock.Unlock()
+		m.handleError(op.path, ErrClosedFilesystem)
+		return
+	}
+
+	m.pending = append(m.pending, op)
+	m.lock.Unlock()
+
+	select {
+	case m.wakeUp <- struct{}{}:
+	default:
+	}
+}
+
+// close waits for the pending operations to be applied and stops the goroutine applying them.
+func (m *metaFsMirror) close() {
+	m.lock.Lock()
+	alreadyClosed := m.closed
+	m.closed = true
+	m.lock.Unlock()
+
+	if !alreadyClosed {
+		select {
+		case m.wakeUp <- struct{}{}:
+		default:
+		}
+	}
+
+	<-m.stopped
+}
+
+func (m *metaFsMirror) applyOperations() {
+	defer close(m.stopped)
+
+	for range m.wakeUp {
+		m.lock.Lock()
+		operations := m.pending
+		m.pending = nil
+		closed := m.closed
+		m.lock.Unlock()
+
+		for _, op := range operations {
+			func() {
+				defer utils.Recover()
+
+				var err error
+				if op.removal {
+					err = m.applyRemoval(op)
+				} else {
+					err = m.applyWrite(op)
+				}
+
+				if err != nil {
+					m.handleError(op.path, err)
+				}
+			}()
+		}
+
+		if closed {
+			return
+		}
+	}
+}
+
+func (m *metaFsMirror) applyWrite(op mirrorOperation) error {
+	content, err := m.fls.openConcreteFileContent(op.written)
+	if errors.Is(err, os.ErrNotExist) {
+		//the file has been removed since the write, its removal will be mirrored.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if dirCapable, ok := m.storage.(billy.Dir); ok {
+		if err := dirCapable.MkdirAll(filepath.Dir(op.path.UnderlyingString()), METAFS_AUTO_CREATED_DIR_PERM); err != nil {
+			return err
+		}
+	}
+
+	file, err := m.storage.OpenFile(op.path.UnderlyingString(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, op.written.mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, content)
+	return errors.Join(err, file.Close())
+}
+
+func (m *metaFsMirror) applyRemoval(op mirrorOperation) error {
+	err := util.RemoveAll(m.storage, op.path.UnderlyingString())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (m *metaFsMirror) handleError(path core.Path, err error) {
+	if m.onError != nil {
+		m.onError(path, err)
+		return
+	}
+	m.fls.ctx.Logger().Err(err).Msg("failed to mirror an operation on " + path.UnderlyingString())
+}
@@ -0,0 +1,265 @@
+package fs_ns
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/inoxlang/inox/internal/buntdb"
+	"github.com/inoxlang/inox/internal/core"
+	"github.com/inoxlang/inox/internal/utils"
+)
+
+// RemoveAll removes the file or directory at path and all its descendants, it does nothing if the file does not exist.
+// The descendants are removed depth-first and their metadata deletions are committed in batches of METAFS_REMOVE_ALL_BATCH_SIZE,
+// the removed file is detached from its parent directory at the end. If an error occurs the descendants removed so far stay
+// removed and calling RemoveAll again completes the removal. Removing the root directory only removes its descendants.
+func (fls *MetaFilesystem) RemoveAll(path core.Path) (finalErr error) {
+	defer fls.startOp(METAFS_DELETE_OP)(&finalErr)
+
+	return fls.removeAll(path, METAFS_REMOVE_ALL_BATCH_SIZE)
+}
+
+func (fls *MetaFilesystem) removeAll(path core.Path, batchSize int) error {
+	if fls.closed.Load() {
+		return ErrClosedFilesystem
+	}
+
+	fls.lock.Lock()
+	defer fls.lock.Unlock()
+
+	normalizedPath := NormalizeAsAbsolute(path.UnderlyingString())
+	pth := core.PathFrom(normalizedPath)
+
+	remover := &metaFsSubtreeRemover{
+		fls:       fls,
+		batchSize: max(batchSize, 1),
+	}
+
+	defer remover.addEvents()
+
+	//begin the first batch, this also commits the pending metadata writes.
+	tx, err := fls.beginMetadataTx(true)
+	if err != nil {
+		return err
+	}
+	remover.tx = tx
+
+	defer func() {
+		if remover.tx != nil {
+			remover.tx.Rollback()
+		}
+	}()
+
+	metadata, exists, err := fls.getFileMetadata(pth, remover.tx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	if metadata.mode.IsDir() {
+		if err := remover.removeDescendants(metadata); err != nil {
+			return err
+		}
+	}
+
+	now := core.DateTime(time.Now())
+
+	if normalizedPath == "/" {
+		metadata.children = nil
+		metadata.modificationTime = now
+		if err := fls.setFileMetadata(metadata, remover.tx); err != nil {
+			return err
+		}
+		return remover.commit()
+	}
+
+	//detach the file from its parent and remove it in the same transaction.
+	parentMetadata, exists, err := fls.getFileMetadata(core.DirPathFrom(filepath.Dir(normalizedPath)), remover.tx)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		for index, childName := range parentMetadata.children {
+			if childName == pth.Basename() {
+				parentMetadata.children = utils.RemoveIndexOfSlice(parentMetadata.children, index)
+				break
+			}
+		}
+
+		parentMetadata.modificationTime = now
+		if err := fls.setFileMetadata(parentMetadata, remover.tx); err != nil {
+			return err
+		}
+	}
+
+	if err := remover.removeFile(metadata, false); err != nil {
+		return err
+	}
+
+	return remover.commit()
+}
+
+// metaFsSubtreeRemover removes files from a MetaFilesystem, the metadata deletions are committed in batches.
+type metaFsSubtreeRemover struct {
+	fls       *MetaFilesystem
+	tx        *buntdb.Tx //current batch, nil after the last commit
+	batchSize int
+
+	batchDeletionCount int
+	noCheckFuel        int
+
+	//concrete files that are no longer referenced by the metadata of the current batch, they are removed
+	//after the batch is committed.
+	unreferencedConcreteFiles []core.Path
+
+	//removals of the current batch, they are moved to removed after the batch is committed.
+	batchRemoved      []core.Path
+	batchRemovalTimes []time.Time
+
+	removed      []core.Path
+	removalTimes []time.Time
+}
+
+// removeDescendants removes the descendants of dir depth-first: a directory is removed after all its descendants,
+// so an interrupted removal never leaves metadata that is unreachable from the removed directory.
+func (r *metaFsSubtreeRemover) removeDescendants(dir *metaFsFileMetadata) error {
+	for _, childPath := range dir.ChildrenPaths() {
+		child, exists, err := r.fls.getFileMetadata(childPath, r.tx)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			//already removed by a previous (interrupted) removal.
+			continue
+		}
+
+		if child.mode.IsDir() {
+			if err := r.removeDescendants(child); err != nil {
+				return err
+			}
+		}
+
+		if err := r.removeFile(child, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeFile removes the concrete file of a file and deletes its metadata. If commitFullBatch is true and
+// the current batch is full, the batch is committed and a new one is started.
+func (r *metaFsSubtreeRemover) removeFile(metadata *metaFsFileMetadata, commitFullBatch bool) error {
+	fls := r.fls
+
+	if r.noCheckFuel <= 0 { //check context
+		select {
+		case <-fls.ctx.Done():
+			return fls.ctx.Err()
+		default:
+		}
+		r.noCheckFuel = 10
+	} else {
+		r.noCheckFuel--
+	}
+
+	//the concrete file is only removed after the commit of the batch if it is not referenced by other paths,
+	//this way the metadata never points to a missing concrete file.
+	if metadata.concreteFile != nil {
+		unreferenced, err := fls.decrementConcreteFileRefCount(*metadata.concreteFile, r.tx)
+		if err != nil {
+			return err
+		}
+		if unreferenced {
+			r.unreferencedConcreteFiles = append(r.unreferencedConcreteFiles, *metadata.concreteFile)
+		}
+	}
+
+	if err := fls.deleteFileMetadata(metadata.path, r.tx); err != nil {
+		return err
+	}
+
+	normalizedPath := NormalizeAsAbsolute(metadata.path.UnderlyingString())
+
+	fls.lastModificationTimesLock.Lock()
+	delete(fls.lastModificationTimes, normalizedPath)
+	fls.lastModificationTimesLock.Unlock()
+	delete(fls.appendLocks, normalizedPath)
+
+	r.batchRemoved = append(r.batchRemoved, metadata.path)
+	r.batchRemovalTimes = append(r.batchRemovalTimes, time.Now())
+	r.batchDeletionCount++
+
+	if !commitFullBatch || r.batchDeletionCount < r.batchSize {
+		return nil
+	}
+
+	if err := r.commit(); err != nil {
+		return err
+	}
+
+	tx, err := fls.beginMetadataTx(true)
+	if err != nil {
+		return err
+	}
+	r.tx = tx
+	return nil
+}
+
+// commit commits the current batch and then removes the concrete files that are no longer referenced,
+// the space they were using is reclaimed.
+func (r *metaFsSubtreeRemover) commit() error {
+	tx := r.tx
+	r.tx = nil
+	r.batchDeletionCount = 0
+
+	unreferencedConcreteFiles := r.unreferencedConcreteFiles
+	batchRemoved, batchRemovalTimes := r.batchRemoved, r.batchRemovalTimes
+	r.unreferencedConcreteFiles = nil
+	r.batchRemoved, r.batchRemovalTimes = nil, nil
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	r.removed = append(r.removed, batchRemoved...)
+	r.removalTimes = append(r.removalTimes, batchRemovalTimes...)
+
+	var removalErrs []error
+
+	for _, concreteFile := range unreferencedConcreteFiles {
+		size, err := r.fls.removeConcreteFile(concreteFile)
+		if err != nil {
+			removalErrs = append(removalErrs, err)
+			continue
+		}
+		r.fls.releaseAddedByteCount(size)
+	}
+
+	return utils.CombineErrors(removalErrs...)
+}
+
+func (r *metaFsSubtreeRemover) addEvents() {
+	defer utils.Recover()
+
+	//note: the events are not added one by one in order to reduce the number of lockings.
+	events := make([]Event, len(r.removed))
+
+	for i, path := range r.removed {
+		events[i] = Event{
+			path:     path,
+			removeOp: true,
+			dateTime: core.DateTime(r.removalTimes[i]),
+		}
+	}
+
+	r.fls.eventQueue.EnqueueAllAutoRemove(events...)
+
+	if r.fls.mirror != nil {
+		r.fls.mirror.addRemovals(r.removed)
+	}
+}
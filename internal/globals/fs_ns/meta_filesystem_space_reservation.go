@@ -0,0 +1,113 @@
+package fs_ns
+
+import (
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/inoxlang/inox/internal/core"
+)
+
+// A SpaceReservation is a part of the usable space reserved by ReserveSpace for a batch operation. Only the writes
+// performed through the reservation (files opened by its OpenFile and Create methods, WriteFileAtomic) consume the
+// reserved space, other writes only see the unreserved space.
+type SpaceReservation struct {
+	fls         *MetaFilesystem
+	remaining   core.ByteCount //part that has not been consumed by writes yet, guarded by fls.usedSpaceCacheLock.
+	releaseOnce sync.Once
+}
+
+// ReserveSpace atomically reserves n bytes of the usable space (see MetaFilesystemParams.MaxUsableSpace) for a batch
+// of operations creating many files, such as cloning a repository or extracting an archive. Individual space checks
+// rely on a cached used space, so a batch of writes can collectively exceed the maximum usable space; reserving the
+// total size beforehand prevents that. The writes of the batch operation should be performed through the returned
+// reservation, they consume the reserved space before the unreserved space. ok is false if there is not enough space,
+// Release should be called at the end of the batch operation in order to free the unconsumed space.
+func (fls *MetaFilesystem) ReserveSpace(n core.ByteCount) (reservation *SpaceReservation, ok bool) {
+	if n < 0 || fls.closed.Load() {
+		return nil, false
+	}
+
+	reservation = &SpaceReservation{fls: fls}
+
+	if n == 0 {
+		return reservation, true
+	}
+
+	if err := fls.checkUnderlyingAvailableSpace(n); err != nil {
+		return nil, false
+	}
+
+	//refresh the used space cache if necessary.
+	if _, err := fls.computeUsedSpace(n < METAFS_ALWAYS_CHECK_USED_SPACE_BYTE_COUNT_THRESHOLD); err != nil {
+		return nil, false
+	}
+
+	fls.usedSpaceCacheLock.Lock()
+	defer fls.usedSpaceCacheLock.Unlock()
+
+	if fls.usedSpaceCache+fls.reservedSpace+n > fls.maxUsableSpace {
+		return nil, false
+	}
+
+	reservation.remaining = n
+	fls.reservedSpace += n
+	return reservation, true
+}
+
+// Release frees the unconsumed part of the reservation, calling Release more than once has no effect. The files opened
+// through the reservation are still usable but their writes only see the unreserved space.
+func (r *SpaceReservation) Release() {
+	r.releaseOnce.Do(func() {
+		r.fls.usedSpaceCacheLock.Lock()
+		defer r.fls.usedSpaceCacheLock.Unlock()
+
+		r.fls.reservedSpace -= r.remaining
+		r.remaining = 0
+	})
+}
+
+// Remaining returns the part of the reserved space that has not been consumed by writes yet.
+func (r *SpaceReservation) Remaining() core.ByteCount {
+	r.fls.usedSpaceCacheLock.RLock()
+	defer r.fls.usedSpaceCacheLock.RUnlock()
+	return r.remaining
+}
+
+// Create is like (*MetaFilesystem).Create but the writes to the file consume the reserved space.
+func (r *SpaceReservation) Create(filename string) (billy.File, error) {
+	return r.fls.create(filename, r)
+}
+
+// OpenFile is like (*MetaFilesystem).OpenFile but the writes to the file consume the reserved space.
+func (r *SpaceReservation) OpenFile(filename string, flag int, perm os.FileMode) (billy.File, error) {
+	return r.fls.openFileWithReservation(filename, flag, perm, r)
+}
+
+// WriteFileAtomic is like (*MetaFilesystem).WriteFileAtomic but the written data consumes the reserved space.
+func (r *SpaceReservation) WriteFileAtomic(path core.Path, data []byte, perm fs.FileMode) error {
+	return r.fls.writeFileAtomic(path, data, perm, r)
+}
+
+// consume consumes at most size bytes from the reservation and returns the consumed byte count,
+// fls.usedSpaceCacheLock should be held by the caller. r can be nil.
+func (r *SpaceReservation) consume(size core.ByteCount) core.ByteCount {
+	if r == nil {
+		return 0
+	}
+
+	consumed := min(size, r.remaining)
+	r.remaining -= consumed
+	r.fls.reservedSpace -= consumed
+	return consumed
+}
+
+// available returns the number of bytes that can be consumed from the reservation, fls.usedSpaceCacheLock should be
+// held by the caller. r can be nil.
+func (r *SpaceReservation) available() core.ByteCount {
+	if r == nil {
+		return 0
+	}
+	return r.remaining
+}
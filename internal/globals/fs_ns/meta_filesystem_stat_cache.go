@@ -0,0 +1,182 @@
+package fs_ns
+
+import (
+	"container/list"
+	"os"
+	"sync"
+
+	"github.com/inoxlang/inox/internal/core"
+)
+
+// A metaFsStatCache is a bounded LRU cache of the os.FileInfo returned by Stat, see MetaFilesystemParams.StatCacheSize.
+// Entries are keyed by normalized path. An entry is ignored if the tracked modification time of its file (see
+// MetaFilesystem.lastModificationTimes) changed since the entry was added, it is removed when the metadata of its file
+// is updated or deleted.
+type metaFsStatCache struct {
+	lock       sync.Mutex
+	maxEntries int
+	entries    map[ /*normalized path*/ string]*list.Element
+	lru        *list.List //front: most recently used
+
+	//entries of the files having a concrete file, this allows the files sharing a concrete file to be invalidated
+	//without iterating over all entries.
+	concreteFileEntries map[core.Path]map[*list.Element]struct{}
+
+	//incremented by each invalidation, this prevents an info computed before an invalidation from being added.
+	generation uint64
+}
+
+type statCacheEntry struct {
+	normalizedPath      string
+	info                os.FileInfo
+	concreteFile        core.Path //empty if the file has no concrete file
+	modificationTime    core.DateTime
+	hasModificationTime bool
+}
+
+func newMetaFsStatCache(maxEntries int) *metaFsStatCache {
+	return &metaFsStatCache{
+		maxEntries:          maxEntries,
+		entries:             map[string]*list.Element{},
+		lru:                 list.New(),
+		concreteFileEntries: map[core.Path]map[*list.Element]struct{}{},
+	}
+}
+
+// get returns the cached info of a file if the entry has been added with the same tracked modification time.
+func (c *metaFsStatCache) get(normalizedPath string, modificationTime core.DateTime, hasModificationTime bool) (os.FileInfo, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.entries[normalizedPath]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*statCacheEntry)
+	if entry.hasModificationTime != hasModificationTime || entry.modificationTime != modificationTime {
+		c.removeElement(elem)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+	return entry.info, true
+}
+
+// currentGeneration should be called before computing an info that will be added.
+func (c *metaFsStatCache) currentGeneration() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.generation
+}
+
+// add adds an entry and evicts the least recently used entries if necessary. Nothing is added if an invalidation
+// occurred since generation was retrieved.
+func (c *metaFsStatCache) add(entry *statCacheEntry, generation uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+
+	if elem, ok := c.entries[entry.normalizedPath]; ok {
+		c.removeElement(elem)
+	}
+
+	for c.lru.Len() >= c.maxEntries {
+		c.removeElement(c.lru.Back())
+	}
+
+	elem := c.lru.PushFront(entry)
+	c.entries[entry.normalizedPath] = elem
+
+	if entry.concreteFile != "" {
+		elems, ok := c.concreteFileEntries[entry.concreteFile]
+		if !ok {
+			elems = map[*list.Element]struct{}{}
+			c.concreteFileEntries[entry.concreteFile] = elems
+		}
+		elems[elem] = struct{}{}
+	}
+}
+
+// invalidate removes the entry of a file, it should be called after the metadata of the file is updated or deleted.
+func (c *metaFsStatCache) invalidate(normalizedPath string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.generation++
+
+	if elem, ok := c.entries[normalizedPath]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// invalidateConcreteFile removes the entries of the files sharing a concrete file (see LinkContent), it should be
+// called after the concrete file is modified.
+func (c *metaFsStatCache) invalidateConcreteFile(concreteFile core.Path) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.generation++
+
+	for elem := range c.concreteFileEntries[concreteFile] {
+		c.removeElement(elem)
+	}
+}
+
+func (c *metaFsStatCache) removeElement(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*statCacheEntry)
+	delete(c.entries, entry.normalizedPath)
+
+	if entry.concreteFile != "" {
+		elems := c.concreteFileEntries[entry.concreteFile]
+		delete(elems, elem)
+		if len(elems) == 0 {
+			delete(c.concreteFileEntries, entry.concreteFile)
+		}
+	}
+}
+
+// invalidateCachedStat removes the cached info of a file, nothing is done if the cache is disabled.
+func (fls *MetaFilesystem) invalidateCachedStat(pth core.Path) {
+	if fls.statCache != nil {
+		fls.statCache.invalidate(NormalizeAsAbsolute(pth.UnderlyingString()))
+	}
+}
+
+// statWithCache returns the cached info of a file, or computes the info and adds it to the cache.
+func (fls *MetaFilesystem) statWithCache(normalizedPath string) (os.FileInfo, error) {
+	var modificationTime core.DateTime
+	var hasModificationTime bool
+	func() {
+		fls.lastModificationTimesLock.RLock()
+		defer fls.lastModificationTimesLock.RUnlock()
+		modificationTime, hasModificationTime = fls.lastModificationTimes[normalizedPath]
+	}()
+
+	if info, ok := fls.statCache.get(normalizedPath, modificationTime, hasModificationTime); ok {
+		return info, nil
+	}
+
+	generation := fls.statCache.currentGeneration()
+
+	info, metadata, err := fls.computeFileInfo(normalizedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &statCacheEntry{
+		normalizedPath:      normalizedPath,
+		info:                info,
+		modificationTime:    modificationTime,
+		hasModificationTime: hasModificationTime,
+	}
+	if metadata.concreteFile != nil {
+		entry.concreteFile = *metadata.concreteFile
+	}
+
+	fls.statCache.add(entry, generation)
+	return info, nil
+}
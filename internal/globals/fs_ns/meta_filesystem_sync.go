@@ -0,0 +1,130 @@
+package fs_ns
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/inoxlang/inox/internal/afs"
+	"github.com/inoxlang/inox/internal/core"
+)
+
+// Sync syncs the open writable handles of the file at path, fsyncs its concrete file and persists its metadata
+// (including the last modification time). The pending metadata writes are also committed, see
+// MetaFilesystemParams.MetadataWriteBatchingWindow. Read-only handles are ignored.
+func (fls *MetaFilesystem) Sync(path string) error {
+	if fls.closed.Load() {
+		return ErrClosedFilesystem
+	}
+
+	fls.lock.Lock()
+	defer fls.lock.Unlock()
+
+	normalizedPath := NormalizeAsAbsolute(path)
+
+	if err := fls.syncOpenWritableFiles(normalizedPath); err != nil {
+		return err
+	}
+
+	metadata, exists, err := fls.getFileMetadata(core.PathFrom(normalizedPath), nil)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", os.ErrNotExist, normalizedPath)
+	}
+
+	if err := fls.persistMetadataAndSyncConcreteFile(metadata); err != nil {
+		return err
+	}
+
+	return fls.flushPendingMetadataWrites()
+}
+
+// SyncAll syncs all the open writable files in the same way as Sync and commits the pending metadata writes,
+// see MetaFilesystemParams.MetadataWriteBatchingWindow.
+func (fls *MetaFilesystem) SyncAll() error {
+	if fls.closed.Load() {
+		return ErrClosedFilesystem
+	}
+
+	fls.lock.Lock()
+	defer fls.lock.Unlock()
+
+	fls.untrackSomeClosedFiles(-1)
+
+	for normalizedPath, files := range fls.openFiles {
+		hasWritableFile := false
+		for file := range files {
+			if !file.closed.Load() && !IsReadOnly(file.flag) {
+				hasWritableFile = true
+				break
+			}
+		}
+
+		if !hasWritableFile {
+			continue
+		}
+
+		if err := fls.syncOpenWritableFiles(normalizedPath); err != nil {
+			return err
+		}
+
+		metadata, exists, err := fls.getFileMetadata(core.PathFrom(normalizedPath), nil)
+		if err != nil {
+			return err
+		}
+		if !exists { //removed
+			continue
+		}
+
+		if err := fls.persistMetadataAndSyncConcreteFile(metadata); err != nil {
+			return err
+		}
+	}
+
+	return fls.flushPendingMetadataWrites()
+}
+
+// syncOpenWritableFiles syncs the open handles of a file that are not read-only, fls.lock should be held.
+func (fls *MetaFilesystem) syncOpenWritableFiles(normalizedPath string) error {
+	for file := range fls.openFiles[normalizedPath] {
+		if file.closed.Load() || IsReadOnly(file.flag) {
+			continue
+		}
+
+		if err := file.syncWhileFsLocked(); err != nil && !errors.Is(err, os.ErrClosed) {
+			return fmt.Errorf("failed to sync %s: %w", normalizedPath, err)
+		}
+	}
+	return nil
+}
+
+// persistMetadataAndSyncConcreteFile stores metadata (the last modification time is included by getFileMetadata)
+// and fsyncs the concrete file of non-dir files, fls.lock should be held.
+func (fls *MetaFilesystem) persistMetadataAndSyncConcreteFile(metadata *metaFsFileMetadata) error {
+	if metadata.mode.IsDir() || metadata.concreteFile == nil {
+		return nil
+	}
+
+	if err := fls.setFileMetadata(metadata, nil); err != nil {
+		return err
+	}
+
+	concreteFile, err := fls.underlying.Open(metadata.concreteFile.UnderlyingString())
+	if errors.Is(err, os.ErrNotExist) {
+		//the concrete file of a compressed file is created by the first write of its content.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync %s", metadata.path)
+	}
+	defer concreteFile.Close()
+
+	if syncCapable, ok := concreteFile.(afs.SyncCapable); ok {
+		if err := syncCapable.Sync(); err != nil {
+			return fmt.Errorf("failed to sync %s", metadata.path)
+		}
+	}
+	return nil
+}
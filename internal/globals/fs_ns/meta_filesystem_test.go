@@ -2,14 +2,24 @@ package fs_ns
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/util"
+	"github.com/inoxlang/inox/internal/afs"
+	"github.com/inoxlang/inox/internal/buntdb"
 	"github.com/inoxlang/inox/internal/core"
 	"github.com/inoxlang/inox/internal/utils"
 	"github.com/stretchr/testify/assert"
@@ -41,6 +51,16 @@ func TestMetaFilesystemWithUnderlyingFs(t *testing.T) {
 	}
 }
 
+func TestMetaFilesystemWithUnderlyingFsAndBatchedMetadataWrites(t *testing.T) {
+	result := check.Run(&MetaFsWithUnderlyingFsTestSuite{batchMetadataWrites: true}, &check.RunConf{
+		Verbose: true,
+	})
+
+	if result.Failed > 0 || result.Panicked > 0 {
+		assert.Fail(t, result.String())
+	}
+}
+
 func TestMetaFilesystemWithBasic(t *testing.T) {
 	result := check.Run(&MetaFsTestSuite{}, &check.RunConf{
 		Verbose: true,
@@ -66,8 +86,9 @@ func TestMetaFilesystemWithBasic(t *testing.T) {
 }
 
 type MetaFsWithUnderlyingFsTestSuite struct {
-	closed   bool
-	contexts []*core.Context
+	closed              bool
+	batchMetadataWrites bool
+	contexts            []*core.Context
 
 	BasicTestSuite
 	DirTestSuite
@@ -80,9 +101,14 @@ func (s *MetaFsWithUnderlyingFsTestSuite) SetUpTest(c *check.C) {
 		s.contexts = append(s.contexts, ctx)
 		underlyingFS := NewMemFilesystem(100_000_000)
 
-		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		params := MetaFilesystemParams{
 			Dir: "/metafs/",
-		})
+		}
+		if s.batchMetadataWrites {
+			params.MetadataWriteBatchingWindow = 10 * time.Millisecond
+		}
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, params)
 		if err != nil {
 			panic(err)
 		}
@@ -146,6 +172,20 @@ func (s *MetaFsTestSuite) TearDownTest(c *check.C) {
 	}
 }
 
+// openTestMetaFilesystem opens a meta filesystem on top of a new in-memory filesystem, the returned context is
+// cancelled at the end of the test.
+func openTestMetaFilesystem(t *testing.T, params MetaFilesystemParams) (*core.Context, *MetaFilesystem, *MemFilesystem) {
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	t.Cleanup(ctx.CancelGracefully)
+
+	underlyingFS := NewMemFilesystem(100_000_000)
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, params)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return ctx, fls, underlyingFS
+}
+
 func TestOpenMetaFilesystem(t *testing.T) {
 	t.Run("once", func(t *testing.T) {
 		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
@@ -183,6 +223,42 @@ func TestOpenMetaFilesystem(t *testing.T) {
 		defer fls.Close(ctx)
 	})
 
+	t.Run("from ContextConfig.CreateFilesystem", func(t *testing.T) {
+		underlyingFS := NewMemFilesystem(100_000_000)
+
+		//create a file in order for the initial walk to visit more than the root directory.
+		func() {
+			ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+			defer ctx.CancelGracefully()
+
+			fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{Dir: "/fs"})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("a"), DEFAULT_FILE_FMODE))
+			utils.PanicIfErr(fls.Close(ctx))
+		}()
+
+		//the context passed to CreateFilesystem is not initialized yet.
+		var fls *MetaFilesystem
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{
+			CreateFilesystem: func(ctx *core.Context) (afs.Filesystem, error) {
+				var err error
+				fls, err = OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{Dir: "/fs"})
+				return fls, err
+			},
+		}, nil)
+		defer ctx.CancelGracefully()
+
+		content, err := util.ReadFile(fls, "/a.txt")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte("a"), content)
+		}
+
+		_, err = fls.DirectorySizes(true)
+		assert.NoError(t, err)
+	})
+
 	t.Run("re-open after creation of files and directories", func(t *testing.T) {
 		type testCase struct {
 			name   string
@@ -480,332 +556,3748 @@ func TestOpenMetaFilesystem(t *testing.T) {
 
 }
 
-func TestMetaFilesystemRemoveShouldRemoveConcreteFile(t *testing.T) {
+func TestMetaFilesystemRootStat(t *testing.T) {
 	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
 	defer ctx.CancelGracefully()
 	underlyingFS := NewMemFilesystem(100_000_000)
 
-	//no dir provided
-	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{})
-	if err != nil {
-		panic(err)
-	}
-
-	defer fls.Close(ctx)
-
-	entries, err := underlyingFS.ReadDir("/")
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir: "/fs",
+	})
 	if !assert.NoError(t, err) {
 		return
 	}
-	if !assert.Len(t, entries, 1) { //metadata file
-		return
-	}
+	defer fls.Close(ctx)
 
-	f, err := fls.Create("file.txt")
-	if !assert.NoError(t, err) {
+	rootMetadata, exists, err := fls.getFileMetadata(core.DirPathFrom("/"), nil)
+	if !assert.NoError(t, err) || !assert.True(t, exists) {
 		return
 	}
-	f.Close()
 
-	entries, err = underlyingFS.ReadDir("/")
-	if !assert.NoError(t, err) {
-		return
-	}
-	if !assert.Len(t, entries, 2) {
-		return
-	}
+	for _, path := range []string{"/", "", "."} {
+		t.Run(strconv.Quote(path), func(t *testing.T) {
+			info, err := fls.Stat(path)
+			if !assert.NoError(t, err) {
+				return
+			}
 
-	err = fls.Remove("file.txt")
-	if !assert.NoError(t, err) {
-		return
+			assert.True(t, info.IsDir())
+			assert.Equal(t, os.FileMode(rootMetadata.mode), info.Mode())
+			assert.Equal(t, time.Time(rootMetadata.modificationTime), info.ModTime())
+
+			coreInfo, ok := info.(core.FileInfo)
+			if !assert.True(t, ok) {
+				return
+			}
+			assert.Equal(t, core.DirPathFrom("/"), coreInfo.AbsPath_)
+			assert.Equal(t, rootMetadata.creationTime, coreInfo.CreationTime_)
+		})
 	}
+}
 
-	entries, err = underlyingFS.ReadDir("/")
+func TestMetaFilesystemMkdirAll(t *testing.T) {
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+	underlyingFS := NewMemFilesystem(100_000_000)
+
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir: "/fs",
+	})
 	if !assert.NoError(t, err) {
 		return
 	}
-	if !assert.Len(t, entries, 1) { //metadata file
-		return
-	}
-}
+	defer fls.Close(ctx)
 
-func TestMetaFilesystemFileCountValidation(t *testing.T) {
-	t.Run("exceeding the limit by creating files one by one should be an error", func(t *testing.T) {
-		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
-		defer ctx.CancelGracefully()
-		underlyingFS := NewMemFilesystem(100_000_000)
+	t.Run("existing directory", func(t *testing.T) {
+		if !assert.NoError(t, fls.MkdirAll("/dir", 0o700)) {
+			return
+		}
 
-		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
-			MaxFileCount: 10 + 1, //add one for the metadata file
-			Dir:          "/fs",
-		})
+		infoBefore, err := fls.Stat("/dir")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		if !assert.NoError(t, fls.MkdirAll("/dir", 0o755)) {
+			return
+		}
 
+		info, err := fls.Stat("/dir")
 		if !assert.NoError(t, err) {
 			return
 		}
 
-		for i := 0; i < 10; i++ {
-			f, err := fls.Create("f" + strconv.Itoa(i))
-			if !assert.NoError(t, err) {
-				return
-			}
-			f.Close()
+		assert.True(t, info.IsDir())
+		assert.Equal(t, infoBefore.Mode(), info.Mode())
+		assert.Equal(t, infoBefore.ModTime(), info.ModTime())
+	})
+
+	t.Run("existing file", func(t *testing.T) {
+		if !assert.NoError(t, util.WriteFile(fls, "/file.txt", nil, DEFAULT_FILE_FMODE)) {
+			return
 		}
-		//at this point the file count has reached the maxiumum
 
-		f, err := fls.Create("f10")
-		if f != nil {
-			f.Close()
+		err := fls.MkdirAll("/file.txt", DEFAULT_DIR_FMODE)
+		assert.ErrorIs(t, err, ErrNotDirectory)
+		assert.ErrorIs(t, err, os.ErrExist)
+
+		info, err := fls.Stat("/file.txt")
+		if assert.NoError(t, err) {
+			assert.False(t, info.IsDir())
 		}
+	})
 
-		if !assert.ErrorIs(t, err, ErrMaxFileNumberAlreadyReached) {
+	t.Run("existing file at the path of a parent", func(t *testing.T) {
+		if !assert.NoError(t, util.WriteFile(fls, "/file2.txt", nil, DEFAULT_FILE_FMODE)) {
 			return
 		}
+
+		err := fls.MkdirAll("/file2.txt/dir", DEFAULT_DIR_FMODE)
+		assert.ErrorIs(t, err, ErrNotDirectory)
+
+		_, err = fls.Stat("/file2.txt/dir")
+		assert.ErrorIs(t, err, os.ErrNotExist)
 	})
+}
 
-	t.Run("exceeding the limit by creating files in parallel should be an error", func(t *testing.T) {
-		//flaky test
-		t.Skip()
+func TestMetaFilesystemWriteFileAtomic(t *testing.T) {
 
+	t.Run("create and overwrite", func(t *testing.T) {
 		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
 		defer ctx.CancelGracefully()
 		underlyingFS := NewMemFilesystem(100_000_000)
 
-		//the value is high to make sure some goroutines run at the same time
-		const fileCount = 1000
-
 		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
-			MaxFileCount:             fileCount + 1,  //add one for the metadata file
-			MaxParallelCreationCount: 10 * fileCount, //we set a high value to not have errors
-			Dir:                      "/fs",
+			Dir: "/fs",
 		})
-
 		if !assert.NoError(t, err) {
 			return
 		}
+		defer fls.Close(ctx)
 
-		var errCount atomic.Int32 //error count should be fileCount
-		wg := new(sync.WaitGroup)
-		goroutineCount := 2 * fileCount
-		wg.Add(goroutineCount)
-
-		for i := 0; i < goroutineCount; i++ {
-			go func(i int) {
-				defer wg.Done()
-				f, err := fls.Create("f" + strconv.Itoa(i))
-				if err != nil {
-					errCount.Add(1)
-					return
-				}
-				f.Close()
-			}(i)
+		if !assert.NoError(t, fls.WriteFileAtomic("/dir/file.txt", []byte("hello"), DEFAULT_FILE_FMODE)) {
+			return
 		}
 
-		wg.Wait()
-
-		assert.Zero(t, fls.pendingFileCreations.Load())
-
-		if !assert.Equal(t, int32(fileCount), errCount.Load()) {
+		content, err := util.ReadFile(fls, "/dir/file.txt")
+		if !assert.NoError(t, err) {
 			return
 		}
-	})
+		assert.Equal(t, "hello", string(content))
 
-}
+		entries, err := fls.ReadDir("/dir")
+		if assert.NoError(t, err) && assert.Len(t, entries, 1) {
+			assert.Equal(t, "file.txt", entries[0].Name())
+		}
 
-func TestMetaFilesystemParallelFileCreationValidation(t *testing.T) {
+		fileCountBefore, _ := fls.getUnderlyingFileCount()
 
-	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
-	defer ctx.CancelGracefully()
-	underlyingFS := NewMemFilesystem(100_000_000)
+		if !assert.NoError(t, fls.WriteFileAtomic("/dir/file.txt", []byte("hello world"), DEFAULT_FILE_FMODE)) {
+			return
+		}
 
-	maxParallelCreationCount := int16(100)
+		content, err = util.ReadFile(fls, "/dir/file.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "hello world", string(content))
 
-	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
-		MaxFileCount:             10_000,
-		MaxParallelCreationCount: maxParallelCreationCount,
-		Dir:                      "/fs",
+		//the previous concrete file should have been removed.
+		fileCountAfter, _ := fls.getUnderlyingFileCount()
+		assert.Equal(t, fileCountBefore, fileCountAfter)
 	})
 
-	if !assert.NoError(t, err) {
-		return
-	}
-
-	var errCount atomic.Int32 //error count should be fileCount
-
-	wg := new(sync.WaitGroup)
-	goroutineCount := int(maxParallelCreationCount + maxParallelCreationCount/10)
-	wg.Add(goroutineCount)
-
-	for i := 0; i < goroutineCount; i++ {
-		go func(i int) {
-			defer wg.Done()
-			f, err := fls.Create("f" + strconv.Itoa(i))
-			if err != nil {
-				errCount.Add(1)
-				return
-			}
-			f.Close()
-		}(i)
-	}
-
-	wg.Wait()
-
-	successCount := int16(goroutineCount) - int16(errCount.Load())
-	if !assert.Less(t, successCount, maxParallelCreationCount+10) {
-		return
-	}
-	assert.Zero(t, fls.pendingFileCreations.Load())
-}
-
-func TestMetaFilesystemUsedSpaceValidation(t *testing.T) {
-
-	//TODO: do the tests without Dir: "/fs"
-
-	t.Run("the maxUsableSpace value should be greater than "+strconv.Itoa(METAFS_MIN_USABLE_SPACE), func(t *testing.T) {
+	t.Run("failed write should not be visible", func(t *testing.T) {
 		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
 		defer ctx.CancelGracefully()
 		underlyingFS := NewMemFilesystem(100_000_000)
 
 		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
-			MaxUsableSpace: 100,
-			Dir:            "/fs",
+			Dir: "/fs",
 		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer fls.Close(ctx)
 
-		if !assert.ErrorIs(t, err, ErrMaxUsableSpaceTooSmall) {
+		if !assert.NoError(t, fls.MkdirAll("/dir", DEFAULT_DIR_FMODE)) {
 			return
 		}
 
-		assert.Nil(t, fls)
+		fileCountBefore, _ := fls.getUnderlyingFileCount()
+
+		err = fls.WriteFileAtomic("/dir", []byte("hello"), DEFAULT_FILE_FMODE)
+		assert.ErrorIs(t, err, ErrCannotOpenDir)
+
+		info, err := fls.Stat("/dir")
+		if assert.NoError(t, err) {
+			assert.True(t, info.IsDir())
+		}
+
+		//the new concrete file should have been removed.
+		fileCountAfter, _ := fls.getUnderlyingFileCount()
+		assert.Equal(t, fileCountBefore, fileCountAfter)
 	})
 
-	t.Run("writing MaxUsableSpace bytes in a file in a single .Write() call should be an error", func(t *testing.T) {
+	t.Run("compressed", func(t *testing.T) {
 		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
 		defer ctx.CancelGracefully()
-		underlyingFS := NewMemFilesystem(10 * METAFS_MIN_USABLE_SPACE)
+		underlyingFS := NewMemFilesystem(100_000_000)
 
-		maxUsableSpace := core.ByteCount(METAFS_MIN_USABLE_SPACE)
 		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
-			MaxUsableSpace: maxUsableSpace,
-			Dir:            "/fs",
+			Dir:      "/fs",
+			Compress: true,
 		})
-
 		if !assert.NoError(t, err) {
 			return
 		}
+		defer fls.Close(ctx)
 
-		f, err := fls.Create("file")
-		if !assert.NoError(t, err) {
+		data := bytes.Repeat([]byte("a"), 10_000)
+
+		if !assert.NoError(t, fls.WriteFileAtomic("/file.txt", data, DEFAULT_FILE_FMODE)) {
 			return
 		}
-		defer f.Close()
-
-		content := bytes.Repeat([]byte{'x'}, int(maxUsableSpace))
 
-		n, err := f.Write(content)
-		if !assert.ErrorIs(t, err, ErrNoRemainingSpaceToApplyChange) {
+		content, err := util.ReadFile(fls, "/file.txt")
+		if !assert.NoError(t, err) {
 			return
 		}
+		assert.Equal(t, data, content)
 
-		assert.Zero(t, n)
+		info, err := fls.Stat("/file.txt")
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, len(data), info.Size())
+		}
 	})
 
-	t.Run("writing MaxUsableSpace bytes in a file in two .Write() calls (MaxUsableSpace / 2 in each call, no delay) should be an error", func(t *testing.T) {
+	t.Run("a concurrent reader should never see partial content", func(t *testing.T) {
 		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
 		defer ctx.CancelGracefully()
-		underlyingFS := NewMemFilesystem(10 * METAFS_MIN_USABLE_SPACE)
+		underlyingFS := NewMemFilesystem(100_000_000)
 
-		maxUsableSpace := core.ByteCount(METAFS_MIN_USABLE_SPACE)
 		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
-			MaxUsableSpace: maxUsableSpace,
-			Dir:            "/fs",
+			Dir: "/fs",
 		})
-
 		if !assert.NoError(t, err) {
 			return
 		}
+		defer fls.Close(ctx)
 
-		f, err := fls.Create("file")
-		if !assert.NoError(t, err) {
-			return
-		}
-		defer f.Close()
+		const CONTENT_SIZE = 100_000
+		const WRITE_COUNT = 100
 
-		content := bytes.Repeat([]byte{'x'}, int(maxUsableSpace/2))
+		contentA := bytes.Repeat([]byte("a"), CONTENT_SIZE)
+		contentB := bytes.Repeat([]byte("b"), CONTENT_SIZE)
 
-		n, err := f.Write(content)
-		if !assert.NoError(t, err) {
+		if !assert.NoError(t, fls.WriteFileAtomic("/file.txt", contentA, DEFAULT_FILE_FMODE)) {
 			return
 		}
 
-		assert.Equal(t, int(maxUsableSpace/2), n)
+		var done atomic.Bool
+		var wg sync.WaitGroup
+		wg.Add(1)
 
-		content = bytes.Repeat([]byte{'x'}, int(maxUsableSpace/2))
+		go func() {
+			defer wg.Done()
+			defer done.Store(true)
+
+			for i := 0; i < WRITE_COUNT; i++ {
+				content := contentA
+				if i%2 == 0 {
+					content = contentB
+				}
+				if !assert.NoError(t, fls.WriteFileAtomic("/file.txt", content, DEFAULT_FILE_FMODE)) {
+					return
+				}
+			}
+		}()
+
+		readCount := 0
+		for !done.Load() {
+			content, err := util.ReadFile(fls, "/file.txt")
+			if !assert.NoError(t, err) {
+				break
+			}
+			readCount++
+
+			if !bytes.Equal(content, contentA) && !bytes.Equal(content, contentB) {
+				assert.Fail(t, "partial content", "content of size %d", len(content))
+				break
+			}
+		}
+
+		wg.Wait()
+		assert.Greater(t, readCount, 0)
+	})
+}
+
+func TestMetaFilesystemMirror(t *testing.T) {
+
+	t.Run("created file should be copied to the mirror and removed file should be removed from it", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(100_000_000)
+		mirror := NewMemFilesystem(100_000_000)
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			Dir:    "/fs",
+			Mirror: mirror,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer fls.Close(ctx)
+
+		if !assert.NoError(t, fls.MkdirAll("/dir", DEFAULT_DIR_FMODE)) {
+			return
+		}
+
+		if !assert.NoError(t, util.WriteFile(fls, "/dir/file.txt", []byte("hello"), DEFAULT_FILE_FMODE)) {
+			return
+		}
+
+		assert.Eventually(t, func() bool {
+			content, err := util.ReadFile(mirror, "/dir/file.txt")
+			return err == nil && string(content) == "hello"
+		}, time.Second, time.Millisecond)
+
+		if !assert.NoError(t, fls.Remove("/dir/file.txt")) {
+			return
+		}
+
+		assert.Eventually(t, func() bool {
+			_, err := mirror.Stat("/dir/file.txt")
+			return errors.Is(err, os.ErrNotExist)
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("pending operations should be applied before the filesystem is closed", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(100_000_000)
+		mirror := NewMemFilesystem(100_000_000)
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			Dir:      "/fs",
+			Mirror:   mirror,
+			Compress: true,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		f, err := fls.Create("/file.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = f.Write([]byte("hello"))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		if !assert.NoError(t, f.Close()) {
+			return
+		}
+
+		if !assert.NoError(t, fls.Close(ctx)) {
+			return
+		}
+
+		//the mirror should contain the decompressed content.
+		content, err := util.ReadFile(mirror, "/file.txt")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "hello", string(content))
+		}
+	})
+
+	t.Run("mirroring errors should not make operations fail", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(100_000_000)
+
+		var mirrorErrorPaths []core.Path
+		var lock sync.Mutex
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			Dir:    "/fs",
+			Mirror: failingOpenFilesystem{NewMemFilesystem(100_000_000)},
+			OnMirrorError: func(path core.Path, err error) {
+				lock.Lock()
+				defer lock.Unlock()
+				mirrorErrorPaths = append(mirrorErrorPaths, path)
+			},
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		if !assert.NoError(t, util.WriteFile(fls, "/file.txt", []byte("hello"), DEFAULT_FILE_FMODE)) {
+			return
+		}
+
+		if !assert.NoError(t, fls.Close(ctx)) {
+			return
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		assert.Equal(t, []core.Path{"/file.txt"}, mirrorErrorPaths)
+	})
+
+	//the operations are applied when the filesystem is closed.
+	createMirroredFS := func(t *testing.T) (*core.Context, *MetaFilesystem, *MemFilesystem) {
+		mirror := NewMemFilesystem(100_000_000)
+		ctx, fls, _ := openTestMetaFilesystem(t, MetaFilesystemParams{
+			Dir:    "/fs",
+			Mirror: mirror,
+		})
+		return ctx, fls, mirror
+	}
+
+	assertMirrorContent := func(t *testing.T, mirror *MemFilesystem, path string, expectedContent string) {
+		content, err := util.ReadFile(mirror, path)
+		if assert.NoError(t, err) {
+			assert.Equal(t, expectedContent, string(content))
+		}
+	}
+
+	assertNotInMirror := func(t *testing.T, mirror *MemFilesystem, path string) {
+		_, err := mirror.Stat(path)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	}
+
+	t.Run("renamed file should be moved in the mirror", func(t *testing.T) {
+		ctx, fls, mirror := createMirroredFS(t)
+
+		//the write is queued before the rename.
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		if !assert.NoError(t, fls.Rename("/a.txt", "/b.txt")) {
+			return
+		}
+
+		if !assert.NoError(t, fls.Close(ctx)) {
+			return
+		}
+
+		assertNotInMirror(t, mirror, "/a.txt")
+		assertMirrorContent(t, mirror, "/b.txt", "hello")
+	})
+
+	t.Run("files of a renamed directory should be moved in the mirror", func(t *testing.T) {
+		ctx, fls, mirror := createMirroredFS(t)
+
+		utils.PanicIfErrAmong(
+			fls.MkdirAll("/dir/subdir", DEFAULT_DIR_FMODE),
+			util.WriteFile(fls, "/dir/a.txt", []byte("a"), DEFAULT_FILE_FMODE),
+			util.WriteFile(fls, "/dir/subdir/b.txt", []byte("b"), DEFAULT_FILE_FMODE),
+		)
+
+		if !assert.NoError(t, fls.Rename("/dir", "/dir2")) {
+			return
+		}
+
+		if !assert.NoError(t, fls.Close(ctx)) {
+			return
+		}
+
+		assertNotInMirror(t, mirror, "/dir")
+		assertMirrorContent(t, mirror, "/dir2/a.txt", "a")
+		assertMirrorContent(t, mirror, "/dir2/subdir/b.txt", "b")
+	})
+
+	t.Run("linked file should be copied to the mirror", func(t *testing.T) {
+		ctx, fls, mirror := createMirroredFS(t)
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		if !assert.NoError(t, fls.LinkContent("/a.txt", "/dir/b.txt")) {
+			return
+		}
+
+		if !assert.NoError(t, fls.Close(ctx)) {
+			return
+		}
+
+		assertMirrorContent(t, mirror, "/a.txt", "hello")
+		assertMirrorContent(t, mirror, "/dir/b.txt", "hello")
+	})
+}
+
+type failingOpenFilesystem struct {
+	*MemFilesystem
+}
+
+func (failingOpenFilesystem) OpenFile(filename string, flag int, perm os.FileMode) (billy.File, error) {
+	return nil, errors.New("failed to open file")
+}
+
+func TestMetaFilesystemContentCache(t *testing.T) {
+
+	//the concrete file of /a.txt is /fs/a.txt, this allows the tests to modify it without invalidating the cache.
+	setup := func(t *testing.T, cacheSize core.ByteCount) (*MemFilesystem, *MetaFilesystem) {
+		_, fls, underlyingFS := openTestMetaFilesystem(t, MetaFilesystemParams{
+			Dir:              "/fs",
+			ContentCacheSize: cacheSize,
+			ConcreteNameFunc: func(path core.Path) string {
+				return string(path.Basename())
+			},
+		})
+		return underlyingFS, fls
+	}
+
+	modifyConcreteFile := func(t *testing.T, underlyingFS *MemFilesystem, content string) {
+		if err := util.WriteFile(underlyingFS, "/fs/a.txt", []byte(content), DEFAULT_FILE_FMODE); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	readFile := func(t *testing.T, fls *MetaFilesystem) string {
+		content, err := util.ReadFile(fls, "/a.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		underlyingFS, fls := setup(t, 0)
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		assert.Equal(t, "hello", readFile(t, fls))
+
+		modifyConcreteFile(t, underlyingFS, "HELLO")
+		assert.Equal(t, "HELLO", readFile(t, fls))
+	})
+
+	t.Run("cached reads should be served from memory", func(t *testing.T) {
+		underlyingFS, fls := setup(t, 1000)
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		assert.Equal(t, "hello", readFile(t, fls))
+
+		modifyConcreteFile(t, underlyingFS, "HELLO")
+		assert.Equal(t, "hello", readFile(t, fls))
+	})
+
+	t.Run("write should invalidate the cached content", func(t *testing.T) {
+		_, fls := setup(t, 1000)
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		assert.Equal(t, "hello", readFile(t, fls))
+
+		f, err := fls.OpenFile("/a.txt", os.O_WRONLY, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = f.Write([]byte("HE"))
+		f.Close()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, "HEllo", readFile(t, fls))
+	})
+
+	t.Run("truncation should invalidate the cached content", func(t *testing.T) {
+		_, fls := setup(t, 1000)
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		assert.Equal(t, "hello", readFile(t, fls))
+
+		f, err := fls.OpenFile("/a.txt", os.O_WRONLY|os.O_TRUNC, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		f.Close()
+
+		assert.Equal(t, "", readFile(t, fls))
+	})
+
+	t.Run("removal should invalidate the cached content", func(t *testing.T) {
+		_, fls := setup(t, 1000)
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		assert.Equal(t, "hello", readFile(t, fls))
+
+		if !assert.NoError(t, fls.Remove("/a.txt")) {
+			return
+		}
+
+		//the new file has the same concrete file.
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("HELLO"), DEFAULT_FILE_FMODE))
+		assert.Equal(t, "HELLO", readFile(t, fls))
+	})
+
+	t.Run("files larger than the cache should not be cached", func(t *testing.T) {
+		underlyingFS, fls := setup(t, 3)
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		assert.Equal(t, "hello", readFile(t, fls))
+
+		modifyConcreteFile(t, underlyingFS, "HELLO")
+		assert.Equal(t, "HELLO", readFile(t, fls))
+	})
+
+	t.Run("compressed files", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		fls, err := OpenMetaFilesystem(ctx, NewMemFilesystem(100_000_000), MetaFilesystemParams{
+			Dir:              "/fs",
+			ContentCacheSize: 1000,
+			Compress:         true,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer fls.Close(ctx)
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+		assert.Equal(t, "hello", readFile(t, fls))
+
+		//the content is written to the concrete file when the file is closed.
+		f, err := fls.OpenFile("/a.txt", os.O_WRONLY, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = f.Write([]byte("HE"))
+		if !assert.NoError(t, err) {
+			return
+		}
+		if !assert.NoError(t, f.Close()) {
+			return
+		}
+
+		assert.Equal(t, "HEllo", readFile(t, fls))
+	})
+
+	t.Run("least recently used contents should be evicted", func(t *testing.T) {
+		underlyingFS, fls := setup(t, 8)
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+		utils.PanicIfErr(util.WriteFile(fls, "/b.txt", []byte("world"), DEFAULT_FILE_FMODE))
+
+		assert.Equal(t, "hello", readFile(t, fls))
+
+		//caching the content of /b.txt evicts the content of /a.txt.
+		content, err := util.ReadFile(fls, "/b.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "world", string(content))
+
+		modifyConcreteFile(t, underlyingFS, "HELLO")
+		assert.Equal(t, "HELLO", readFile(t, fls))
+	})
+}
+
+func TestMetaFilesystemStatCache(t *testing.T) {
+
+	//the concrete file of /a.txt is /fs/a.txt, this allows the tests to modify it without invalidating the cache.
+	setup := func(t *testing.T, cacheSize int) (*MemFilesystem, *MetaFilesystem) {
+		_, fls, underlyingFS := openTestMetaFilesystem(t, MetaFilesystemParams{
+			Dir:           "/fs",
+			StatCacheSize: cacheSize,
+			ConcreteNameFunc: func(path core.Path) string {
+				return string(path.Basename())
+			},
+		})
+		return underlyingFS, fls
+	}
+
+	modifyConcreteFile := func(t *testing.T, underlyingFS *MemFilesystem, content string) {
+		if err := util.WriteFile(underlyingFS, "/fs/a.txt", []byte(content), DEFAULT_FILE_FMODE); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	statSize := func(t *testing.T, fls *MetaFilesystem, path string) int64 {
+		info, err := fls.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Size()
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		underlyingFS, fls := setup(t, 0)
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		assert.EqualValues(t, 5, statSize(t, fls, "/a.txt"))
+
+		modifyConcreteFile(t, underlyingFS, "hello world")
+		assert.EqualValues(t, 11, statSize(t, fls, "/a.txt"))
+	})
+
+	t.Run("cached infos should be served from memory", func(t *testing.T) {
+		underlyingFS, fls := setup(t, 10)
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		assert.EqualValues(t, 5, statSize(t, fls, "/a.txt"))
+
+		modifyConcreteFile(t, underlyingFS, "hello world")
+		assert.EqualValues(t, 5, statSize(t, fls, "/a.txt"))
+	})
+
+	t.Run("modification should invalidate the cached info", func(t *testing.T) {
+		_, fls := setup(t, 10)
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		info, err := fls.Stat("/a.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.EqualValues(t, 5, info.Size())
+
+		f, err := fls.OpenFile("/a.txt", os.O_WRONLY|os.O_APPEND, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = f.Write([]byte(" world"))
+		f.Close()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		newInfo, err := fls.Stat("/a.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.EqualValues(t, 11, newInfo.Size())
+		assert.True(t, newInfo.ModTime().After(info.ModTime()))
+	})
+
+	t.Run("modification through a path sharing the concrete file should invalidate the cached info", func(t *testing.T) {
+		_, fls := setup(t, 10)
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		if !assert.NoError(t, fls.LinkContent("/a.txt", "/b.txt")) {
+			return
+		}
+
+		assert.EqualValues(t, 5, statSize(t, fls, "/b.txt"))
+
+		f, err := fls.OpenFile("/a.txt", os.O_WRONLY|os.O_APPEND, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = f.Write([]byte(" world"))
+		f.Close()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.EqualValues(t, 11, statSize(t, fls, "/b.txt"))
+	})
+
+	t.Run("removal should invalidate the cached info", func(t *testing.T) {
+		_, fls := setup(t, 10)
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		assert.EqualValues(t, 5, statSize(t, fls, "/a.txt"))
+
+		if !assert.NoError(t, fls.Remove("/a.txt")) {
+			return
+		}
+
+		_, err := fls.Stat("/a.txt")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("least recently used infos should be evicted", func(t *testing.T) {
+		underlyingFS, fls := setup(t, 1)
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+		utils.PanicIfErr(util.WriteFile(fls, "/b.txt", []byte("world"), DEFAULT_FILE_FMODE))
+
+		assert.EqualValues(t, 5, statSize(t, fls, "/a.txt"))
+
+		//caching the info of /b.txt evicts the info of /a.txt.
+		assert.EqualValues(t, 5, statSize(t, fls, "/b.txt"))
+		assert.Len(t, fls.statCache.concreteFileEntries, 1)
+
+		modifyConcreteFile(t, underlyingFS, "hello world")
+		assert.EqualValues(t, 11, statSize(t, fls, "/a.txt"))
+	})
+}
+
+func TestMetaFilesystemPermissions(t *testing.T) {
+
+	setup := func(t *testing.T, enforcePermissions bool) *MetaFilesystem {
+		_, fls, _ := openTestMetaFilesystem(t, MetaFilesystemParams{
+			Dir:                "/fs",
+			EnforcePermissions: enforcePermissions,
+		})
+
+		//a file created with a read-only mode is writable through the file returned by the creation.
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), 0o400))
+		return fls
+	}
+
+	t.Run("strict mode: opening a read-only file for writing should fail", func(t *testing.T) {
+		fls := setup(t, true)
+
+		for _, flag := range []int{os.O_WRONLY, os.O_RDWR, os.O_WRONLY | os.O_APPEND, os.O_WRONLY | os.O_TRUNC} {
+			_, err := fls.OpenFile("/a.txt", flag, 0)
+			assert.ErrorIs(t, err, os.ErrPermission)
+		}
+
+		err := fls.WriteFileAtomic("/a.txt", []byte("HELLO"), 0o600)
+		assert.ErrorIs(t, err, os.ErrPermission)
+
+		content, err := util.ReadFile(fls, "/a.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "hello", string(content))
+	})
+
+	t.Run("strict mode: opening a writable file for writing should succeed", func(t *testing.T) {
+		fls := setup(t, true)
+		utils.PanicIfErr(util.WriteFile(fls, "/b.txt", []byte("hello"), 0o600))
+
+		f, err := fls.OpenFile("/b.txt", os.O_WRONLY, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		f.Close()
+	})
+
+	t.Run("lax mode: opening a read-only file for writing should succeed", func(t *testing.T) {
+		fls := setup(t, false)
+
+		f, err := fls.OpenFile("/a.txt", os.O_WRONLY, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = f.Write([]byte("HE"))
+		f.Close()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		content, err := util.ReadFile(fls, "/a.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "HEllo", string(content))
+	})
+}
+
+func TestMetaFilesystemMetrics(t *testing.T) {
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+	underlyingFS := NewMemFilesystem(100_000_000)
+
+	metrics := &fakeMetaFsMetrics{}
+
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir:     "/fs",
+		Metrics: metrics,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer fls.Close(ctx)
+
+	f, err := fls.Create("/a.txt")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = f.Write([]byte("hello"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = f.Write([]byte(" world"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	f.Close()
+
+	f, err = fls.Open("/a.txt")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	buf := make([]byte, 100)
+	_, err = f.Read(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = f.Read(buf)
+	assert.ErrorIs(t, err, io.EOF)
+	f.Close()
+
+	_, err = fls.Open("/b.txt")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	assert.NoError(t, fls.WriteFileAtomic("/c.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+	_, err = fls.TakeFilesystemSnapshot(core.FilesystemSnapshotConfig{
+		GetContent: func(ChecksumSHA256 [32]byte) core.AddressableContent {
+			return nil
+		},
+		InclusionFilters: []core.PathPattern{"/..."},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, fls.Remove("/a.txt"))
+	assert.NoError(t, fls.RemoveAll("/c.txt"))
+
+	metrics.lock.Lock()
+	defer metrics.lock.Unlock()
+
+	assert.Equal(t, map[string]int{
+		METAFS_CREATE_OP:   1,
+		METAFS_WRITE_OP:    3,
+		METAFS_OPEN_OP:     2,
+		METAFS_READ_OP:     2,
+		METAFS_SNAPSHOT_OP: 1,
+		METAFS_DELETE_OP:   2,
+	}, metrics.counts)
+
+	assert.Equal(t, map[string]int{
+		METAFS_OPEN_OP: 1,
+	}, metrics.errorCounts)
+}
+
+type fakeMetaFsMetrics struct {
+	lock        sync.Mutex
+	counts      map[string]int
+	errorCounts map[string]int
+}
+
+func (m *fakeMetaFsMetrics) RecordOp(name string, dur time.Duration, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.counts == nil {
+		m.counts = map[string]int{}
+		m.errorCounts = map[string]int{}
+	}
+
+	m.counts[name]++
+	if err != nil {
+		m.errorCounts[name]++
+	}
+}
+
+func TestMetaFilesystemDirAlreadyInUse(t *testing.T) {
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+	underlyingFS := NewMemFilesystem(100_000_000)
+
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir: "/fs",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	//opening the same directory a second time should fail.
+	_, err = OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir: "/fs",
+	})
+	assert.ErrorIs(t, err, ErrFilesystemDirAlreadyInUse)
+
+	//another directory can be used.
+	otherFls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir: "/fs2",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	otherFls.Close(ctx)
+
+	//the directory should be released when the filesystem is closed.
+	if !assert.NoError(t, fls.Close(ctx)) {
+		return
+	}
+
+	fls, err = OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir: "/fs",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	fls.Close(ctx)
+}
+
+func TestMetaFilesystemMkdirAllTimes(t *testing.T) {
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+	underlyingFS := NewMemFilesystem(100_000_000)
+
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir: "/fs",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer fls.Close(ctx)
+
+	beforeCreation := time.Now()
+
+	if !assert.NoError(t, fls.MkdirAll("/a/b/c", DEFAULT_DIR_FMODE)) {
+		return
+	}
+
+	afterCreation := time.Now()
+
+	for _, path := range []string{"/a", "/a/b", "/a/b/c"} {
+		t.Run(path, func(t *testing.T) {
+			info, err := fls.Stat(path)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.True(t, info.IsDir())
+
+			coreInfo, ok := info.(core.FileInfo)
+			if !assert.True(t, ok) {
+				return
+			}
+
+			creationTime := time.Time(coreInfo.CreationTime_)
+			modificationTime := info.ModTime()
+
+			assert.True(t, coreInfo.HasCreationTime)
+			assert.False(t, creationTime.Before(beforeCreation))
+			assert.False(t, creationTime.After(afterCreation))
+			assert.False(t, modificationTime.Before(creationTime))
+			assert.False(t, modificationTime.After(afterCreation))
+		})
+	}
+
+	t.Run("the modification time of the parent of a new directory should be updated", func(t *testing.T) {
+		if !assert.NoError(t, fls.MkdirAll("/a/d", DEFAULT_DIR_FMODE)) {
+			return
+		}
+
+		parentInfo, err := fls.Stat("/a")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		dirInfo, err := fls.Stat("/a/d")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, dirInfo.ModTime(), parentInfo.ModTime())
+	})
+}
+
+func TestMetaFilesystemLargeFileStreaming(t *testing.T) {
+	const (
+		FILE_SIZE  = 32_000_000
+		CHUNK_SIZE = 4096
+	)
+
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+	underlyingFS := NewMemFilesystem(100_000_000)
+
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir:            "/fs",
+		MaxUsableSpace: 2 * FILE_SIZE,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer fls.Close(ctx)
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), FILE_SIZE/16)
+
+	if !assert.NoError(t, util.WriteFile(fls, "/large.txt", content, DEFAULT_FILE_FMODE)) {
+		return
+	}
+
+	runtime.GC()
+	var memStatsBefore runtime.MemStats
+	runtime.ReadMemStats(&memStatsBefore)
+
+	f, err := fls.Open("/large.txt")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	chunk := make([]byte, CHUNK_SIZE)
+	readByteCount := 0
+
+	for {
+		n, err := f.Read(chunk)
+		if n > 0 {
+			if !bytes.Equal(content[readByteCount:readByteCount+n], chunk[:n]) {
+				assert.Fail(t, "unexpected content", "at offset %d", readByteCount)
+				return
+			}
+			readByteCount += n
+		}
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+
+	var memStatsAfter runtime.MemStats
+	runtime.ReadMemStats(&memStatsAfter)
+
+	assert.Equal(t, FILE_SIZE, readByteCount)
+
+	//the content should not have been loaded in memory.
+	allocated := memStatsAfter.TotalAlloc - memStatsBefore.TotalAlloc
+	assert.Less(t, allocated, uint64(FILE_SIZE/10))
+}
+
+func TestMetaFilesystemRemoveShouldRemoveConcreteFile(t *testing.T) {
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+	underlyingFS := NewMemFilesystem(100_000_000)
+
+	//no dir provided
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{})
+	if err != nil {
+		panic(err)
+	}
+
+	defer fls.Close(ctx)
+
+	entries, err := underlyingFS.ReadDir("/")
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, entries, 1) { //metadata file
+		return
+	}
+
+	f, err := fls.Create("file.txt")
+	if !assert.NoError(t, err) {
+		return
+	}
+	f.Close()
+
+	entries, err = underlyingFS.ReadDir("/")
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, entries, 2) {
+		return
+	}
+
+	err = fls.Remove("file.txt")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	entries, err = underlyingFS.ReadDir("/")
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, entries, 1) { //metadata file
+		return
+	}
+}
+
+func TestMetaFilesystemConcreteNameFunc(t *testing.T) {
+
+	//the concrete name is derived from the path for the test to be deterministic.
+	concreteNameFunc := func(path core.Path) string {
+		return "concrete-" + strings.ReplaceAll(strings.TrimPrefix(path.UnderlyingString(), "/"), "/", "-")
+	}
+
+	t.Run("the metadata should record the name produced by the function", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(100_000_000)
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			Dir:              "/metafs/",
+			ConcreteNameFunc: concreteNameFunc,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer fls.Close(ctx)
+
+		if !assert.NoError(t, util.WriteFile(fls, "/dir/a.txt", []byte("a"), DEFAULT_FILE_FMODE)) {
+			return
+		}
+
+		metadata, found, err := fls.getFileMetadata("/dir/a.txt", nil)
+		if !assert.NoError(t, err) || !assert.True(t, found) {
+			return
+		}
+		if !assert.NotNil(t, metadata.concreteFile) {
+			return
+		}
+		assert.Equal(t, "/metafs/concrete-dir-a.txt", metadata.concreteFile.UnderlyingString())
+
+		content, err := util.ReadFile(underlyingFS, "/metafs/concrete-dir-a.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []byte("a"), content)
+	})
+
+	t.Run("an invalid name should cause the creation to fail", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(100_000_000)
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			Dir: "/metafs/",
+			ConcreteNameFunc: func(path core.Path) string {
+				return "a/b"
+			},
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer fls.Close(ctx)
+
+		_, err = fls.Create("/a.txt")
+		assert.ErrorContains(t, err, "invalid concrete file name")
+
+		_, err = fls.Stat("/a.txt")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("atomic writes should be able to overwrite a file", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(100_000_000)
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			Dir:              "/metafs/",
+			ConcreteNameFunc: concreteNameFunc,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer fls.Close(ctx)
+
+		//the second and third writes produce the name of the current concrete file.
+		for _, content := range []string{"a", "b", "c"} {
+			if !assert.NoError(t, fls.WriteFileAtomic("/a.txt", []byte(content), DEFAULT_FILE_FMODE)) {
+				return
+			}
+
+			readContent, err := util.ReadFile(fls, "/a.txt")
+			if assert.NoError(t, err) {
+				assert.Equal(t, []byte(content), readContent)
+			}
+		}
+
+		//the previous concrete files should have been removed.
+		entries, err := underlyingFS.ReadDir("/metafs/")
+		if !assert.NoError(t, err) {
+			return
+		}
+		concreteFileCount := 0
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), "concrete-a.txt") {
+				concreteFileCount++
+			}
+		}
+		assert.Equal(t, 1, concreteFileCount)
+	})
+}
+
+func TestMetaFilesystemRemoveAll(t *testing.T) {
+
+	const (
+		TREE_DEPTH     = 8
+		FILES_PER_DIR  = 2
+		FILE_SIZE      = 500_000
+		TREE_FILE_SIZE = TREE_DEPTH * FILES_PER_DIR * FILE_SIZE
+	)
+
+	createMetaFS := func(t *testing.T) (*core.Context, *MetaFilesystem, *MemFilesystem) {
+		return openTestMetaFilesystem(t, MetaFilesystemParams{
+			Dir:            "/metafs/",
+			MaxUsableSpace: METAFS_MIN_USABLE_SPACE,
+		})
+	}
+
+	//createTree creates a tree of TREE_DEPTH directories, each one containing FILES_PER_DIR files.
+	createTree := func(fls *MetaFilesystem, root string) {
+		dir := root
+		content := bytes.Repeat([]byte{'x'}, FILE_SIZE)
+
+		for depth := 0; depth < TREE_DEPTH; depth++ {
+			dir = fls.Join(dir, fmt.Sprintf("dir%d", depth))
+			utils.PanicIfErr(fls.MkdirAll(dir, DEFAULT_DIR_FMODE))
+
+			for i := 0; i < FILES_PER_DIR; i++ {
+				utils.PanicIfErr(util.WriteFile(fls, fls.Join(dir, fmt.Sprintf("file%d.txt", i)), content, DEFAULT_FILE_FMODE))
+			}
+		}
+	}
+
+	assertNoIntegrityIssue := func(t *testing.T, ctx *core.Context, fls *MetaFilesystem) {
+		issues, err := fls.VerifyIntegrity(ctx)
+		if assert.NoError(t, err) {
+			assert.Empty(t, issues)
+		}
+	}
+
+	t.Run("deep tree", func(t *testing.T) {
+		ctx, fls, underlyingFS := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		utils.PanicIfErr(fls.MkdirAll("/a", DEFAULT_DIR_FMODE))
+		utils.PanicIfErr(util.WriteFile(fls, "/a/kept.txt", []byte("kept"), DEFAULT_FILE_FMODE))
+		createTree(fls, "/a")
+
+		//small batches in order to have several commits.
+		if !assert.NoError(t, fls.removeAll("/a/dir0", 3)) {
+			return
+		}
+
+		_, err := fls.Stat("/a/dir0")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		entries, err := fls.ReadDir("/a")
+		if assert.NoError(t, err) && assert.Len(t, entries, 1) {
+			assert.Equal(t, "kept.txt", entries[0].Name())
+		}
+
+		//only the KV file and the concrete file of /a/kept.txt should remain.
+		underlyingEntries, err := underlyingFS.ReadDir("/metafs/")
+		if assert.NoError(t, err) {
+			assert.Len(t, underlyingEntries, 2)
+		}
+
+		assertNoIntegrityIssue(t, ctx, fls)
+
+		//the space used by the removed files should be usable again.
+		createTree(fls, "/a")
+	})
+
+	t.Run("the quota should be reclaimed", func(t *testing.T) {
+		ctx, fls, _ := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		createTree(fls, "/")
+
+		//without reclamation the tree would not fit twice in the usable space.
+		if !assert.Greater(t, 2*TREE_FILE_SIZE, METAFS_MIN_USABLE_SPACE) {
+			return
+		}
+
+		if !assert.NoError(t, fls.RemoveAll("/dir0")) {
+			return
+		}
+
+		usedSpace, err := fls.computeUsedSpace(true)
+		if assert.NoError(t, err) {
+			assert.Less(t, usedSpace, core.ByteCount(FILE_SIZE))
+		}
+
+		err = util.WriteFile(fls, "/big.txt", bytes.Repeat([]byte{'x'}, TREE_FILE_SIZE), DEFAULT_FILE_FMODE)
+		assert.NoError(t, err)
+	})
+
+	t.Run("an interrupted removal should be completed by a new call", func(t *testing.T) {
+		ctx, fls, underlyingFS := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		createTree(fls, "/")
+
+		//simulate a removal of /dir0 that was interrupted after having removed the descendants of /dir0/dir1/dir2.
+		func() {
+			fls.lock.Lock()
+			defer fls.lock.Unlock()
+
+			metadata, found, err := fls.getFileMetadata("/dir0/dir1/dir2", nil)
+			utils.PanicIfErr(err)
+			if !found {
+				panic(core.ErrUnreachable)
+			}
+
+			remover := &metaFsSubtreeRemover{fls: fls, batchSize: 2}
+			remover.tx = utils.Must(fls.metadata.Begin(true))
+			utils.PanicIfErr(remover.removeDescendants(metadata))
+			utils.PanicIfErr(remover.commit())
+		}()
+
+		_, err := fls.Stat("/dir0/dir1/dir2/file0.txt")
+		if !assert.ErrorIs(t, err, os.ErrNotExist) {
+			return
+		}
+
+		if !assert.NoError(t, fls.RemoveAll("/dir0")) {
+			return
+		}
+
+		_, err = fls.Stat("/dir0")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		entries, err := fls.ReadDir("/")
+		if assert.NoError(t, err) {
+			assert.Empty(t, entries)
+		}
+
+		underlyingEntries, err := underlyingFS.ReadDir("/metafs/")
+		if assert.NoError(t, err) {
+			assert.Len(t, underlyingEntries, 1) //KV file
+		}
+
+		assertNoIntegrityIssue(t, ctx, fls)
+
+		//a new call should have no effect.
+		assert.NoError(t, fls.RemoveAll("/dir0"))
+	})
+
+	t.Run("file", func(t *testing.T) {
+		ctx, fls, _ := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("a"), DEFAULT_FILE_FMODE))
+
+		if !assert.NoError(t, fls.RemoveAll("/a.txt")) {
+			return
+		}
+
+		_, err := fls.Stat("/a.txt")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		assertNoIntegrityIssue(t, ctx, fls)
+	})
+
+	t.Run("root directory", func(t *testing.T) {
+		ctx, fls, _ := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		createTree(fls, "/")
+
+		if !assert.NoError(t, fls.RemoveAll("/")) {
+			return
+		}
+
+		entries, err := fls.ReadDir("/")
+		if assert.NoError(t, err) {
+			assert.Empty(t, entries)
+		}
+
+		assertNoIntegrityIssue(t, ctx, fls)
+	})
+
+	t.Run("non existing file", func(t *testing.T) {
+		ctx, fls, _ := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		assert.NoError(t, fls.RemoveAll("/missing"))
+	})
+}
+
+func TestMetaFilesystemCompression(t *testing.T) {
+
+	setup := func(t *testing.T) (*core.Context, *MemFilesystem, *MetaFilesystem) {
+		ctx, fls, underlyingFS := openTestMetaFilesystem(t, MetaFilesystemParams{
+			Dir:      "/fs",
+			Compress: true,
+		})
+		return ctx, underlyingFS, fls
+	}
+
+	getConcreteFileSize := func(t *testing.T, fls *MetaFilesystem, path string) int64 {
+		metadata, exists, err := fls.getFileMetadata(core.PathFrom(path), nil)
+		if !assert.NoError(t, err) || !assert.True(t, exists) {
+			t.FailNow()
+		}
+		assert.True(t, metadata.compressed)
+
+		stat, err := fls.underlying.Stat(metadata.concreteFile.UnderlyingString())
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return stat.Size()
+	}
+
+	t.Run("compressible content", func(t *testing.T) {
+		_, _, fls := setup(t)
+
+		content := []byte(strings.Repeat("hello world ", 10_000))
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", content, DEFAULT_FILE_FMODE))
+
+		readContent, err := util.ReadFile(fls, "/a.txt")
+		if assert.NoError(t, err) {
+			assert.Equal(t, content, readContent)
+		}
+
+		stat, err := fls.Stat("/a.txt")
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, len(content), stat.Size())
+		}
+
+		//the concrete file should contain the compressed content.
+		assert.Less(t, getConcreteFileSize(t, fls, "/a.txt"), int64(len(content)/10))
+	})
+
+	t.Run("incompressible content", func(t *testing.T) {
+		_, _, fls := setup(t)
+
+		content := make([]byte, 10_000)
+		for i := range content {
+			content[i] = byte(i*7919 + i*i*31 + i>>3)
+		}
+		utils.PanicIfErr(util.WriteFile(fls, "/a.bin", content, DEFAULT_FILE_FMODE))
+
+		readContent, err := util.ReadFile(fls, "/a.bin")
+		if assert.NoError(t, err) {
+			assert.Equal(t, content, readContent)
+		}
+
+		stat, err := fls.Stat("/a.bin")
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, len(content), stat.Size())
+		}
+	})
+
+	t.Run("partial reads and writes", func(t *testing.T) {
+		_, _, fls := setup(t)
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("0123456789"), DEFAULT_FILE_FMODE))
+
+		f, err := fls.OpenFile("/a.txt", os.O_RDWR, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		buf := make([]byte, 3)
+		n, err := f.ReadAt(buf, 4)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "456", string(buf[:n]))
+		}
+
+		_, err = f.Seek(8, io.SeekStart)
+		utils.PanicIfErr(err)
+		_, err = f.Write([]byte("abcd"))
+		utils.PanicIfErr(err)
+		utils.PanicIfErr(f.Close())
+
+		readContent, err := util.ReadFile(fls, "/a.txt")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "01234567abcd", string(readContent))
+		}
+
+		//append
+		f, err = fls.OpenFile("/a.txt", os.O_WRONLY|os.O_APPEND, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = f.Write([]byte("ef"))
+		utils.PanicIfErr(err)
+		utils.PanicIfErr(f.Close())
+
+		readContent, err = util.ReadFile(fls, "/a.txt")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "01234567abcdef", string(readContent))
+		}
+	})
+
+	t.Run("content should be readable after reopening the filesystem", func(t *testing.T) {
+		ctx, underlyingFS, fls := setup(t)
+
+		content := []byte(strings.Repeat("hello world ", 100))
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", content, DEFAULT_FILE_FMODE))
+		utils.PanicIfErr(fls.Close(ctx))
+
+		//compression is disabled, existing compressed files should still be decompressed.
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			Dir: "/fs",
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		readContent, err := util.ReadFile(fls, "/a.txt")
+		if assert.NoError(t, err) {
+			assert.Equal(t, content, readContent)
+		}
+
+		stat, err := fls.Stat("/a.txt")
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, len(content), stat.Size())
+		}
+	})
+
+	t.Run("linked file", func(t *testing.T) {
+		_, _, fls := setup(t)
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		if !assert.NoError(t, fls.LinkContent("/a.txt", "/b.txt")) {
+			return
+		}
+
+		//the original size should be updated for both paths.
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello world"), DEFAULT_FILE_FMODE))
+
+		stat, err := fls.Stat("/b.txt")
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, 11, stat.Size())
+		}
+
+		sizes, err := fls.DirectorySizes(false)
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, 22, sizes["/"])
+		}
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		_, _, fls := setup(t)
+
+		content := []byte(strings.Repeat("hello world ", 100))
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", content, DEFAULT_FILE_FMODE))
+
+		snapshot, err := fls.TakeFilesystemSnapshot(core.FilesystemSnapshotConfig{
+			GetContent: func(ChecksumSHA256 [32]byte) core.AddressableContent {
+				return nil
+			},
+			InclusionFilters: []core.PathPattern{"/..."},
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		addressableContent, err := snapshot.Content("/a.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		snapshotContent, err := io.ReadAll(addressableContent.Reader())
+		if assert.NoError(t, err) {
+			assert.Equal(t, content, snapshotContent)
+		}
+	})
+}
+
+func TestMetaFilesystemSync(t *testing.T) {
+
+	//the content of compressed files is only written to the concrete files when they are synced or closed.
+	setup := func(t *testing.T) (*core.Context, *MetaFilesystem) {
+		ctx, fls, _ := openTestMetaFilesystem(t, MetaFilesystemParams{
+			Dir:      "/fs",
+			Compress: true,
+		})
+		return ctx, fls
+	}
+
+	getDurableContent := func(t *testing.T, fls *MetaFilesystem, path string) []byte {
+		metadata, exists, err := fls.getFileMetadata(core.PathFrom(path), nil)
+		if !assert.NoError(t, err) || !assert.True(t, exists) {
+			t.FailNow()
+		}
+
+		compressed, err := util.ReadFile(fls.underlying, metadata.concreteFile.UnderlyingString())
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		content, err := decompressFileContent(compressed)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return content
+	}
+
+	t.Run("Sync", func(t *testing.T) {
+		_, fls := setup(t)
+
+		f, err := fls.Create("/a.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f.Close()
+
+		_, err = f.Write([]byte("hello"))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Empty(t, getDurableContent(t, fls, "/a.txt"))
+
+		if !assert.NoError(t, fls.Sync("/a.txt")) {
+			return
+		}
+
+		assert.Equal(t, []byte("hello"), getDurableContent(t, fls, "/a.txt"))
+
+		metadata, _, err := fls.getFileMetadata(core.PathFrom("/a.txt"), nil)
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, 5, metadata.originalSize)
+		}
+	})
+
+	t.Run("SyncAll", func(t *testing.T) {
+		_, fls := setup(t)
+
+		f1, err := fls.Create("/a.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f1.Close()
+
+		f2, err := fls.Create("/b.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f2.Close()
+
+		f1.Write([]byte("a"))
+		f2.Write([]byte("b"))
+
+		assert.Empty(t, getDurableContent(t, fls, "/a.txt"))
+		assert.Empty(t, getDurableContent(t, fls, "/b.txt"))
+
+		if !assert.NoError(t, fls.SyncAll()) {
+			return
+		}
+
+		assert.Equal(t, []byte("a"), getDurableContent(t, fls, "/a.txt"))
+		assert.Equal(t, []byte("b"), getDurableContent(t, fls, "/b.txt"))
+	})
+
+	t.Run("read-only handles are ignored", func(t *testing.T) {
+		_, fls := setup(t)
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+
+		f, err := fls.Open("/a.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f.Close()
+
+		assert.NoError(t, fls.Sync("/a.txt"))
+		assert.NoError(t, fls.SyncAll())
+		assert.Equal(t, []byte("hello"), getDurableContent(t, fls, "/a.txt"))
+	})
+
+	t.Run("non existing file", func(t *testing.T) {
+		_, fls := setup(t)
+
+		assert.ErrorIs(t, fls.Sync("/a.txt"), os.ErrNotExist)
+	})
+
+	t.Run("closed filesystem", func(t *testing.T) {
+		ctx, fls := setup(t)
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("hello"), DEFAULT_FILE_FMODE))
+		fls.Close(ctx)
+
+		assert.ErrorIs(t, fls.Sync("/a.txt"), ErrClosedFilesystem)
+		assert.ErrorIs(t, fls.SyncAll(), ErrClosedFilesystem)
+	})
+}
+
+func TestMetaFilesystemLinkContent(t *testing.T) {
+
+	createMetaFS := func(t *testing.T) (*core.Context, *MetaFilesystem, *MemFilesystem) {
+		return openTestMetaFilesystem(t, MetaFilesystemParams{Dir: "/metafs/"})
+	}
+
+	getUnderlyingFileCount := func(t *testing.T, underlyingFS *MemFilesystem) int {
+		entries, err := underlyingFS.ReadDir("/metafs/")
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return len(entries)
+	}
+
+	t.Run("link", func(t *testing.T) {
+		ctx, fls, underlyingFS := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("content"), DEFAULT_FILE_FMODE))
+
+		if !assert.NoError(t, fls.LinkContent("/a.txt", "/dir/b.txt")) {
+			return
+		}
+
+		content, err := util.ReadFile(fls, "/dir/b.txt")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "content", string(content))
+		}
+
+		//the concrete file should be shared: KV file + 1 concrete file.
+		assert.Equal(t, 2, getUnderlyingFileCount(t, underlyingFS))
+
+		//writes should be visible from both paths.
+		utils.PanicIfErr(util.WriteFile(fls, "/dir/b.txt", []byte("new content"), DEFAULT_FILE_FMODE))
+
+		content, err = util.ReadFile(fls, "/a.txt")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "new content", string(content))
+		}
+
+		issues, err := fls.VerifyIntegrity(ctx)
+		if assert.NoError(t, err) {
+			assert.Empty(t, issues)
+		}
+	})
+
+	t.Run("linking to an existing path should fail", func(t *testing.T) {
+		ctx, fls, _ := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("a"), DEFAULT_FILE_FMODE))
+		utils.PanicIfErr(util.WriteFile(fls, "/b.txt", []byte("b"), DEFAULT_FILE_FMODE))
+
+		assert.ErrorIs(t, fls.LinkContent("/a.txt", "/b.txt"), os.ErrExist)
+	})
+
+	t.Run("linking the content of a directory should fail", func(t *testing.T) {
+		ctx, fls, _ := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		utils.PanicIfErr(fls.MkdirAll("/dir", DEFAULT_DIR_FMODE))
+
+		assert.ErrorIs(t, fls.LinkContent("/dir/", "/b.txt"), ErrCannotLinkContentOfNonRegularFile)
+	})
+
+	t.Run("linking the content of a non existing file should fail", func(t *testing.T) {
+		ctx, fls, _ := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		assert.ErrorIs(t, fls.LinkContent("/a.txt", "/b.txt"), os.ErrNotExist)
+	})
+
+	t.Run("delete one", func(t *testing.T) {
+		ctx, fls, underlyingFS := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("content"), DEFAULT_FILE_FMODE))
+		utils.PanicIfErr(fls.LinkContent("/a.txt", "/b.txt"))
+
+		if !assert.NoError(t, fls.Remove("/a.txt")) {
+			return
+		}
+
+		_, err := fls.Stat("/a.txt")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		//the concrete file should still be present because it is referenced by /b.txt.
+		assert.Equal(t, 2, getUnderlyingFileCount(t, underlyingFS))
+
+		content, err := util.ReadFile(fls, "/b.txt")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "content", string(content))
+		}
+	})
+
+	t.Run("delete both", func(t *testing.T) {
+		ctx, fls, underlyingFS := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("content"), DEFAULT_FILE_FMODE))
+		utils.PanicIfErr(fls.LinkContent("/a.txt", "/b.txt"))
+		utils.PanicIfErr(fls.LinkContent("/b.txt", "/c.txt"))
+
+		if !assert.NoError(t, fls.Remove("/a.txt")) {
+			return
+		}
+		if !assert.NoError(t, fls.Remove("/c.txt")) {
+			return
+		}
+		assert.Equal(t, 2, getUnderlyingFileCount(t, underlyingFS))
+
+		if !assert.NoError(t, fls.Remove("/b.txt")) {
+			return
+		}
+
+		//only the KV file should remain.
+		assert.Equal(t, 1, getUnderlyingFileCount(t, underlyingFS))
+	})
+
+	t.Run("RemoveAll should only remove concrete files that are no longer referenced", func(t *testing.T) {
+		ctx, fls, underlyingFS := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		utils.PanicIfErr(util.WriteFile(fls, "/dir/a.txt", []byte("a"), DEFAULT_FILE_FMODE))
+		utils.PanicIfErr(util.WriteFile(fls, "/dir/b.txt", []byte("b"), DEFAULT_FILE_FMODE))
+		utils.PanicIfErr(fls.LinkContent("/dir/a.txt", "/a.txt"))
+		utils.PanicIfErr(fls.LinkContent("/dir/b.txt", "/dir/c.txt"))
+
+		if !assert.NoError(t, fls.RemoveAll("/dir")) {
+			return
+		}
+
+		//KV file + concrete file of /a.txt.
+		assert.Equal(t, 2, getUnderlyingFileCount(t, underlyingFS))
+
+		content, err := util.ReadFile(fls, "/a.txt")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "a", string(content))
+		}
+
+		if !assert.NoError(t, fls.Remove("/a.txt")) {
+			return
+		}
+		assert.Equal(t, 1, getUnderlyingFileCount(t, underlyingFS))
+	})
+}
+
+func TestMetaFilesystemEachFile(t *testing.T) {
+
+	createMetaFS := func(t *testing.T) (*core.Context, *MetaFilesystem) {
+		ctx, fls, _ := openTestMetaFilesystem(t, MetaFilesystemParams{Dir: "/metafs/"})
+		return ctx, fls
+	}
+
+	files := map[string]string{
+		"/a.txt":         "a",
+		"/dir/b.txt":     "b",
+		"/dir/sub/c.txt": "c",
+		"/dir2/d.txt":    "d",
+	}
+
+	createTree := func(fls *MetaFilesystem) {
+		for path, content := range files {
+			utils.PanicIfErr(util.WriteFile(fls, path, []byte(content), DEFAULT_FILE_FMODE))
+		}
+		utils.PanicIfErr(fls.MkdirAll("/empty-dir", DEFAULT_DIR_FMODE))
+	}
+
+	t.Run("backup", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		createTree(fls)
+
+		var visited []string
+		backup := map[string]string{}
+
+		err := fls.EachFile(ctx, func(path core.Path, metadata *metaFsFileMetadata, open func() (io.ReadCloser, error)) error {
+			visited = append(visited, path.UnderlyingString())
+
+			if metadata.mode.IsDir() {
+				_, err := open()
+				assert.ErrorIs(t, err, ErrCannotOpenDir)
+				return nil
+			}
+
+			reader, err := open()
+			if err != nil {
+				return err
+			}
+			defer reader.Close()
+
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				return err
+			}
+			backup[path.UnderlyingString()] = string(content)
+			return nil
+		})
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, []string{
+			"/",
+			"/a.txt",
+			"/dir/",
+			"/dir/b.txt",
+			"/dir/sub/",
+			"/dir/sub/c.txt",
+			"/dir2/",
+			"/dir2/d.txt",
+			"/empty-dir/",
+		}, visited)
+
+		assert.Equal(t, files, backup)
+	})
+
+	t.Run("contents should only be read on demand", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		createTree(fls)
+
+		var opens []func() (io.ReadCloser, error)
+
+		err := fls.EachFile(ctx, func(path core.Path, metadata *metaFsFileMetadata, open func() (io.ReadCloser, error)) error {
+			if path == "/dir/b.txt" {
+				opens = append(opens, open)
+			}
+			return nil
+		})
+
+		if !assert.NoError(t, err) || !assert.Len(t, opens, 1) {
+			return
+		}
+
+		//the content should be the content at the time of the opening.
+		utils.PanicIfErr(util.WriteFile(fls, "/dir/b.txt", []byte("new b"), DEFAULT_FILE_FMODE))
+
+		reader, err := opens[0]()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer reader.Close()
+
+		content, err := io.ReadAll(reader)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "new b", string(content))
+		}
+	})
+
+	t.Run("an error returned by the callback should stop the walk", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		createTree(fls)
+
+		callbackErr := errors.New("callback error")
+		callCount := 0
+
+		err := fls.EachFile(ctx, func(path core.Path, metadata *metaFsFileMetadata, open func() (io.ReadCloser, error)) error {
+			callCount++
+			if path == "/dir/" {
+				return callbackErr
+			}
+			return nil
+		})
+
+		assert.ErrorIs(t, err, callbackErr)
+		assert.Equal(t, 3, callCount)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		createTree(fls)
+
+		walkCtx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		walkCtx.CancelGracefully()
+
+		err := fls.EachFile(walkCtx, func(path core.Path, metadata *metaFsFileMetadata, open func() (io.ReadCloser, error)) error {
+			assert.Fail(t, "the callback should not be called")
+			return nil
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestMetaFilesystemFileCountValidation(t *testing.T) {
+	t.Run("exceeding the limit by creating files one by one should be an error", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(100_000_000)
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			MaxFileCount: 10 + 1, //add one for the metadata file
+			Dir:          "/fs",
+		})
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		for i := 0; i < 10; i++ {
+			f, err := fls.Create("f" + strconv.Itoa(i))
+			if !assert.NoError(t, err) {
+				return
+			}
+			f.Close()
+		}
+		//at this point the file count has reached the maxiumum
+
+		f, err := fls.Create("f10")
+		if f != nil {
+			f.Close()
+		}
+
+		if !assert.ErrorIs(t, err, ErrMaxFileNumberAlreadyReached) {
+			return
+		}
+	})
+
+	t.Run("eviction: the least recently modified file should be removed to admit a new file", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(100_000_000)
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			MaxFileCount:                    3 + 1, //add one for the metadata file
+			Dir:                             "/fs",
+			EvictLeastRecentlyModifiedFiles: true,
+		})
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		utils.PanicIfErr(fls.MkdirAll("/dir", DEFAULT_DIR_FMODE))
+
+		for _, name := range []string{"/f0", "/dir/f1", "/f2"} {
+			utils.PanicIfErr(util.WriteFile(fls, name, []byte(name), DEFAULT_FILE_FMODE))
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		//modify /f0, /dir/f1 becomes the least recently modified file.
+		utils.PanicIfErr(util.WriteFile(fls, "/f0", []byte("new content"), DEFAULT_FILE_FMODE))
+
+		f, err := fls.Create("/f3")
+		if !assert.NoError(t, err) {
+			return
+		}
+		f.Close()
+
+		_, err = fls.Stat("/dir/f1")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		//the parent directory should no longer list the evicted file.
+		entries, err := fls.ReadDir("/dir")
+		if assert.NoError(t, err) {
+			assert.Empty(t, entries)
+		}
+
+		for _, name := range []string{"/f0", "/f2", "/f3"} {
+			_, err = fls.Stat(name)
+			assert.NoError(t, err, name)
+		}
+
+		//the concrete file of /dir/f1 should have been removed.
+		count, err := fls.getUnderlyingFileCount()
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, 4, count)
+		}
+
+		issues, err := fls.VerifyIntegrity(ctx)
+		if assert.NoError(t, err) {
+			assert.Empty(t, issues)
+		}
+	})
+
+	t.Run("eviction: open files should not be evicted", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(100_000_000)
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			MaxFileCount:                    2 + 1, //add one for the metadata file
+			Dir:                             "/fs",
+			EvictLeastRecentlyModifiedFiles: true,
+		})
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		f0, err := fls.Create("/f0")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f0.Close()
+
+		time.Sleep(10 * time.Millisecond)
+		utils.PanicIfErr(util.WriteFile(fls, "/f1", []byte("f1"), DEFAULT_FILE_FMODE))
+
+		f2, err := fls.Create("/f2")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f2.Close()
+
+		_, err = fls.Stat("/f0")
+		assert.NoError(t, err)
+
+		_, err = fls.Stat("/f1")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		//all files are open, no file can be evicted.
+		f3, err := fls.Create("/f3")
+		if f3 != nil {
+			f3.Close()
+		}
+		assert.ErrorIs(t, err, ErrMaxFileNumberAlreadyReached)
+	})
+
+	t.Run("exceeding the limit by creating files in parallel should be an error", func(t *testing.T) {
+		//flaky test
+		t.Skip()
+
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(100_000_000)
+
+		//the value is high to make sure some goroutines run at the same time
+		const fileCount = 1000
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			MaxFileCount:             fileCount + 1,  //add one for the metadata file
+			MaxParallelCreationCount: 10 * fileCount, //we set a high value to not have errors
+			Dir:                      "/fs",
+		})
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var errCount atomic.Int32 //error count should be fileCount
+		wg := new(sync.WaitGroup)
+		goroutineCount := 2 * fileCount
+		wg.Add(goroutineCount)
+
+		for i := 0; i < goroutineCount; i++ {
+			go func(i int) {
+				defer wg.Done()
+				f, err := fls.Create("f" + strconv.Itoa(i))
+				if err != nil {
+					errCount.Add(1)
+					return
+				}
+				f.Close()
+			}(i)
+		}
+
+		wg.Wait()
+
+		assert.Zero(t, fls.pendingFileCreations.Load())
+
+		if !assert.Equal(t, int32(fileCount), errCount.Load()) {
+			return
+		}
+	})
+
+}
+
+func TestMetaFilesystemParallelFileCreationValidation(t *testing.T) {
+
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+	underlyingFS := NewMemFilesystem(100_000_000)
+
+	maxParallelCreationCount := int16(100)
+
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		MaxFileCount:             10_000,
+		MaxParallelCreationCount: maxParallelCreationCount,
+		Dir:                      "/fs",
+	})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var errCount atomic.Int32 //error count should be fileCount
+
+	wg := new(sync.WaitGroup)
+	goroutineCount := int(maxParallelCreationCount + maxParallelCreationCount/10)
+	wg.Add(goroutineCount)
+
+	for i := 0; i < goroutineCount; i++ {
+		go func(i int) {
+			defer wg.Done()
+			f, err := fls.Create("f" + strconv.Itoa(i))
+			if err != nil {
+				errCount.Add(1)
+				return
+			}
+			f.Close()
+		}(i)
+	}
+
+	wg.Wait()
+
+	successCount := int16(goroutineCount) - int16(errCount.Load())
+	if !assert.Less(t, successCount, maxParallelCreationCount+10) {
+		return
+	}
+	assert.Zero(t, fls.pendingFileCreations.Load())
+}
+
+func TestMetaFilesystemOpenFileCountValidation(t *testing.T) {
+
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+	underlyingFS := NewMemFilesystem(100_000_000)
+
+	maxOpenFiles := 3
+
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir:          "/fs",
+		MaxOpenFiles: maxOpenFiles,
+	})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var files []billy.File
+
+	for i := 0; i < maxOpenFiles; i++ {
+		f, err := fls.Create("/f" + strconv.Itoa(i))
+		if !assert.NoError(t, err) {
+			return
+		}
+		files = append(files, f)
+	}
+
+	//the limit is reached.
+	_, err = fls.Create("/f" + strconv.Itoa(maxOpenFiles))
+	if !assert.ErrorIs(t, err, ErrTooManyOpenFiles) {
+		return
+	}
+
+	_, err = fls.Open("/f0")
+	if !assert.ErrorIs(t, err, ErrTooManyOpenFiles) {
+		return
+	}
+
+	//closing a file should allow another file to be opened.
+	if !assert.NoError(t, files[0].Close()) {
+		return
+	}
+
+	f, err := fls.Create("/f" + strconv.Itoa(maxOpenFiles))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	_, err = fls.Open("/f0")
+	assert.ErrorIs(t, err, ErrTooManyOpenFiles)
+
+	for _, f := range files[1:] {
+		f.Close()
+	}
+}
+
+func TestMetaFilesystemParallelExclusiveFileCreation(t *testing.T) {
+
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+	underlyingFS := NewMemFilesystem(100_000_000)
+
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir: "/fs",
+	})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	const goroutineCount = 100
+
+	var successCount atomic.Int32
+	var errExistCount atomic.Int32
+
+	wg := new(sync.WaitGroup)
+	wg.Add(goroutineCount)
+
+	for i := 0; i < goroutineCount; i++ {
+		go func() {
+			defer wg.Done()
+			f, err := fls.OpenFile("/file", os.O_RDWR|os.O_CREATE|os.O_EXCL, DEFAULT_FILE_FMODE)
+			if err != nil {
+				if errors.Is(err, os.ErrExist) {
+					errExistCount.Add(1)
+				}
+				return
+			}
+			successCount.Add(1)
+			f.Close()
+		}()
+	}
+
+	wg.Wait()
+
+	assert.EqualValues(t, 1, successCount.Load())
+	assert.EqualValues(t, goroutineCount-1, errExistCount.Load())
+}
+
+func TestMetaFilesystemParallelAppends(t *testing.T) {
+
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+	underlyingFS := NewMemFilesystem(100_000_000)
+
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir: "/fs",
+	})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if !assert.NoError(t, util.WriteFile(fls, "/file", []byte("start\n"), DEFAULT_FILE_FMODE)) {
+		return
+	}
+
+	const goroutineCount = 5
+	const lineCountPerGoroutine = 50
+
+	//all files are opened before the first append.
+	var files []billy.File
+	for i := 0; i < goroutineCount; i++ {
+		f, err := fls.OpenFile("/file", os.O_WRONLY|os.O_APPEND, DEFAULT_FILE_FMODE)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f.Close()
+		files = append(files, f)
+	}
+
+	wg := new(sync.WaitGroup)
+	wg.Add(goroutineCount)
+
+	for i := 0; i < goroutineCount; i++ {
+		go func(goroutineIndex int) {
+			defer wg.Done()
+			f := files[goroutineIndex]
+
+			for lineIndex := 0; lineIndex < lineCountPerGoroutine; lineIndex++ {
+				line := "line-" + strconv.Itoa(goroutineIndex) + "-" + strconv.Itoa(lineIndex) + "\n"
+				if _, err := f.Write([]byte(line)); !assert.NoError(t, err) {
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	content, err := util.ReadFile(fls, "/file")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if !assert.Len(t, lines, 1+goroutineCount*lineCountPerGoroutine) {
+		return
+	}
+
+	assert.Equal(t, "start", lines[0])
+
+	for i := 0; i < goroutineCount; i++ {
+		for lineIndex := 0; lineIndex < lineCountPerGoroutine; lineIndex++ {
+			assert.Contains(t, lines, "line-"+strconv.Itoa(i)+"-"+strconv.Itoa(lineIndex))
+		}
+	}
+}
+
+func TestMetaFilesystemParallelCompressedAppends(t *testing.T) {
+	_, fls, _ := openTestMetaFilesystem(t, MetaFilesystemParams{
+		Dir:      "/fs",
+		Compress: true,
+	})
+
+	if !assert.NoError(t, util.WriteFile(fls, "/file", []byte("start\n"), DEFAULT_FILE_FMODE)) {
+		return
+	}
+
+	const goroutineCount = 5
+	const lineCountPerGoroutine = 50
+
+	//all files are opened before the first append.
+	var files []billy.File
+	for i := 0; i < goroutineCount; i++ {
+		f, err := fls.OpenFile("/file", os.O_WRONLY|os.O_APPEND, DEFAULT_FILE_FMODE)
+		if !assert.NoError(t, err) {
+			return
+		}
+		files = append(files, f)
+	}
+
+	wg := new(sync.WaitGroup)
+	wg.Add(goroutineCount)
+
+	for i := 0; i < goroutineCount; i++ {
+		go func(goroutineIndex int) {
+			defer wg.Done()
+			f := files[goroutineIndex]
+
+			//the content is flushed by each file, the flushes should not overwrite the lines of the other files.
+			defer f.Close()
+
+			for lineIndex := 0; lineIndex < lineCountPerGoroutine; lineIndex++ {
+				line := "line-" + strconv.Itoa(goroutineIndex) + "-" + strconv.Itoa(lineIndex) + "\n"
+				if _, err := f.Write([]byte(line)); !assert.NoError(t, err) {
+					return
+				}
+			}
+
+			assert.NoError(t, f.(*metaFsFile).Sync())
+		}(i)
+	}
+
+	wg.Wait()
+
+	content, err := util.ReadFile(fls, "/file")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if !assert.Len(t, lines, 1+goroutineCount*lineCountPerGoroutine) {
+		return
+	}
+
+	assert.Equal(t, "start", lines[0])
+
+	for i := 0; i < goroutineCount; i++ {
+		for lineIndex := 0; lineIndex < lineCountPerGoroutine; lineIndex++ {
+			assert.Contains(t, lines, "line-"+strconv.Itoa(i)+"-"+strconv.Itoa(lineIndex))
+		}
+	}
+
+	stat, err := fls.Stat("/file")
+	if assert.NoError(t, err) {
+		assert.EqualValues(t, len(content), stat.Size())
+	}
+}
+
+func TestMetaFilesystemRenameAppendLocks(t *testing.T) {
+
+	openForAppend := func(t *testing.T, fls *MetaFilesystem, path string) *metaFsFile {
+		f, err := fls.OpenFile(path, os.O_WRONLY|os.O_APPEND, DEFAULT_FILE_FMODE)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		t.Cleanup(func() { f.Close() })
+		return f.(*metaFsFile)
+	}
+
+	t.Run("file", func(t *testing.T) {
+		_, fls, _ := openTestMetaFilesystem(t, MetaFilesystemParams{Dir: "/fs"})
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", nil, DEFAULT_FILE_FMODE))
+
+		f1 := openForAppend(t, fls, "/a.txt")
+
+		if !assert.NoError(t, fls.Rename("/a.txt", "/b.txt")) {
+			return
+		}
+
+		f2 := openForAppend(t, fls, "/b.txt")
+		assert.Same(t, f1.appendLock, f2.appendLock)
+		assert.NotContains(t, fls.appendLocks, "/a.txt")
+	})
+
+	t.Run("file in a renamed directory", func(t *testing.T) {
+		_, fls, _ := openTestMetaFilesystem(t, MetaFilesystemParams{Dir: "/fs"})
+		utils.PanicIfErr(fls.MkdirAll("/dir", DEFAULT_DIR_FMODE))
+		utils.PanicIfErr(util.WriteFile(fls, "/dir/a.txt", nil, DEFAULT_FILE_FMODE))
+
+		f1 := openForAppend(t, fls, "/dir/a.txt")
+
+		if !assert.NoError(t, fls.Rename("/dir", "/dir2")) {
+			return
+		}
+
+		f2 := openForAppend(t, fls, "/dir2/a.txt")
+		assert.Same(t, f1.appendLock, f2.appendLock)
+		assert.NotContains(t, fls.appendLocks, "/dir/a.txt")
+	})
+}
+
+func TestMetaFilesystemUsedSpaceValidation(t *testing.T) {
+
+	//TODO: do the tests without Dir: "/fs"
+
+	t.Run("the maxUsableSpace value should be greater than "+strconv.Itoa(METAFS_MIN_USABLE_SPACE), func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(100_000_000)
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			MaxUsableSpace: 100,
+			Dir:            "/fs",
+		})
+
+		if !assert.ErrorIs(t, err, ErrMaxUsableSpaceTooSmall) {
+			return
+		}
+
+		assert.Nil(t, fls)
+	})
+
+	t.Run("writing MaxUsableSpace bytes in a file in a single .Write() call should be an error", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(10 * METAFS_MIN_USABLE_SPACE)
+
+		maxUsableSpace := core.ByteCount(METAFS_MIN_USABLE_SPACE)
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			MaxUsableSpace: maxUsableSpace,
+			Dir:            "/fs",
+		})
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		f, err := fls.Create("file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f.Close()
+
+		content := bytes.Repeat([]byte{'x'}, int(maxUsableSpace))
+
+		n, err := f.Write(content)
+		if !assert.ErrorIs(t, err, ErrNoRemainingSpaceToApplyChange) {
+			return
+		}
+
+		assert.Zero(t, n)
+	})
+
+	t.Run("writing MaxUsableSpace bytes in a file in two .Write() calls (MaxUsableSpace / 2 in each call, no delay) should be an error", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(10 * METAFS_MIN_USABLE_SPACE)
+
+		maxUsableSpace := core.ByteCount(METAFS_MIN_USABLE_SPACE)
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			MaxUsableSpace: maxUsableSpace,
+			Dir:            "/fs",
+		})
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		f, err := fls.Create("file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f.Close()
+
+		content := bytes.Repeat([]byte{'x'}, int(maxUsableSpace/2))
+
+		n, err := f.Write(content)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, int(maxUsableSpace/2), n)
+
+		content = bytes.Repeat([]byte{'x'}, int(maxUsableSpace/2))
+
+		n, err = f.Write(content)
+		if !assert.ErrorIs(t, err, ErrNoRemainingSpaceToApplyChange) {
+			return
+		}
+
+		assert.Zero(t, n)
+	})
+
+	t.Run("allocating MaxUsableSpace bytes in a file in a single .Truncate() call should be an error", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(10 * METAFS_MIN_USABLE_SPACE)
+
+		maxUsableSpace := core.ByteCount(METAFS_MIN_USABLE_SPACE)
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			MaxUsableSpace: maxUsableSpace,
+			Dir:            "/fs",
+		})
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		f, err := fls.Create("file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f.Close()
+
+		err = f.Truncate(int64(maxUsableSpace))
+		if !assert.ErrorIs(t, err, ErrNoRemainingSpaceToApplyChange) {
+			return
+		}
+	})
+
+	t.Run("allocating MaxUsableSpace bytes in a file in two .Truncate() calls (MaxUsableSpace / 2 in each call, no delay) should be an error", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(10 * METAFS_MIN_USABLE_SPACE)
+
+		maxUsableSpace := core.ByteCount(METAFS_MIN_USABLE_SPACE)
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			MaxUsableSpace: maxUsableSpace,
+			Dir:            "/fs",
+		})
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		f, err := fls.Create("file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f.Close()
+
+		err = f.Truncate(int64(maxUsableSpace / 2))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		err = f.Truncate(int64(maxUsableSpace))
+		if !assert.ErrorIs(t, err, ErrNoRemainingSpaceToApplyChange) {
+			return
+		}
+	})
+
+	t.Run("reserving more than MaxUsableSpace bytes should fail", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(10 * METAFS_MIN_USABLE_SPACE)
+
+		maxUsableSpace := core.ByteCount(METAFS_MIN_USABLE_SPACE)
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			MaxUsableSpace: maxUsableSpace,
+			Dir:            "/fs",
+		})
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		reservation, ok := fls.ReserveSpace(maxUsableSpace + 1)
+		assert.False(t, ok)
+		assert.Nil(t, reservation)
+	})
+
+	t.Run("writes exceeding the space reserved near the limit should be rejected", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(10 * METAFS_MIN_USABLE_SPACE)
+
+		maxUsableSpace := core.ByteCount(METAFS_MIN_USABLE_SPACE)
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			MaxUsableSpace: maxUsableSpace,
+			Dir:            "/fs",
+		})
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		reserved := maxUsableSpace - 1_000_000
+
+		reservation, ok := fls.ReserveSpace(reserved)
+		if !assert.True(t, ok) {
+			return
+		}
+
+		//the reserved space should not be reservable again.
+		_, ok = fls.ReserveSpace(2_000_000)
+		assert.False(t, ok)
+
+		f, err := reservation.Create("file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f.Close()
+
+		//the reserved space should be usable by writes.
+		n, err := f.Write(bytes.Repeat([]byte{'x'}, int(reserved/2)))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, int(reserved/2), n)
+
+		//writing more than the remaining reserved space + the unreserved space should fail.
+		n, err = f.Write(bytes.Repeat([]byte{'x'}, int(reserved/2)+2_000_000))
+		if !assert.ErrorIs(t, err, ErrNoRemainingSpaceToApplyChange) {
+			return
+		}
+		assert.Zero(t, n)
+
+		reservation.Release()
+		reservation.Release()
+
+		assert.Zero(t, reservation.Remaining())
+
+		fls.usedSpaceCacheLock.Lock()
+		defer fls.usedSpaceCacheLock.Unlock()
+
+		assert.Zero(t, fls.reservedSpace)
+	})
+
+	t.Run("writes not performed through a reservation should not consume it", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(10 * METAFS_MIN_USABLE_SPACE)
+
+		maxUsableSpace := core.ByteCount(METAFS_MIN_USABLE_SPACE)
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			MaxUsableSpace: maxUsableSpace,
+			Dir:            "/fs",
+		})
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		reserved := maxUsableSpace - 1_000_000
+
+		reservation, ok := fls.ReserveSpace(reserved)
+		if !assert.True(t, ok) {
+			return
+		}
+		defer reservation.Release()
+
+		//a concurrent writer should only be able to use the unreserved space.
+		otherFile, err := fls.Create("other-file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer otherFile.Close()
+
+		n, err := otherFile.Write(bytes.Repeat([]byte{'x'}, 2_000_000))
+		if !assert.ErrorIs(t, err, ErrNoRemainingSpaceToApplyChange) {
+			return
+		}
+		assert.Zero(t, n)
+
+		err = fls.WriteFileAtomic("/other-file-2", bytes.Repeat([]byte{'x'}, 2_000_000), DEFAULT_FILE_FMODE)
+		assert.ErrorIs(t, err, ErrNoRemainingSpaceToApplyChange)
+
+		assert.Equal(t, reserved, reservation.Remaining())
+
+		//the batch operation should be able to use the whole reservation.
+		f, err := reservation.Create("file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f.Close()
+
+		n, err = f.Write(bytes.Repeat([]byte{'x'}, int(reserved)))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, int(reserved), n)
+		assert.Zero(t, reservation.Remaining())
+	})
+
+	t.Run("concurrent writers should not consume the reservation of a batch operation", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(10 * METAFS_MIN_USABLE_SPACE)
+
+		maxUsableSpace := core.ByteCount(METAFS_MIN_USABLE_SPACE)
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			MaxUsableSpace: maxUsableSpace,
+			Dir:            "/fs",
+		})
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		const FILE_COUNT = 10
+		const FILE_SIZE = 10_000
+
+		reservation, ok := fls.ReserveSpace(FILE_COUNT * FILE_SIZE)
+		if !assert.True(t, ok) {
+			return
+		}
+		defer reservation.Release()
+
+		//fill the unreserved space (the metadata also use space).
+		unreservedFreeSpace, err := fls.computeFreeSpace(false)
+		if !assert.NoError(t, err) {
+			return
+		}
+		filler := bytes.Repeat([]byte{'x'}, int(unreservedFreeSpace-FILE_SIZE/2))
+		utils.PanicIfErr(util.WriteFile(fls, "/filler", filler, DEFAULT_FILE_FMODE))
+
+		wg := new(sync.WaitGroup)
+		wg.Add(2)
+
+		var batchErrors, otherErrors []error
+		var otherErrorsLock sync.Mutex
+
+		go func() {
+			defer wg.Done()
+			for i := 0; i < FILE_COUNT; i++ {
+				err := reservation.WriteFileAtomic(core.Path("/batch/"+strconv.Itoa(i)), bytes.Repeat([]byte{'x'}, FILE_SIZE), DEFAULT_FILE_FMODE)
+				if err != nil {
+					batchErrors = append(batchErrors, err)
+				}
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for i := 0; i < FILE_COUNT; i++ {
+				err := fls.WriteFileAtomic(core.Path("/other-"+strconv.Itoa(i)), bytes.Repeat([]byte{'x'}, FILE_SIZE), DEFAULT_FILE_FMODE)
+				if err != nil {
+					otherErrorsLock.Lock()
+					otherErrors = append(otherErrors, err)
+					otherErrorsLock.Unlock()
+				}
+			}
+		}()
+
+		wg.Wait()
+
+		assert.Empty(t, batchErrors)
+		assert.Len(t, otherErrors, FILE_COUNT)
+		assert.Zero(t, reservation.Remaining())
+	})
+}
+
+func TestMetaFilesystemUnderlyingAvailableSpaceValidation(t *testing.T) {
+
+	t.Run("write larger than the available space", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := &availableSpaceReportingFilesystem{MemFilesystem: NewMemFilesystem(100_000_000)}
+		underlyingFS.availableSpace.Store(10)
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			Dir: "/metafs/",
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer fls.Close(ctx)
+
+		f, err := fls.Create("/file.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f.Close()
+
+		_, err = f.Write(bytes.Repeat([]byte{'a'}, 100))
+		assert.ErrorIs(t, err, ErrUnderlyingStorageFull)
+		assert.NotErrorIs(t, err, ErrNoRemainingSpaceToApplyChange)
+	})
+
+	t.Run("storage becoming full", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := &availableSpaceReportingFilesystem{MemFilesystem: NewMemFilesystem(100_000_000)}
+		underlyingFS.availableSpace.Store(1000)
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			Dir: "/metafs/",
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer fls.Close(ctx)
+
+		f, err := fls.Create("/file.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f.Close()
+
+		_, err = f.Write(bytes.Repeat([]byte{'a'}, 100))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		underlyingFS.availableSpace.Store(10)
+		time.Sleep(METAFS_AVAILABLE_SPACE_CHECK_INTERVAL)
+
+		_, err = f.Write(bytes.Repeat([]byte{'a'}, 100))
+		assert.ErrorIs(t, err, ErrUnderlyingStorageFull)
+	})
+
+	t.Run("successive writes should be taken into account before the cached available space is refreshed", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := &availableSpaceReportingFilesystem{MemFilesystem: NewMemFilesystem(100_000_000)}
+		underlyingFS.availableSpace.Store(150)
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			Dir: "/metafs/",
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer fls.Close(ctx)
+
+		f, err := fls.Create("/file.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f.Close()
+
+		_, err = f.Write(bytes.Repeat([]byte{'a'}, 100))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = f.Write(bytes.Repeat([]byte{'a'}, 100))
+		assert.ErrorIs(t, err, ErrUnderlyingStorageFull)
+	})
+}
+
+// availableSpaceReportingFilesystem is an in-memory filesystem that reports a configurable available space,
+// it is used to simulate the exhaustion of a disk.
+type availableSpaceReportingFilesystem struct {
+	*MemFilesystem
+	availableSpace atomic.Int64
+}
+
+func (fls *availableSpaceReportingFilesystem) AvailableSpace(path string) (int64, error) {
+	return fls.availableSpace.Load(), nil
+}
+
+func TestMetaFilesystemEventQueueBound(t *testing.T) {
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+	underlyingFS := NewMemFilesystem(100_000_000)
+
+	const maxEventQueueLength = 3
+
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir:                 "/fs",
+		MaxEventQueueLength: maxEventQueueLength,
+	})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	f, err := fls.Create("file")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	//flood the queue: one creation event followed by writeCount write events.
+	const writeCount = 10
+	for i := 0; i < writeCount; i++ {
+		_, err := f.Write([]byte("a"))
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+
+	assert.EqualValues(t, 1+writeCount-maxEventQueueLength, fls.DroppedEventCount())
+
+	//the newest events should have been retained.
+	events := fls.Events().Values()
+	if !assert.Len(t, events, maxEventQueueLength) {
+		return
+	}
+
+	for _, event := range events {
+		assert.True(t, event.writeOp)
+	}
+
+	fls.lastModificationTimesLock.RLock()
+	lastModifTime := fls.lastModificationTimes["/file"]
+	fls.lastModificationTimesLock.RUnlock()
+
+	assert.Equal(t, lastModifTime, events[len(events)-1].dateTime)
+}
+
+func TestMetaFilesystemSubscribe(t *testing.T) {
+
+	createMetaFS := func(t *testing.T) (*core.Context, *MetaFilesystem) {
+		ctx, fls, _ := openTestMetaFilesystem(t, MetaFilesystemParams{Dir: "/metafs/"})
+
+		utils.PanicIfErr(fls.MkdirAll("/dir", DEFAULT_DIR_FMODE))
+		return ctx, fls
+	}
+
+	receiveAvailableEvents := func(events <-chan Event) (received []Event, closed bool) {
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return received, true
+				}
+				received = append(received, event)
+			default:
+				return received, false
+			}
+		}
+	}
+
+	t.Run("only events in the subtree should be received", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		events, unsubscribe, err := fls.Subscribe(ctx, "/dir/...")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer unsubscribe()
+
+		utils.PanicIfErrAmong(
+			util.WriteFile(fls, "/dir/a.txt", []byte("a"), DEFAULT_FILE_FMODE),
+			util.WriteFile(fls, "/b.txt", []byte("b"), DEFAULT_FILE_FMODE),
+		)
+
+		time.Sleep(SLEEP_DURATION)
+
+		received, closed := receiveAvailableEvents(events)
+		if !assert.False(t, closed) || !assert.NotEmpty(t, received) {
+			return
+		}
+
+		for _, event := range received {
+			assert.Equal(t, core.Path("/dir/a.txt"), event.Path())
+		}
+		assert.True(t, received[0].HasCreateOp())
+	})
+
+	t.Run("the channel should be closed after unsubscribing", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		events, unsubscribe, err := fls.Subscribe(ctx, "/dir/...")
+		if !assert.NoError(t, err) {
+			return
+		}
+		unsubscribe()
+		unsubscribe() //should have no effect
+
+		utils.PanicIfErr(util.WriteFile(fls, "/dir/a.txt", []byte("a"), DEFAULT_FILE_FMODE))
+		time.Sleep(SLEEP_DURATION)
+
+		received, closed := receiveAvailableEvents(events)
+		assert.True(t, closed)
+		assert.Empty(t, received)
+		assert.Empty(t, fls.GetWatchers())
+	})
+
+	t.Run("the subscription should end when the context is done", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		subscriptionCtx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+
+		events, _, err := fls.Subscribe(subscriptionCtx, "/dir/...")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		subscriptionCtx.CancelGracefully()
+		time.Sleep(SLEEP_DURATION)
+
+		_, closed := receiveAvailableEvents(events)
+		assert.True(t, closed)
+	})
+
+	t.Run("relative path pattern", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		_, _, err := fls.Subscribe(ctx, "./dir/...")
+		assert.Error(t, err)
+	})
+}
+
+func TestMetaFilesystemWriteEventAndStatConsistency(t *testing.T) {
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+	underlyingFS := NewMemFilesystem(100_000_000)
+
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir: "/fs",
+	})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	f, err := fls.Create("file")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	checkLastEvent := func(t *testing.T) {
+		events := fls.Events().DequeueAll()
+		if !assert.NotEmpty(t, events) {
+			return
+		}
+		lastEvent := events[len(events)-1]
+		assert.True(t, lastEvent.HasWriteOp())
+
+		stat, err := fls.Stat("/file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, stat.ModTime().Equal(time.Time(lastEvent.Time())))
+	}
+
+	t.Run("write", func(t *testing.T) {
+		_, err := f.Write([]byte("a"))
+		if !assert.NoError(t, err) {
+			return
+		}
+		checkLastEvent(t)
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		err := f.Truncate(0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		checkLastEvent(t)
+	})
+
+	t.Run("parallel writes", func(t *testing.T) {
+		wg := new(sync.WaitGroup)
+		wg.Add(10)
+
+		for i := 0; i < 10; i++ {
+			go func() {
+				defer wg.Done()
+				f.Write([]byte("a"))
+			}()
+		}
+
+		wg.Wait()
+		checkLastEvent(t)
+	})
+}
+
+func TestMetaFilesystemDirectorySizes(t *testing.T) {
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+	underlyingFS := NewMemFilesystem(100_000_000)
+
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir: "/fs",
+	})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	utils.PanicIfErrAmong(
+		util.WriteFile(fls, "/a.txt", []byte("1"), DEFAULT_FILE_FMODE),
+		fls.MkdirAll("/dir/subdir", DEFAULT_DIR_FMODE),
+		fls.MkdirAll("/empty-dir", DEFAULT_DIR_FMODE),
+		util.WriteFile(fls, "/dir/b.txt", []byte("12"), DEFAULT_FILE_FMODE),
+		util.WriteFile(fls, "/dir/c.txt", []byte("123"), DEFAULT_FILE_FMODE),
+		util.WriteFile(fls, "/dir/subdir/d.txt", []byte("1234"), DEFAULT_FILE_FMODE),
+	)
+
+	t.Run("direct children", func(t *testing.T) {
+		sizes, err := fls.DirectorySizes(false)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, map[string]core.ByteCount{
+			"/":           1,
+			"/dir":        5,
+			"/dir/subdir": 4,
+			"/empty-dir":  0,
+		}, sizes)
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		sizes, err := fls.DirectorySizes(true)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, map[string]core.ByteCount{
+			"/":           10,
+			"/dir":        9,
+			"/dir/subdir": 4,
+			"/empty-dir":  0,
+		}, sizes)
+	})
+
+	t.Run("compressed files", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		fls, err := OpenMetaFilesystem(ctx, NewMemFilesystem(100_000_000), MetaFilesystemParams{
+			Dir:      "/fs",
+			Compress: true,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		//the compressed content is much smaller than the original content.
+		content := bytes.Repeat([]byte("a"), 10_000)
+		utils.PanicIfErrAmong(
+			util.WriteFile(fls, "/dir/a.txt", content, DEFAULT_FILE_FMODE),
+			fls.WriteFileAtomic("/dir/b.txt", content, DEFAULT_FILE_FMODE),
+		)
+
+		stat, err := fls.Stat("/dir/a.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.EqualValues(t, len(content), stat.Size())
+
+		sizes, err := fls.DirectorySizes(true)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, map[string]core.ByteCount{
+			"/":    2 * 10_000,
+			"/dir": 2 * 10_000,
+		}, sizes)
+	})
+}
+
+func TestMetaFilesystemChtimes(t *testing.T) {
+
+	createMetaFS := func(t *testing.T) (*core.Context, *MetaFilesystem) {
+		ctx, fls, _ := openTestMetaFilesystem(t, MetaFilesystemParams{Dir: "/fs"})
+		return ctx, fls
+	}
+
+	pastTime := time.Date(2020, time.January, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("file", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("1"), DEFAULT_FILE_FMODE))
+
+		if !assert.NoError(t, fls.Chtimes("/a.txt", pastTime, pastTime)) {
+			return
+		}
+
+		stat, err := fls.Stat("/a.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, pastTime.Equal(stat.ModTime()))
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		utils.PanicIfErr(fls.MkdirAll("/dir", DEFAULT_DIR_FMODE))
+
+		if !assert.NoError(t, fls.Chtimes("/dir/", pastTime, pastTime)) {
+			return
+		}
+
+		stat, err := fls.Stat("/dir/")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, pastTime.Equal(stat.ModTime()))
+	})
+
+	t.Run("creation time", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("1"), DEFAULT_FILE_FMODE))
+
+		creationTime := pastTime.Add(-time.Hour)
+
+		if !assert.NoError(t, fls.ChtimesWithCreationTime("/a.txt", pastTime, pastTime, creationTime)) {
+			return
+		}
+
+		stat, err := fls.Stat("/a.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, pastTime.Equal(stat.ModTime()))
+		assert.True(t, creationTime.Equal(time.Time(stat.(core.FileInfo).CreationTime_)))
+	})
+
+	t.Run("write after Chtimes", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("1"), DEFAULT_FILE_FMODE))
+		utils.PanicIfErr(fls.Chtimes("/a.txt", pastTime, pastTime))
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("2"), DEFAULT_FILE_FMODE))
+
+		stat, err := fls.Stat("/a.txt")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, stat.ModTime().After(pastTime))
+	})
+
+	t.Run("non-existing file", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		err := fls.Chtimes("/a.txt", pastTime, pastTime)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("closed filesystem", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		utils.PanicIfErr(util.WriteFile(fls, "/a.txt", []byte("1"), DEFAULT_FILE_FMODE))
+		utils.PanicIfErr(fls.Close(ctx))
+
+		err := fls.Chtimes("/a.txt", pastTime, pastTime)
+		assert.ErrorIs(t, err, ErrClosedFilesystem)
+	})
+}
+
+func TestMetaFilesystemReadDirPage(t *testing.T) {
+	ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+	underlyingFS := NewMemFilesystem(100_000_000)
+
+	fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+		Dir: "/fs",
+	})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	const FILE_COUNT = 250
+
+	utils.PanicIfErr(fls.MkdirAll("/dir", DEFAULT_DIR_FMODE))
+	for i := 0; i < FILE_COUNT; i++ {
+		utils.PanicIfErr(util.WriteFile(fls, "/dir/file"+strconv.Itoa(i)+".txt", nil, DEFAULT_FILE_FMODE))
+	}
+
+	entries, err := fls.ReadDir("/dir")
+	utils.PanicIfErr(err)
+
+	var expectedNames []string
+	for _, entry := range entries {
+		expectedNames = append(expectedNames, entry.Name())
+	}
+
+	t.Run("paging through the directory", func(t *testing.T) {
+		const LIMIT = 7
+
+		var names []string
+		offset := 0
+
+		for {
+			page, hasMore, err := fls.ReadDirPage("/dir", offset, LIMIT)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if hasMore && !assert.Len(t, page, LIMIT) {
+				return
+			}
+
+			for _, entry := range page {
+				names = append(names, entry.Name())
+			}
+			offset += len(page)
+
+			if !hasMore {
+				break
+			}
+		}
+
+		assert.Equal(t, expectedNames, names)
+	})
+
+	t.Run("page containing the last entries", func(t *testing.T) {
+		page, hasMore, err := fls.ReadDirPage("/dir", FILE_COUNT-2, 10)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.False(t, hasMore)
+		if assert.Len(t, page, 2) {
+			assert.Equal(t, expectedNames[FILE_COUNT-2], page[0].Name())
+			assert.Equal(t, expectedNames[FILE_COUNT-1], page[1].Name())
+		}
+	})
 
-		n, err = f.Write(content)
-		if !assert.ErrorIs(t, err, ErrNoRemainingSpaceToApplyChange) {
+	t.Run("offset greater than the number of entries", func(t *testing.T) {
+		page, hasMore, err := fls.ReadDirPage("/dir", FILE_COUNT+1, 10)
+		if !assert.NoError(t, err) {
 			return
 		}
+		assert.False(t, hasMore)
+		assert.Empty(t, page)
+	})
 
-		assert.Zero(t, n)
+	t.Run("invalid limit", func(t *testing.T) {
+		_, _, err := fls.ReadDirPage("/dir", 0, 0)
+		assert.Error(t, err)
 	})
 
-	t.Run("allocating MaxUsableSpace bytes in a file in a single .Truncate() call should be an error", func(t *testing.T) {
+	t.Run("non-existing directory", func(t *testing.T) {
+		_, _, err := fls.ReadDirPage("/missing-dir", 0, 10)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestMetaFilesystemVerifyIntegrity(t *testing.T) {
+
+	createMetaFS := func(t *testing.T) (*core.Context, *MetaFilesystem) {
+		ctx, fls, _ := openTestMetaFilesystem(t, MetaFilesystemParams{Dir: "/fs"})
+
+		utils.PanicIfErrAmong(
+			util.WriteFile(fls, "/a.txt", []byte("1"), DEFAULT_FILE_FMODE),
+			fls.MkdirAll("/dir", DEFAULT_DIR_FMODE),
+			util.WriteFile(fls, "/dir/b.txt", []byte("12"), DEFAULT_FILE_FMODE),
+		)
+
+		return ctx, fls
+	}
+
+	t.Run("no issues", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		issues, err := fls.VerifyIntegrity(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Empty(t, issues)
+	})
+
+	t.Run("orphaned metadata entry and dangling child reference", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		//add a metadata entry whose parent directory does not exist.
+		utils.PanicIfErr(fls.setFileMetadata(&metaFsFileMetadata{
+			path: "/missing-dir/orphan/",
+			mode: os.ModeDir | DEFAULT_DIR_FMODE,
+		}, nil))
+
+		//add a child without metadata to /dir.
+		dirMetadata, _, err := fls.getFileMetadata("/dir/", nil)
+		utils.PanicIfErr(err)
+		dirMetadata.children = append(dirMetadata.children, "missing.txt")
+		utils.PanicIfErr(fls.setFileMetadata(dirMetadata, nil))
+
+		issues, err := fls.VerifyIntegrity(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		if !assert.Len(t, issues, 2) {
+			return
+		}
+
+		assert.Equal(t, DanglingChildReference, issues[0].Kind)
+		assert.Equal(t, "/dir/missing.txt", issues[0].Path)
+
+		assert.Equal(t, OrphanedMetadata, issues[1].Kind)
+		assert.Equal(t, "/missing-dir/orphan", issues[1].Path)
+	})
+
+	t.Run("missing concrete file", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		metadata, _, err := fls.getFileMetadata("/a.txt", nil)
+		utils.PanicIfErr(err)
+		utils.PanicIfErr(fls.underlying.Remove(metadata.concreteFile.UnderlyingString()))
+
+		issues, err := fls.VerifyIntegrity(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		if !assert.Len(t, issues, 1) {
+			return
+		}
+		assert.Equal(t, MissingConcreteFile, issues[0].Kind)
+		assert.Equal(t, "/a.txt", issues[0].Path)
+	})
+
+	t.Run("closed filesystem", func(t *testing.T) {
+		ctx, fls := createMetaFS(t)
+		defer ctx.CancelGracefully()
+
+		utils.PanicIfErr(fls.Close(ctx))
+
+		_, err := fls.VerifyIntegrity(ctx)
+		assert.ErrorIs(t, err, ErrClosedFilesystem)
+	})
+}
+
+func TestMetaFilesystemBatchedMetadataWrites(t *testing.T) {
+
+	isCommitted := func(fls *MetaFilesystem, path core.Path) bool {
+		tx, err := fls.metadata.Begin(false)
+		if err != nil {
+			panic(err)
+		}
+		defer tx.Rollback()
+
+		_, err = tx.Get(getKvKeyFromPath(path).UnderlyingString())
+		if errors.Is(err, buntdb.ErrNotFound) {
+			return false
+		}
+		if err != nil {
+			panic(err)
+		}
+		return true
+	}
+
+	t.Run("writes should be visible before being committed", func(t *testing.T) {
 		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
 		defer ctx.CancelGracefully()
-		underlyingFS := NewMemFilesystem(10 * METAFS_MIN_USABLE_SPACE)
+		underlyingFS := NewMemFilesystem(100_000_000)
 
-		maxUsableSpace := core.ByteCount(METAFS_MIN_USABLE_SPACE)
 		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
-			MaxUsableSpace: maxUsableSpace,
-			Dir:            "/fs",
+			Dir:                         "/metafs/",
+			MetadataWriteBatchingWindow: time.Hour,
 		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer fls.Close(ctx)
+
+		if !assert.NoError(t, fls.MkdirAll("/dir", DEFAULT_DIR_FMODE)) {
+			return
+		}
+
+		if !assert.NoError(t, util.WriteFile(fls, "/dir/a.txt", []byte("a"), DEFAULT_FILE_FMODE)) {
+			return
+		}
 
+		assert.False(t, isCommitted(fls, "/dir/"))
+		assert.False(t, isCommitted(fls, "/dir/a.txt"))
+
+		info, err := fls.Stat("/dir/a.txt")
 		if !assert.NoError(t, err) {
 			return
 		}
+		assert.EqualValues(t, 1, info.Size())
 
-		f, err := fls.Create("file")
+		entries, err := fls.ReadDir("/dir")
 		if !assert.NoError(t, err) {
 			return
 		}
-		defer f.Close()
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, "a.txt", entries[0].Name())
+		}
 
-		err = f.Truncate(int64(maxUsableSpace))
-		if !assert.ErrorIs(t, err, ErrNoRemainingSpaceToApplyChange) {
+		if !assert.NoError(t, fls.SyncAll()) {
 			return
 		}
+
+		assert.True(t, isCommitted(fls, "/dir/"))
+		assert.True(t, isCommitted(fls, "/dir/a.txt"))
 	})
 
-	t.Run("allocating MaxUsableSpace bytes in a file in two .Truncate() calls (MaxUsableSpace / 2 in each call, no delay) should be an error", func(t *testing.T) {
+	t.Run("writes should be committed before a transaction begins", func(t *testing.T) {
 		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
 		defer ctx.CancelGracefully()
-		underlyingFS := NewMemFilesystem(10 * METAFS_MIN_USABLE_SPACE)
+		underlyingFS := NewMemFilesystem(100_000_000)
 
-		maxUsableSpace := core.ByteCount(METAFS_MIN_USABLE_SPACE)
 		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
-			MaxUsableSpace: maxUsableSpace,
-			Dir:            "/fs",
+			Dir:                         "/metafs/",
+			MetadataWriteBatchingWindow: time.Hour,
 		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer fls.Close(ctx)
+
+		if !assert.NoError(t, util.WriteFile(fls, "/a.txt", nil, DEFAULT_FILE_FMODE)) {
+			return
+		}
+
+		//Rename uses a transaction.
+		if !assert.NoError(t, fls.Rename("/a.txt", "/b.txt")) {
+			return
+		}
+
+		assert.False(t, isCommitted(fls, "/a.txt"))
+		assert.True(t, isCommitted(fls, "/b.txt"))
 
+		_, err = fls.Stat("/a.txt")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		_, err = fls.Stat("/b.txt")
+		assert.NoError(t, err)
+	})
+
+	t.Run("writes should be committed after the batching window", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(100_000_000)
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			Dir:                         "/metafs/",
+			MetadataWriteBatchingWindow: 10 * time.Millisecond,
+		})
 		if !assert.NoError(t, err) {
 			return
 		}
+		defer fls.Close(ctx)
 
-		f, err := fls.Create("file")
+		if !assert.NoError(t, util.WriteFile(fls, "/a.txt", nil, DEFAULT_FILE_FMODE)) {
+			return
+		}
+
+		assert.Eventually(t, func() bool {
+			return isCommitted(fls, "/a.txt")
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("writes should be committed when the filesystem is closed", func(t *testing.T) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		underlyingFS := NewMemFilesystem(100_000_000)
+
+		fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			Dir:                         "/metafs/",
+			MetadataWriteBatchingWindow: time.Hour,
+		})
 		if !assert.NoError(t, err) {
 			return
 		}
-		defer f.Close()
 
-		err = f.Truncate(int64(maxUsableSpace / 2))
+		for i := 0; i < 10; i++ {
+			if !assert.NoError(t, util.WriteFile(fls, "/dir/file"+strconv.Itoa(i), nil, DEFAULT_FILE_FMODE)) {
+				return
+			}
+		}
+
+		if !assert.NoError(t, fls.Close(ctx)) {
+			return
+		}
+
+		fls, err = OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+			Dir: "/metafs/",
+		})
 		if !assert.NoError(t, err) {
 			return
 		}
+		defer fls.Close(ctx)
 
-		err = f.Truncate(int64(maxUsableSpace))
-		if !assert.ErrorIs(t, err, ErrNoRemainingSpaceToApplyChange) {
+		entries, err := fls.ReadDir("/dir")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Len(t, entries, 10)
+
+		issues, err := fls.VerifyIntegrity(ctx)
+		if !assert.NoError(t, err) {
 			return
 		}
+		assert.Empty(t, issues)
 	})
 }
 
@@ -829,6 +4321,76 @@ func TestMetaFilesystemTakeSnapshot(t *testing.T) {
 	}
 
 	testSnapshoting(t, createEmptyMetaFS)
+
+	t.Run("max total size", func(t *testing.T) {
+		createFS := func(t *testing.T) (*core.Context, core.SnapshotableFilesystem) {
+			ctx, fls := createEmptyMetaFS(t)
+
+			utils.PanicIfErrAmong(
+				util.WriteFile(fls, "/a.ix", bytes.Repeat([]byte{'a'}, 100), DEFAULT_FILE_FMODE),
+				fls.MkdirAll("/dir", DEFAULT_DIR_FMODE),
+				util.WriteFile(fls, "/dir/b.ix", bytes.Repeat([]byte{'b'}, 50), DEFAULT_FILE_FMODE),
+				//excluded file
+				util.WriteFile(fls, "/c.txt", bytes.Repeat([]byte{'c'}, 1000), DEFAULT_FILE_FMODE),
+			)
+			return ctx, fls
+		}
+
+		t.Run("just under the maximum", func(t *testing.T) {
+			ctx, fls := createFS(t)
+			defer ctx.CancelGracefully()
+
+			snapshot, err := fls.TakeFilesystemSnapshot(core.FilesystemSnapshotConfig{
+				GetContent:       func(ChecksumSHA256 [32]byte) core.AddressableContent { return nil },
+				InclusionFilters: []core.PathPattern{"/**/*.ix"},
+				MaxTotalSize:     150,
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			content, err := snapshot.Content("/dir/b.ix")
+			if assert.NoError(t, err) {
+				assert.Equal(t, bytes.Repeat([]byte{'b'}, 50), utils.Must(io.ReadAll(content.Reader())))
+			}
+		})
+
+		t.Run("just over the maximum", func(t *testing.T) {
+			ctx, fls := createFS(t)
+			defer ctx.CancelGracefully()
+
+			snapshot, err := fls.TakeFilesystemSnapshot(core.FilesystemSnapshotConfig{
+				GetContent:       func(ChecksumSHA256 [32]byte) core.AddressableContent { return nil },
+				InclusionFilters: []core.PathPattern{"/**/*.ix"},
+				MaxTotalSize:     149,
+			})
+			assert.ErrorIs(t, err, core.ErrSnapshotMaxTotalSizeExceeded)
+			assert.Nil(t, snapshot)
+		})
+
+		t.Run("a file being written should be counted", func(t *testing.T) {
+			ctx, fls := createFS(t)
+			defer ctx.CancelGracefully()
+
+			f, err := fls.OpenFile("/dir/b.ix", os.O_WRONLY|os.O_APPEND, DEFAULT_FILE_FMODE)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer f.Close()
+
+			_, err = f.Write([]byte("b"))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			_, err = fls.TakeFilesystemSnapshot(core.FilesystemSnapshotConfig{
+				GetContent:       func(ChecksumSHA256 [32]byte) core.AddressableContent { return nil },
+				InclusionFilters: []core.PathPattern{"/**/*.ix"},
+				MaxTotalSize:     150,
+			})
+			assert.ErrorIs(t, err, core.ErrSnapshotMaxTotalSizeExceeded)
+		})
+	})
 }
 
 func TestMetaFilesystemWalk(t *testing.T) {
@@ -1165,7 +4727,7 @@ func TestMetaFilesystemWalk(t *testing.T) {
 
 			var traversal []string
 
-			err = fls.Walk(func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error {
+			err = fls.Walk(ctx, func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error {
 				traversal = append(traversal, normalizedPath)
 				return nil
 			})
@@ -1178,3 +4740,161 @@ func TestMetaFilesystemWalk(t *testing.T) {
 		})
 	}
 }
+
+func TestMetaFilesystemWalkCancellation(t *testing.T) {
+	const DIR_COUNT = 20
+	const FILES_PER_DIR = 50
+
+	setup := func(t *testing.T) (*core.Context, *MetaFilesystem) {
+		ctx, fls, _ := openTestMetaFilesystem(t, MetaFilesystemParams{
+			Dir:          "/fs",
+			MaxFileCount: 2 * DIR_COUNT * FILES_PER_DIR,
+		})
+
+		for i := 0; i < DIR_COUNT; i++ {
+			dir := "/dir" + strconv.Itoa(i)
+			utils.PanicIfErr(fls.MkdirAll(dir, DEFAULT_DIR_FMODE))
+			for j := 0; j < FILES_PER_DIR; j++ {
+				f, err := fls.Create(dir + "/f" + strconv.Itoa(j))
+				utils.PanicIfErr(err)
+				f.Close()
+			}
+		}
+		return ctx, fls
+	}
+
+	t.Run("walk", func(t *testing.T) {
+		_, fls := setup(t)
+
+		walkCtx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer walkCtx.CancelGracefully()
+
+		visitCount := 0
+		err := fls.Walk(walkCtx, func(normalizedPath string, path core.Path, metadata *metaFsFileMetadata) error {
+			visitCount++
+			if visitCount == 10 {
+				walkCtx.CancelGracefully()
+			}
+			return nil
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 10, visitCount)
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		_, fls := setup(t)
+
+		snapshotCtx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		snapshotCtx.CancelGracefully()
+
+		snapshot, err := fls.TakeFilesystemSnapshot(core.FilesystemSnapshotConfig{
+			GetContent: func(ChecksumSHA256 [32]byte) core.AddressableContent {
+				return nil
+			},
+			InclusionFilters: []core.PathPattern{"/..."},
+			Context:          snapshotCtx,
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, snapshot)
+	})
+
+	t.Run("snapshot without context: the context of the filesystem should be used", func(t *testing.T) {
+		ctx, fls := setup(t)
+		ctx.CancelGracefully()
+
+		snapshot, err := fls.TakeFilesystemSnapshot(core.FilesystemSnapshotConfig{
+			GetContent: func(ChecksumSHA256 [32]byte) core.AddressableContent {
+				return nil
+			},
+			InclusionFilters: []core.PathPattern{"/..."},
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, snapshot)
+	})
+}
+
+func BenchmarkMetaFilesystemFileCreation(b *testing.B) {
+	const FILE_COUNT = 100
+
+	run := func(b *testing.B, batchingWindow time.Duration) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+			underlyingFS := NewMemFilesystem(100_000_000)
+
+			fls, err := OpenMetaFilesystem(ctx, underlyingFS, MetaFilesystemParams{
+				Dir:                         "/metafs/",
+				MaxFileCount:                FILE_COUNT + 1,
+				MetadataWriteBatchingWindow: batchingWindow,
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.StartTimer()
+
+			for fileIndex := 0; fileIndex < FILE_COUNT; fileIndex++ {
+				f, err := fls.Create("/file" + strconv.Itoa(fileIndex))
+				if err != nil {
+					b.Fatal(err)
+				}
+				f.Close()
+			}
+
+			if err := fls.SyncAll(); err != nil {
+				b.Fatal(err)
+			}
+
+			b.StopTimer()
+			fls.Close(ctx)
+			ctx.CancelGracefully()
+			b.StartTimer()
+		}
+	}
+
+	b.Run("without batching", func(b *testing.B) {
+		run(b, 0)
+	})
+
+	b.Run("with batching", func(b *testing.B) {
+		run(b, time.Second)
+	})
+}
+
+func BenchmarkMetaFilesystemStat(b *testing.B) {
+	run := func(b *testing.B, statCacheSize int) {
+		ctx := core.NewContexWithEmptyState(core.ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		fls, err := OpenMetaFilesystem(ctx, NewMemFilesystem(100_000_000), MetaFilesystemParams{
+			Dir:           "/metafs/",
+			StatCacheSize: statCacheSize,
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer fls.Close(ctx)
+
+		if err := util.WriteFile(fls, "/file.txt", []byte("hello"), DEFAULT_FILE_FMODE); err != nil {
+			b.Fatal(err)
+		}
+
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := fls.Stat("/file.txt"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("without cache", func(b *testing.B) {
+		run(b, 0)
+	})
+
+	b.Run("with cache", func(b *testing.B) {
+		run(b, 100)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"syscall"
 
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/osfs"
@@ -20,8 +21,9 @@ var (
 		OS: *osfs.Default,
 	}
 
-	_ afs.Filesystem = osFs
-	_                = core.IWithSecondaryContext((*OsFilesystem)(nil))
+	_ afs.Filesystem            = osFs
+	_ afs.AvailableSpaceCapable = osFs
+	_                           = core.IWithSecondaryContext((*OsFilesystem)(nil))
 )
 
 type OsFilesystem struct {
@@ -52,6 +54,14 @@ func (fs OsFilesystem) Absolute(path string) (string, error) {
 	return filepath.Abs(path)
 }
 
+func (fs *OsFilesystem) AvailableSpace(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
+
 func (fs *OsFilesystem) WithSecondaryContext(ctx *core.Context) any {
 	if ctx == nil {
 		panic(errors.New("nil context"))
@@ -37,3 +37,14 @@ func TestOSFsWithContextFilesystem(t *testing.T) {
 		assert.Fail(t, result.String())
 	}
 }
+
+func TestOSFilesystemAvailableSpace(t *testing.T) {
+	availableSpace, err := GetOsFilesystem().AvailableSpace(t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Greater(t, availableSpace, int64(0))
+
+	_, err = GetOsFilesystem().AvailableSpace("/non-existing-dir-for-available-space-test")
+	assert.Error(t, err)
+}
@@ -1,6 +1,8 @@
 package fs_ns
 
 import (
+	"context"
+	"errors"
 	"maps"
 	"slices"
 	"sync"
@@ -15,6 +17,8 @@ import (
 const (
 	WATCHER_MANAGEMENT_TICK_INTERVAL = 25 * time.Millisecond
 	OLD_EVENT_MIN_AGE                = max(50*time.Millisecond, 2*WATCHER_MANAGEMENT_TICK_INTERVAL)
+
+	EVENT_SUBSCRIPTION_CHANNEL_SIZE = 100
 )
 
 var (
@@ -49,16 +53,54 @@ func isOldEvent(v Event) bool {
 }
 
 type VirtualFilesystemWatcher struct {
-	eventSource  *FilesystemEventSource
+	eventSource  *FilesystemEventSource //nil if the watcher has been created by a subscription
+	subscription *eventSubscription     //nil if the watcher has been created for an event source
 	creationTime time.Time
 	stopped      atomic.Bool
 }
 
 func (w *VirtualFilesystemWatcher) Close() error {
 	w.stopped.Store(true)
+	if w.subscription != nil {
+		w.subscription.close()
+	}
 	return nil
 }
 
+// An eventSubscription delivers the events matching a path pattern on a channel, see (*MetaFilesystem).Subscribe.
+type eventSubscription struct {
+	filter core.PathPattern
+	events chan Event
+	lock   sync.Mutex
+	closed bool
+}
+
+// deliver sends the event on the channel, the event is dropped if the channel is full.
+func (s *eventSubscription) deliver(event Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.events <- event:
+	default:
+	}
+}
+
+func (s *eventSubscription) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.events)
+}
+
 func (fls *MemFilesystem) Watcher(evs *FilesystemEventSource) *VirtualFilesystemWatcher {
 	watcher := &VirtualFilesystemWatcher{
 		eventSource:  evs,
@@ -105,6 +147,46 @@ func (fls *MetaFilesystem) Watcher(evs *FilesystemEventSource) *VirtualFilesyste
 		creationTime: time.Now(),
 	}
 
+	fls.addWatcher(watcher)
+	return watcher
+}
+
+// Subscribe returns a channel receiving the events concerning the paths matched by pathPattern (absolute),
+// events are dropped if the channel is full. The returned function ends the subscription, the subscription
+// is also ended when ctx is done or when the filesystem is closed. The channel is closed at the end of the subscription.
+func (fls *MetaFilesystem) Subscribe(ctx *core.Context, pathPattern core.PathPattern) (<-chan Event, func(), error) {
+	if fls.closed.Load() {
+		return nil, nil, ErrClosedFilesystem
+	}
+
+	if !pathPattern.IsAbsolute() {
+		return nil, nil, errors.New("the path pattern of a subscription should be absolute")
+	}
+
+	subscription := &eventSubscription{
+		filter: pathPattern,
+		events: make(chan Event, EVENT_SUBSCRIPTION_CHANNEL_SIZE),
+	}
+
+	watcher := &VirtualFilesystemWatcher{
+		subscription: subscription,
+		creationTime: time.Now(),
+	}
+
+	unsubscribe := func() {
+		watcher.Close()
+	}
+
+	ctx.OnDone(func(timeoutCtx context.Context, teardownStatus core.GracefulTeardownStatus) error {
+		watcher.Close()
+		return nil
+	})
+
+	fls.addWatcher(watcher)
+	return subscription.events, unsubscribe, nil
+}
+
+func (fls *MetaFilesystem) addWatcher(watcher *VirtualFilesystemWatcher) {
 	startWatcherManagingGoroutine()
 
 	fls.fsWatchersLock.Lock()
@@ -114,8 +196,6 @@ func (fls *MetaFilesystem) Watcher(evs *FilesystemEventSource) *VirtualFilesyste
 	watchedVirtualFilesystemsLock.Lock()
 	watchedVirtualFilesystems[fls] = struct{}{}
 	watchedVirtualFilesystemsLock.Unlock()
-
-	return watcher
 }
 
 func (fls *MetaFilesystem) Events() *memds.TSArrayQueue[Event] {
@@ -220,6 +300,16 @@ func informWatchersAboutEvents(filesystems map[WatchableVirtualFilesystem]struct
 
 		//inform watchers about the events
 		for _, w := range watchers {
+			if w.subscription != nil {
+				for _, event := range deduplicatedEvents {
+					//if the event happened before the subscription we ignore it.
+					if !time.Time(event.dateTime).Before(w.creationTime) && w.subscription.filter.Test(nil, event.path) {
+						w.subscription.deliver(event)
+					}
+				}
+				continue
+			}
+
 			handlers := w.eventSource.GetHandlers()
 
 			if w.eventSource.IsClosed() {
@@ -12,6 +12,9 @@ type TSArrayQueue[T any] struct {
 
 	autoRemoveCondition func(v T) bool
 	hasHadElements      bool
+
+	maxSize      int //0 if unbounded
+	droppedCount int64
 }
 
 func NewTSArrayQueue[T any]() *TSArrayQueue[T] {
@@ -21,12 +24,17 @@ func NewTSArrayQueue[T any]() *TSArrayQueue[T] {
 func NewTSArrayQueueWithConfig[T any](config TSArrayQueueConfig[T]) *TSArrayQueue[T] {
 	q := &TSArrayQueue[T]{}
 	q.autoRemoveCondition = config.AutoRemoveCondition
+	q.maxSize = max(config.MaxSize, 0)
 
 	return q
 }
 
 type TSArrayQueueConfig[T any] struct {
 	AutoRemoveCondition func(v T) bool
+
+	//Maximum number of elements in the queue, 0 means unbounded.
+	//When the maximum is exceeded the oldest elements are dropped.
+	MaxSize int
 }
 
 // Enqueue adds a value to the end of the queue
@@ -36,6 +44,7 @@ func (q *TSArrayQueue[T]) Enqueue(value T) {
 
 	q.elements = append(q.elements, value)
 	q.hasHadElements = true
+	q.dropOldestNoLock()
 }
 
 // EnqueueAutoRemove does the same as Enqueue but also removes all elements that validate the autoremove condition.
@@ -46,6 +55,7 @@ func (q *TSArrayQueue[T]) EnqueueAutoRemove(value T) {
 	q.elements = append(q.elements, value)
 	q.hasHadElements = true
 	q.autoRemoveNoLock()
+	q.dropOldestNoLock()
 }
 
 // Enqueue adds zero or more values to the end of the queue
@@ -57,6 +67,7 @@ func (q *TSArrayQueue[T]) EnqueueAll(values ...T) {
 	if len(values) > 0 {
 		q.hasHadElements = true
 	}
+	q.dropOldestNoLock()
 }
 
 // EnqueueAllAutoRemove does the same as EnqueueAllAutoRemove but also removes all elements that validate the autoremove condition.
@@ -69,6 +80,7 @@ func (q *TSArrayQueue[T]) EnqueueAllAutoRemove(values ...T) {
 		q.hasHadElements = true
 	}
 	q.autoRemoveNoLock()
+	q.dropOldestNoLock()
 }
 
 // Dequeue removes first element of the queue and returns it, or nil if queue is empty.
@@ -186,6 +198,27 @@ func (q *TSArrayQueue[T]) autoRemoveNoLock() {
 	}
 }
 
+func (q *TSArrayQueue[T]) dropOldestNoLock() {
+	if q.maxSize <= 0 || len(q.elements) <= q.maxSize {
+		return
+	}
+
+	dropped := len(q.elements) - q.maxSize
+
+	//shift the newest elements to the left.
+	copy(q.elements, q.elements[dropped:])
+	q.elements = q.elements[:q.maxSize]
+	q.droppedCount += int64(dropped)
+}
+
+// DroppedCount returns the number of elements that have been dropped because the queue was full.
+func (q *TSArrayQueue[T]) DroppedCount() int64 {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	return q.droppedCount
+}
+
 // Values returns all elements in the queue (FIFO order).
 func (q *TSArrayQueue[T]) Values() []T {
 	q.lock.RLock()
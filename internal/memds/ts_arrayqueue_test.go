@@ -105,6 +105,25 @@ func TestTSArrayQueue(t *testing.T) {
 			assert.Equal(t, []int{}, q.Values())
 		})
 
+		t.Run("max size", func(t *testing.T) {
+			q := NewTSArrayQueueWithConfig[int](TSArrayQueueConfig[int]{
+				MaxSize: 2,
+			})
+
+			q.Enqueue(1)
+			q.Enqueue(2)
+			assert.Equal(t, []int{1, 2}, q.Values())
+			assert.Zero(t, q.DroppedCount())
+
+			//the oldest element should be dropped.
+			q.Enqueue(3)
+			assert.Equal(t, []int{2, 3}, q.Values())
+			assert.EqualValues(t, 1, q.DroppedCount())
+
+			q.EnqueueAll(4, 5, 6)
+			assert.Equal(t, []int{5, 6}, q.Values())
+			assert.EqualValues(t, 4, q.DroppedCount())
+		})
 	})
 
 	t.Run("several goroutines", func(t *testing.T) {
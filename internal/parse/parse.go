@@ -3851,6 +3851,18 @@ func (p *parser) parseRuneRuneRange() Node {
 
 		if value == '\\' {
 			p.i++
+
+			if p.i >= p.len {
+				return &RuneLiteral{
+					NodeBase: NodeBase{
+						NodeSpan{start, p.i},
+						&ParsingError{UnspecifiedParsingError, UNTERMINATED_RUNE_LIT},
+						false,
+					},
+					Value: 0,
+				}
+			}
+
 			switch p.s[p.i] {
 			//same single character escapes as Golang
 			case 'a':
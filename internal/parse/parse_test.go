@@ -8338,6 +8338,24 @@ func testParse(
 			})
 		})
 
+		t.Run("rune literal : backslash at the end of the input", func(t *testing.T) {
+			n, err := parseChunk(t, `'\`, "")
+			assert.Error(t, err)
+			assert.EqualValues(t, &Chunk{
+				NodeBase: NodeBase{NodeSpan{0, 2}, nil, false},
+				Statements: []Node{
+					&RuneLiteral{
+						NodeBase: NodeBase{
+							NodeSpan{0, 2},
+							&ParsingError{UnspecifiedParsingError, UNTERMINATED_RUNE_LIT},
+							false,
+						},
+						Value: 0,
+					},
+				},
+			}, n)
+		})
+
 	})
 
 	t.Run("single letter", func(t *testing.T) {
@@ -8918,6 +8936,24 @@ func testParse(
 			}, n)
 		})
 
+		t.Run("slice expression in LHS", func(t *testing.T) {
+			//only identifiers are supported in the LHS, so there is no compound operator to check for slice expressions.
+			n, err := parseChunk(t, "assign s[0:1] b = x", "")
+			assert.Error(t, err)
+
+			if !assert.NotEmpty(t, n.Statements) {
+				return
+			}
+
+			assert.EqualValues(t, &MultiAssignment{
+				NodeBase: NodeBase{
+					NodeSpan{0, 13},
+					&ParsingError{UnspecifiedParsingError, ASSIGN_KEYWORD_SHOULD_BE_FOLLOWED_BY_IDENTS},
+					false,
+				},
+			}, n.Statements[0])
+		})
+
 		t.Run("missing value after equal sign", func(t *testing.T) {
 			n, err := parseChunk(t, "assign a =", "")
 			assert.Error(t, err)
@@ -61,6 +61,16 @@ func OpenRegistry(projectsDir string, openProjectsContext *core.Context) (*Regis
 }
 
 func (r *Registry) Close(ctx *core.Context) {
+	//close the filesystems of the open projects, this allows them to be re-opened.
+	r.openProjectsLock.Lock()
+	for _, project := range r.openProjects {
+		if closable, ok := project.liveFilesystem.(interface{ Close(ctx *core.Context) error }); ok {
+			closable.Close(ctx)
+		}
+	}
+	r.openProjects = map[core.ProjectID]*Project{}
+	r.openProjectsLock.Unlock()
+
 	r.metadata.Close()
 }
 
@@ -233,21 +243,23 @@ func (r *Registry) OpenProject(ctx *core.Context, params OpenProjectParams) (*Pr
 	if params.DevSideConfig.Cloudflare != nil {
 		cf, err := cloudflareprovider.New(project.id, params.DevSideConfig.Cloudflare)
 		if err != nil {
+			projectFS.Close(ctx)
 			return nil, fmt.Errorf("failed to create clouflare helper: %w", err)
 		}
 		project.cloudflare = cf
 	}
 
-	project.Share(nil)
-	r.openProjects[project.id] = project
-
 	projectDevDatabasesDir, err := r.getCreateDevDatabasesDir(project.id)
 	if err != nil {
+		projectFS.Close(ctx)
 		return nil, err
 	}
 
 	project.devDatabasesDirOnOsFs.Store(projectDevDatabasesDir)
 
+	project.Share(nil)
+	r.openProjects[project.id] = project
+
 	return project, nil
 }
 
@@ -97,9 +97,10 @@ func notifyDiagnostics(session *jsonrpc.Session, docURI defines.DocumentUri, usi
 			i++
 
 			return defines.Diagnostic{
-				Message:  err.Message,
-				Severity: &errSeverity,
-				Range:    rangeToLspRange(getPositionInPositionStackOrFirst(err.Location, fpath)),
+				Message:            err.Message,
+				Severity:           &errSeverity,
+				Range:              rangeToLspRange(getPositionInPositionStackOrFirst(err.Location, fpath)),
+				RelatedInformation: getStaticCheckErrorRelatedInformation(err, fpath, usingInoxFS),
 			}
 		})
 
@@ -137,9 +138,10 @@ func notifyDiagnostics(session *jsonrpc.Session, docURI defines.DocumentUri, usi
 			i++
 
 			return defines.Diagnostic{
-				Message:  err.Message,
-				Severity: &errSeverity,
-				Range:    rangeToLspRange(getPositionInPositionStackOrFirst(err.Location, fpath)),
+				Message:            err.Message,
+				Severity:           &errSeverity,
+				Range:              rangeToLspRange(getPositionInPositionStackOrFirst(err.Location, fpath)),
+				RelatedInformation: getStaticCheckErrorRelatedInformation(err, fpath, usingInoxFS),
 			}
 		})
 		diagnostics = append(diagnostics, staticCheckErrorDiagnostics...)
@@ -189,6 +191,37 @@ func notifyDiagnostics(session *jsonrpc.Session, docURI defines.DocumentUri, usi
 	return nil
 }
 
+// getStaticCheckErrorRelatedInformation returns the related locations of a static check error (e.g. the first definition
+// of a struct defined twice), nil is returned if the error has no related locations.
+func getStaticCheckErrorRelatedInformation(err *core.StaticCheckError, fpath string, usingInoxFS bool) *[]defines.DiagnosticRelatedInformation {
+	var relatedInfo []defines.DiagnosticRelatedInformation
+
+	for _, location := range err.RelatedLocations {
+		if len(location) == 0 {
+			continue
+		}
+
+		position := getPositionInPositionStackOrFirst(location, fpath)
+		uri, err := getFileURI(position.SourceName, usingInoxFS)
+		if err != nil {
+			continue
+		}
+
+		relatedInfo = append(relatedInfo, defines.DiagnosticRelatedInformation{
+			Location: defines.Location{
+				Uri:   uri,
+				Range: rangeToLspRange(position),
+			},
+			Message: "related location",
+		})
+	}
+
+	if len(relatedInfo) == 0 {
+		return nil
+	}
+	return &relatedInfo
+}
+
 func sendDiagnostics(session *jsonrpc.Session, docURI defines.DocumentUri, diagnostics []defines.Diagnostic) error {
 	return session.Notify(jsonrpc.NotificationMessage{
 		Method: "textDocument/publishDiagnostics",
@@ -0,0 +1,58 @@
+package projectserver
+
+import (
+	"testing"
+
+	"github.com/inoxlang/inox/internal/core"
+	"github.com/inoxlang/inox/internal/parse"
+	"github.com/inoxlang/inox/internal/projectserver/lsp/defines"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStaticCheckErrorRelatedInformation(t *testing.T) {
+
+	position := parse.SourcePositionRange{
+		SourceName:  "/main.ix",
+		StartLine:   2,
+		StartColumn: 8,
+		EndLine:     2,
+		EndColumn:   13,
+		Span:        parse.NodeSpan{Start: 19, End: 24},
+	}
+
+	t.Run("no related locations", func(t *testing.T) {
+		err := core.NewStaticCheckError("error", parse.SourcePositionStack{position})
+
+		assert.Nil(t, getStaticCheckErrorRelatedInformation(err, "/main.ix", true))
+	})
+
+	t.Run("related location", func(t *testing.T) {
+		err := core.NewStaticCheckError("error", parse.SourcePositionStack{position})
+		err.RelatedLocations = []parse.SourcePositionStack{{position}}
+
+		relatedInfo := getStaticCheckErrorRelatedInformation(err, "/main.ix", true)
+		if !assert.NotNil(t, relatedInfo) {
+			return
+		}
+
+		assert.Equal(t, []defines.DiagnosticRelatedInformation{
+			{
+				Location: defines.Location{
+					Uri:   defines.DocumentUri(INOX_FS_SCHEME + ":///main.ix"),
+					Range: rangeToLspRange(position),
+				},
+				Message: "related location",
+			},
+		}, *relatedInfo)
+	})
+
+	t.Run("locations that are not in a file should be ignored", func(t *testing.T) {
+		inMemoryPosition := position
+		inMemoryPosition.SourceName = "in-memory"
+
+		err := core.NewStaticCheckError("error", parse.SourcePositionStack{position})
+		err.RelatedLocations = []parse.SourcePositionStack{{inMemoryPosition}}
+
+		assert.Nil(t, getStaticCheckErrorRelatedInformation(err, "/main.ix", true))
+	})
+}